@@ -1,6 +1,16 @@
 // Package adc defines the ADC interface for Analog Digital Converters.
 package adc
 
+import (
+	"fmt"
+
+	"github.com/advancedclimatesystems/io/bus"
+)
+
+// ErrBusFailure matches the errors returned by an ADC when the connection to
+// the ADC failed. It is the same error as bus.ErrFailure.
+var ErrBusFailure = bus.ErrFailure
+
 // InputType defines how an ADC samples the input signal. A single-ended input
 // samples its input in the range from the ground (0V) to Vref, that is  the
 // reference input. A 10-bits ADC with a reference input of 5V has a precision
@@ -24,6 +34,12 @@ const (
 // ADC is the interface that wraps an OutputCode and Voltage method. The first
 // returns the digital output code of a channel. The latter returns the voltage
 // of a channel.
+//
+// Implementations document whether they are safe for concurrent use. An ADC
+// that is, performs the transactions of a conversion without them being
+// interleaved with those of other calls. Note that OutputCodeContext and
+// VoltageContext leave a conversion running when ctx is done, so a following
+// call can run concurrently with it.
 type ADC interface {
 	// OutputCode queries the channel and returns its digital output code.
 	// This should be the raw value. If exists, PGA should not be applied.
@@ -31,3 +47,54 @@ type ADC interface {
 	// Voltage queries the channel of an ADC and returns its voltage.
 	Voltage(channel int) (float64, error)
 }
+
+// MultiChannelADC is an ADC that reads all its channels with a single call.
+type MultiChannelADC interface {
+	ADC
+
+	// OutputCodeAll queries all channels and returns their digital output
+	// codes. Element i of the result is the output code of channel i.
+	OutputCodeAll() ([]int, error)
+	// VoltageAll queries all channels and returns their voltages. Element
+	// i of the result is the voltage of channel i.
+	VoltageAll() ([]float64, error)
+}
+
+// Sized is an ADC that reports its number of channels. The channels of every
+// ADC in this repository are numbered from 0, so generic code can read all
+// channels of a Sized ADC like this:
+//
+//	for ch := 0; ch < a.Channels(); ch++ {
+//		v, err := a.Voltage(ch)
+//		...
+//	}
+type Sized interface {
+	ADC
+
+	// Channels returns the number of channels.
+	Channels() int
+}
+
+// ErrInvalidChannel is the error returned by an ADC that is queried for a
+// channel it doesn't have. Use errors.Is(err, ErrInvalidChannel{}) to check
+// for an invalid channel, regardless of the channel.
+type ErrInvalidChannel struct {
+	// Channel is the channel that has been queried.
+	Channel int
+	// Channels is the number of channels of the ADC.
+	Channels int
+}
+
+func (e ErrInvalidChannel) Error() string {
+	if e.Channels == 1 {
+		return fmt.Sprintf("channel %d is invalid, ADC has only 1 channel", e.Channel)
+	}
+	return fmt.Sprintf("channel %d is invalid, ADC has only %d channels", e.Channel, e.Channels)
+}
+
+// Is reports whether target is an ErrInvalidChannel with the same channel and
+// number of channels, or a zero ErrInvalidChannel.
+func (e ErrInvalidChannel) Is(target error) bool {
+	t, ok := target.(ErrInvalidChannel)
+	return ok && (t == ErrInvalidChannel{} || t == e)
+}
@@ -0,0 +1,12 @@
+package adc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrInvalidChannel(t *testing.T) {
+	assert.EqualError(t, ErrInvalidChannel{Channel: 4, Channels: 4}, "channel 4 is invalid, ADC has only 4 channels")
+	assert.EqualError(t, ErrInvalidChannel{Channel: 0, Channels: 1}, "channel 0 is invalid, ADC has only 1 channel")
+}
@@ -0,0 +1,92 @@
+package adc
+
+import (
+	"fmt"
+	"math"
+)
+
+// Calibration corrects the voltage of a channel. First the offset is
+// subtracted and the result is multiplied by the gain. When Coefficients is
+// set, the corrected voltage v is then used as input of the polynomial
+// Coefficients[0] + Coefficients[1]*v + Coefficients[2]*v^2 + ...
+type Calibration struct {
+	// Offset is the voltage read when the true voltage is 0.
+	Offset float64
+	// Gain corrects the gain error. An ADC that reads 1.7% too high has a
+	// gain of 1 / 1.017. A gain of 0 is treated as 1, so a Calibration
+	// without a gain only corrects the offset.
+	Gain float64
+	// Coefficients are the coefficients of a polynomial that corrects
+	// non-linearity, starting with the constant term.
+	Coefficients []float64
+}
+
+// apply returns the corrected voltage.
+func (c Calibration) apply(v float64) float64 {
+	gain := c.Gain
+	if gain == 0 {
+		gain = 1
+	}
+	v = (v - c.Offset) * gain
+
+	if len(c.Coefficients) == 0 {
+		return v
+	}
+
+	// Evaluate the polynomial using Horner's method.
+	var r float64
+	for i := len(c.Coefficients) - 1; i >= 0; i-- {
+		r = r*v + c.Coefficients[i]
+	}
+	return r
+}
+
+// validate returns an error if one of the values is NaN or infinite.
+func (c Calibration) validate() error {
+	values := append([]float64{c.Offset, c.Gain}, c.Coefficients...)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("%v is not a valid value for a calibration", v)
+		}
+	}
+	return nil
+}
+
+// calibrated is an ADC that corrects the voltages of another ADC.
+type calibrated struct {
+	ADC
+	cal map[int]Calibration
+}
+
+// Calibrated returns an ADC that corrects the voltages of inner using the
+// calibration of the channel. Channels without a calibration aren't
+// corrected. The output codes aren't corrected either. An error is returned
+// when a calibration contains a NaN or infinite value.
+func Calibrated(inner ADC, cal map[int]Calibration) (ADC, error) {
+	c := calibrated{
+		ADC: inner,
+		cal: make(map[int]Calibration, len(cal)),
+	}
+
+	for ch, calibration := range cal {
+		if err := calibration.validate(); err != nil {
+			return nil, fmt.Errorf("invalid calibration for channel %d: %w", ch, err)
+		}
+		c.cal[ch] = calibration
+	}
+
+	return c, nil
+}
+
+// Voltage returns the corrected voltage of a channel.
+func (c calibrated) Voltage(channel int) (float64, error) {
+	v, err := c.ADC.Voltage(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	if calibration, ok := c.cal[channel]; ok {
+		return calibration.apply(v), nil
+	}
+	return v, nil
+}
@@ -0,0 +1,98 @@
+package adc
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// distortedADC is a mocked ADC. The voltage of a channel is distorted by
+// distort.
+type distortedADC struct {
+	v       float64
+	distort func(v float64) float64
+	err     error
+}
+
+func (a distortedADC) OutputCode(channel int) (int, error) {
+	return 42, a.err
+}
+
+func (a distortedADC) Voltage(channel int) (float64, error) {
+	return a.distort(a.v), a.err
+}
+
+func TestCalibrated(t *testing.T) {
+	var tests = []struct {
+		distort func(v float64) float64
+		cal     Calibration
+	}{
+		// A transducer that reads 0.012V at true zero and has a gain
+		// error of 1.7%.
+		{
+			func(v float64) float64 { return v*1.017 + 0.012 },
+			Calibration{Offset: 0.012, Gain: 1 / 1.017},
+		},
+		{
+			func(v float64) float64 { return v - 0.3 },
+			Calibration{Offset: -0.3},
+		},
+		{
+			func(v float64) float64 { return 2*v + 1 },
+			Calibration{Offset: 1, Coefficients: []float64{0, 0.5}},
+		},
+	}
+
+	for _, test := range tests {
+		for _, v := range []float64{0, 0.5, 1, 3.3} {
+			inner := distortedADC{v: v, distort: test.distort}
+			a, err := Calibrated(inner, map[int]Calibration{1: test.cal})
+			assert.Nil(t, err)
+
+			got, err := a.Voltage(1)
+			assert.Nil(t, err)
+			assert.InDelta(t, v, got, 1e-9)
+
+			// Channels without calibration pass through.
+			got, err = a.Voltage(2)
+			assert.Nil(t, err)
+			assert.Equal(t, test.distort(v), got)
+
+			code, err := a.OutputCode(1)
+			assert.Nil(t, err)
+			assert.Equal(t, 42, code)
+		}
+	}
+}
+
+func TestCalibrationPolynomial(t *testing.T) {
+	c := Calibration{Coefficients: []float64{1, 2, 3}}
+	assert.Equal(t, 1+2*2+3*4.0, c.apply(2))
+
+	c = Calibration{Offset: 1, Gain: 2, Coefficients: []float64{0, 1, 1}}
+	assert.Equal(t, 4+16.0, c.apply(3))
+}
+
+func TestCalibratedWithInvalidCalibration(t *testing.T) {
+	inner := distortedADC{}
+
+	var tests = []struct {
+		cal Calibration
+		err string
+	}{
+		{Calibration{Offset: math.NaN()}, "invalid calibration for channel 3: NaN is not a valid value for a calibration"},
+		{Calibration{Gain: math.Inf(1)}, "invalid calibration for channel 3: +Inf is not a valid value for a calibration"},
+		{Calibration{Coefficients: []float64{0, math.Inf(-1)}}, "invalid calibration for channel 3: -Inf is not a valid value for a calibration"},
+	}
+
+	for _, test := range tests {
+		_, err := Calibrated(inner, map[int]Calibration{0: {}, 3: test.cal})
+		assert.EqualError(t, err, test.err)
+	}
+
+	a, _ := Calibrated(distortedADC{distort: math.Abs, err: errors.New("some error")}, nil)
+	_, err := a.Voltage(0)
+	assert.EqualError(t, err, "some error")
+}
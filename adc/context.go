@@ -0,0 +1,60 @@
+package adc
+
+import "context"
+
+// OutputCodeContext calls a.OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read. Most buses can't abort a transfer that
+// is in progress, so the read itself continues in the background until it
+// finishes. The caller regains control though, even if the bus hangs forever.
+func OutputCodeContext(ctx context.Context, a ADC, channel int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	type result struct {
+		code int
+		err  error
+	}
+
+	// The channel is buffered, so the goroutine can always send its result
+	// and exit, also when nobody is receiving anymore.
+	c := make(chan result, 1)
+	go func() {
+		code, err := a.OutputCode(channel)
+		c <- result{code, err}
+	}()
+
+	select {
+	case r := <-c:
+		return r.code, r.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// VoltageContext calls a.Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read. Like OutputCodeContext the read itself continues
+// in the background until it finishes.
+func VoltageContext(ctx context.Context, a ADC, channel int) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	type result struct {
+		v   float64
+		err error
+	}
+
+	c := make(chan result, 1)
+	go func() {
+		v, err := a.Voltage(channel)
+		c <- result{v, err}
+	}()
+
+	select {
+	case r := <-c:
+		return r.v, r.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
@@ -0,0 +1,69 @@
+package adc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testADC is a mocked ADC. It returns the channel as output code and half of
+// the channel as voltage, after blocking until block is closed.
+type testADC struct {
+	block chan struct{}
+	err   error
+}
+
+func (a testADC) OutputCode(channel int) (int, error) {
+	<-a.block
+	return channel, a.err
+}
+
+func (a testADC) Voltage(channel int) (float64, error) {
+	<-a.block
+	return float64(channel) / 2, a.err
+}
+
+func TestOutputCodeContext(t *testing.T) {
+	block := make(chan struct{})
+	close(block)
+
+	code, err := OutputCodeContext(context.Background(), testADC{block: block}, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, code)
+
+	_, err = OutputCodeContext(context.Background(), testADC{block: block, err: errors.New("error")}, 3)
+	assert.EqualError(t, err, "error")
+
+	// A read that hangs is abandoned when the context expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = OutputCodeContext(ctx, testADC{block: make(chan struct{})}, 3)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// No read is done with a context that is done already.
+	_, err = OutputCodeContext(ctx, testADC{}, 3)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestVoltageContext(t *testing.T) {
+	block := make(chan struct{})
+	close(block)
+
+	v, err := VoltageContext(context.Background(), testADC{block: block}, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.5, v)
+
+	_, err = VoltageContext(context.Background(), testADC{block: block, err: errors.New("error")}, 3)
+	assert.EqualError(t, err, "error")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err = VoltageContext(ctx, testADC{block: make(chan struct{})}, 3)
+	assert.Equal(t, context.Canceled, err)
+}
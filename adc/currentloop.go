@@ -0,0 +1,94 @@
+package adc
+
+import "fmt"
+
+const (
+	// underRange is the current below which a 4-20 mA current loop is
+	// considered broken, see NAMUR NE 43.
+	underRange = 3.6
+
+	// overRange is the current above which the transmitter of a 4-20 mA
+	// current loop is considered saturated or failing.
+	overRange = 21
+)
+
+// ErrUnderRange is the error returned by CurrentLoop when the current is below
+// 3.6 mA. Usually the wire is broken or the transmitter isn't powered.
+type ErrUnderRange struct {
+	// MilliAmps is the measured current.
+	MilliAmps float64
+}
+
+func (e ErrUnderRange) Error() string {
+	return fmt.Sprintf("current of %.2f mA is under range, the current loop might be broken", e.MilliAmps)
+}
+
+// ErrOverRange is the error returned by CurrentLoop when the current is above
+// 21 mA. Usually the transmitter is saturated or shorted.
+type ErrOverRange struct {
+	// MilliAmps is the measured current.
+	MilliAmps float64
+}
+
+func (e ErrOverRange) Error() string {
+	return fmt.Sprintf("current of %.2f mA is over range, the transmitter might be saturated", e.MilliAmps)
+}
+
+// CurrentLoop reads a 4-20 mA current loop using the voltage over a sense
+// resistor. It maps 4 mA to the minimum value and 20 mA to the maximum value
+// of the transmitter.
+type CurrentLoop struct {
+	// Tolerance is the current in mA outside the 4-20 mA range for which
+	// the value is clamped to the minimum or maximum value. A transmitter
+	// that outputs 3.98 mA at its minimum reads as the minimum with a
+	// tolerance of 0.02 mA. Currents further outside the range, but
+	// between 3.6 mA and 21 mA, result in a value outside the range.
+	Tolerance float64
+
+	adc       ADC
+	channel   int
+	senseOhms float64
+	min       float64
+	max       float64
+}
+
+// NewCurrentLoop returns a CurrentLoop that reads the voltage over a sense
+// resistor of senseOhms from a channel of a. minValue is the value of the
+// transmitter at 4 mA, maxValue the value at 20 mA.
+func NewCurrentLoop(a ADC, channel int, senseOhms, minValue, maxValue float64) *CurrentLoop {
+	return &CurrentLoop{
+		adc:       a,
+		channel:   channel,
+		senseOhms: senseOhms,
+		min:       minValue,
+		max:       maxValue,
+	}
+}
+
+// Read returns the value of the transmitter and the current in mA. An
+// ErrUnderRange or ErrOverRange is returned, together with the current, when
+// the current is outside 3.6 - 21 mA.
+func (c *CurrentLoop) Read() (value float64, milliAmps float64, err error) {
+	if c.senseOhms <= 0 {
+		return 0, 0, fmt.Errorf("sense resistor of %v ohm is invalid, it must be greater than 0", c.senseOhms)
+	}
+
+	v, err := c.adc.Voltage(c.channel)
+	if err != nil {
+		return 0, 0, err
+	}
+	milliAmps = v / c.senseOhms * 1000
+
+	switch {
+	case milliAmps < underRange:
+		return 0, milliAmps, ErrUnderRange{MilliAmps: milliAmps}
+	case milliAmps > overRange:
+		return 0, milliAmps, ErrOverRange{MilliAmps: milliAmps}
+	case milliAmps < 4 && milliAmps >= 4-c.Tolerance:
+		return c.min, milliAmps, nil
+	case milliAmps > 20 && milliAmps <= 20+c.Tolerance:
+		return c.max, milliAmps, nil
+	}
+
+	return c.min + (milliAmps-4)/16*(c.max-c.min), milliAmps, nil
+}
@@ -0,0 +1,91 @@
+package adc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrentLoop(t *testing.T) {
+	var tests = []struct {
+		// v is the voltage over the 250 ohm sense resistor.
+		v         float64
+		tolerance float64
+		value     float64
+		milliAmps float64
+	}{
+		{1, 0, 0, 4},
+		{5, 0, 100, 20},
+		{3, 0, 50, 12},
+		{2, 0, 25, 8},
+
+		// Between 3.6 and 4 mA and between 20 and 21 mA the value is
+		// outside the range of the transmitter.
+		{0.95, 0, -1.25, 3.8},
+		{5.1, 0, 102.5, 20.4},
+
+		// Unless it's within the tolerance.
+		{0.995, 0.05, 0, 3.98},
+		{5.01, 0.05, 100, 20.04},
+		{0.95, 0.05, -1.25, 3.8},
+	}
+
+	for _, test := range tests {
+		c := NewCurrentLoop(distortedADC{v: test.v, distort: identity}, 1, 250, 0, 100)
+		c.Tolerance = test.tolerance
+
+		value, milliAmps, err := c.Read()
+		assert.Nil(t, err)
+		assert.InDelta(t, test.value, value, 1e-9, "%v V", test.v)
+		assert.InDelta(t, test.milliAmps, milliAmps, 1e-9, "%v V", test.v)
+	}
+}
+
+func TestCurrentLoopInverted(t *testing.T) {
+	// A transmitter can map 4 mA to a higher value than 20 mA.
+	c := NewCurrentLoop(distortedADC{v: 0.6, distort: identity}, 1, 50, 40, -10)
+	value, milliAmps, err := c.Read()
+	assert.Nil(t, err)
+	assert.InDelta(t, 12, milliAmps, 1e-9)
+	assert.InDelta(t, 15, value, 1e-9)
+}
+
+func TestCurrentLoopOutOfRange(t *testing.T) {
+	// A broken wire.
+	c := NewCurrentLoop(distortedADC{v: 0, distort: identity}, 1, 250, 0, 100)
+	_, milliAmps, err := c.Read()
+	assert.Equal(t, float64(0), milliAmps)
+	assert.EqualError(t, err, "current of 0.00 mA is under range, the current loop might be broken")
+	assert.Equal(t, ErrUnderRange{MilliAmps: 0}, err)
+
+	// Just under range.
+	c = NewCurrentLoop(distortedADC{v: 0.875, distort: identity}, 1, 250, 0, 100)
+	c.Tolerance = 1
+	_, _, err = c.Read()
+	var under ErrUnderRange
+	assert.True(t, errors.As(err, &under))
+	assert.InDelta(t, 3.5, under.MilliAmps, 1e-9)
+
+	// A saturated transmitter.
+	c = NewCurrentLoop(distortedADC{v: 5.5, distort: identity}, 1, 250, 0, 100)
+	_, milliAmps, err = c.Read()
+	assert.InDelta(t, 22, milliAmps, 1e-9)
+	assert.EqualError(t, err, "current of 22.00 mA is over range, the transmitter might be saturated")
+	var over ErrOverRange
+	assert.True(t, errors.As(err, &over))
+}
+
+func TestCurrentLoopErrors(t *testing.T) {
+	c := NewCurrentLoop(distortedADC{err: errors.New("some error"), distort: identity}, 1, 250, 0, 100)
+	_, _, err := c.Read()
+	assert.EqualError(t, err, "some error")
+
+	c = NewCurrentLoop(distortedADC{v: 1, distort: identity}, 1, 0, 0, 100)
+	_, _, err = c.Read()
+	assert.EqualError(t, err, "sense resistor of 0 ohm is invalid, it must be greater than 0")
+}
+
+func identity(v float64) float64 {
+	return v
+}
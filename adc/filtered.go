@@ -0,0 +1,145 @@
+package adc
+
+import "sync"
+
+// Filter is an ADC that filters the voltages of another ADC. It keeps the
+// state of the filter per channel, so every call of Voltage adds a voltage to
+// the filter of that channel. The output codes aren't filtered.
+//
+// Voltages of different channels can be read concurrently.
+type Filter struct {
+	ADC
+
+	// newState creates the state of the filter of a channel.
+	newState func() filterState
+
+	m        sync.Mutex
+	channels map[int]*channelFilter
+}
+
+// filterState is the state of the filter of a single channel.
+type filterState interface {
+	// add adds a voltage and returns the filtered voltage.
+	add(v float64) float64
+}
+
+// channelFilter guards the state of the filter of a channel.
+type channelFilter struct {
+	m     sync.Mutex
+	state filterState
+}
+
+// Filtered returns a Filter that returns the mean of the last window voltages
+// of a channel. Until window voltages have been read, the mean of the voltages
+// read so far is returned. A window smaller than 1 is treated as 1, which
+// means no filtering at all.
+func Filtered(inner ADC, window int) *Filter {
+	if window < 1 {
+		window = 1
+	}
+
+	return newFilter(inner, func() filterState {
+		return &movingAverage{values: make([]float64, window)}
+	})
+}
+
+// FilteredEWMA returns a Filter that returns the exponentially weighted moving
+// average of the voltages of a channel. Every voltage v changes the average to
+// alpha*v + (1-alpha)*average, so a smaller alpha filters stronger. The first
+// voltage of a channel is used as the initial average. An alpha outside the
+// range 0 < alpha <= 1 is treated as 1, which means no filtering at all.
+func FilteredEWMA(inner ADC, alpha float64) *Filter {
+	if alpha <= 0 || alpha > 1 {
+		alpha = 1
+	}
+
+	return newFilter(inner, func() filterState {
+		return &ewma{alpha: alpha}
+	})
+}
+
+func newFilter(inner ADC, newState func() filterState) *Filter {
+	return &Filter{
+		ADC:      inner,
+		newState: newState,
+		channels: make(map[int]*channelFilter),
+	}
+}
+
+// Voltage reads the voltage of a channel and returns the filtered voltage.
+func (f *Filter) Voltage(channel int) (float64, error) {
+	v, err := f.ADC.Voltage(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	c := f.channel(channel)
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.state.add(v), nil
+}
+
+// Reset clears the state of the filter of a channel. The next voltage read
+// isn't affected by voltages read before.
+func (f *Filter) Reset(channel int) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	delete(f.channels, channel)
+}
+
+// channel returns the filter of a channel, it's created when needed.
+func (f *Filter) channel(channel int) *channelFilter {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	c, ok := f.channels[channel]
+	if !ok {
+		c = &channelFilter{state: f.newState()}
+		f.channels[channel] = c
+	}
+	return c
+}
+
+// movingAverage is the mean of the last len(values) voltages.
+type movingAverage struct {
+	// values is a ring buffer, next is the index of the oldest value.
+	values []float64
+	next   int
+	n      int
+}
+
+func (m *movingAverage) add(v float64) float64 {
+	m.values[m.next] = v
+	m.next = (m.next + 1) % len(m.values)
+	if m.n < len(m.values) {
+		m.n++
+	}
+
+	// The sum is calculated every time to prevent rounding errors from
+	// adding up.
+	var sum float64
+	for _, v := range m.values[:m.n] {
+		sum += v
+	}
+	return sum / float64(m.n)
+}
+
+// ewma is an exponentially weighted moving average.
+type ewma struct {
+	alpha   float64
+	average float64
+	started bool
+}
+
+func (e *ewma) add(v float64) float64 {
+	if !e.started {
+		e.average = v
+		e.started = true
+		return v
+	}
+
+	e.average = e.alpha*v + (1-e.alpha)*e.average
+	return e.average
+}
@@ -0,0 +1,104 @@
+package adc
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stepADC is a mocked ADC. Every channel returns 0V for the first steps reads
+// of that channel, after that it returns 1V.
+type stepADC struct {
+	m     sync.Mutex
+	steps int
+	reads map[int]int
+}
+
+func newStepADC(steps int) *stepADC {
+	return &stepADC{steps: steps, reads: make(map[int]int)}
+}
+
+func (a *stepADC) OutputCode(channel int) (int, error) {
+	return 7, nil
+}
+
+func (a *stepADC) Voltage(channel int) (float64, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	a.reads[channel]++
+	if a.reads[channel] > a.steps {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func readVoltages(t *testing.T, a ADC, channel, n int) []float64 {
+	var vs []float64
+	for i := 0; i < n; i++ {
+		v, err := a.Voltage(channel)
+		assert.Nil(t, err)
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+func TestFiltered(t *testing.T) {
+	f := Filtered(newStepADC(2), 4)
+
+	// During warm up the available voltages are averaged. Once the step
+	// arrives, the mean converges to 1 within the window.
+	assert.Equal(t, []float64{0, 0, 1.0 / 3, 0.5, 0.75, 1, 1}, readVoltages(t, f, 0, 7))
+
+	code, err := f.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, code)
+
+	// Reset only clears the state of a single channel.
+	readVoltages(t, f, 1, 2)
+	f.Reset(0)
+	assert.Equal(t, []float64{1}, readVoltages(t, f, 0, 1))
+	assert.Equal(t, []float64{1.0 / 3}, readVoltages(t, f, 1, 1))
+
+	// A window of 0 doesn't filter.
+	f = Filtered(newStepADC(1), 0)
+	assert.Equal(t, []float64{0, 1, 1}, readVoltages(t, f, 0, 3))
+}
+
+func TestFilteredEWMA(t *testing.T) {
+	f := FilteredEWMA(newStepADC(1), 0.5)
+	assert.Equal(t, []float64{0, 0.5, 0.75, 0.875, 0.9375}, readVoltages(t, f, 0, 5))
+
+	f.Reset(0)
+	assert.Equal(t, []float64{1, 1}, readVoltages(t, f, 0, 2))
+
+	for _, alpha := range []float64{0, -1, 1.5} {
+		f = FilteredEWMA(newStepADC(1), alpha)
+		assert.Equal(t, []float64{0, 1}, readVoltages(t, f, 0, 2))
+	}
+}
+
+func TestFilteredConcurrently(t *testing.T) {
+	f := Filtered(newStepADC(50), 10)
+
+	var wg sync.WaitGroup
+	for ch := 0; ch < 4; ch++ {
+		wg.Add(1)
+		go func(ch int) {
+			defer wg.Done()
+			vs := readVoltages(t, f, ch, 100)
+			assert.Equal(t, 0.0, vs[49])
+			assert.Equal(t, 1.0, vs[99])
+		}(ch)
+	}
+	wg.Wait()
+}
+
+func TestFilteredWithErrors(t *testing.T) {
+	f := Filtered(distortedADC{distort: math.Abs, err: errors.New("some error")}, 3)
+	_, err := f.Voltage(0)
+	assert.EqualError(t, err, "some error")
+}
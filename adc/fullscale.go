@@ -0,0 +1,34 @@
+package adc
+
+import "math"
+
+// FullScaleMode defines how an output code is converted to a voltage. An ADC
+// with n bits converts its input range to 2^n output codes.
+//
+// With CountBased, the default, the voltage of a code is code * Vref / 2^n.
+// Every code is a step of exactly 1 LSB, which is how the datasheets of most
+// ADCs define the transfer function. The highest code is 1 LSB below Vref
+// though, so Vref itself is never reported.
+//
+// With CodeBased, the voltage of a code is code * Vref / (2^n - 1). The
+// highest code reports exactly Vref, like many other libraries do, at the
+// cost of steps that are slightly larger than 1 LSB.
+type FullScaleMode int
+
+const (
+	// CountBased divides by the number of codes, 2^n.
+	CountBased FullScaleMode = iota
+
+	// CodeBased divides by the highest code, 2^n - 1.
+	CodeBased
+)
+
+// Denominator returns the number a code of an ADC with the given number of
+// bits is divided by to get a fraction of the reference voltage.
+func (m FullScaleMode) Denominator(bits int) float64 {
+	d := math.Pow(2, float64(bits))
+	if m == CodeBased {
+		return d - 1
+	}
+	return d
+}
@@ -0,0 +1,14 @@
+package adc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullScaleModeDenominator(t *testing.T) {
+	assert.Equal(t, float64(1024), CountBased.Denominator(10))
+	assert.Equal(t, float64(1023), CodeBased.Denominator(10))
+	assert.Equal(t, float64(4096), FullScaleMode(0).Denominator(12))
+	assert.Equal(t, float64(65535), CodeBased.Denominator(16))
+}
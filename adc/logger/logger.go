@@ -0,0 +1,86 @@
+// Package logger periodically samples channels of an ADC and writes their
+// voltages to a CSV file.
+package logger
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/advancedclimatesystems/io/adc"
+)
+
+// DefaultFlushInterval is the FlushInterval of a Logger created by NewLogger.
+const DefaultFlushInterval = 10 * time.Second
+
+// Logger samples the voltage of channels of an ADC and writes them as CSV
+// records, in the format of adc.RecordCSV. The first column of a record is the
+// time of the sample, the following columns contain the voltages of the
+// channels. When a channel can't be read its column contains the error
+// instead.
+//
+// Unlike adc.Record, a Logger doesn't flush every record to the underlying
+// writer, which saves writes to flash storage.
+type Logger struct {
+	// FlushInterval is the minimum time between 2 flushes of the records to
+	// the underlying writer. When 0, every record is flushed immediately.
+	FlushInterval time.Duration
+
+	w        io.Writer
+	adc      adc.ADC
+	channels []int
+}
+
+// NewLogger returns a Logger that writes the voltages of the channels of a to
+// w.
+func NewLogger(w io.Writer, a adc.ADC, channels []int) *Logger {
+	return &Logger{
+		FlushInterval: DefaultFlushInterval,
+		w:             w,
+		adc:           a,
+		channels:      channels,
+	}
+}
+
+// Run writes a header and then samples the channels every interval, until ctx
+// is done, see adc.Record. Pending records are always flushed before Run
+// returns. Run returns ctx.Err() when ctx is done, or an error when the records
+// can't be written.
+func (l *Logger) Run(ctx context.Context, interval time.Duration) error {
+	w := &flushWriter{
+		w:         bufio.NewWriter(l.w),
+		interval:  l.FlushInterval,
+		lastFlush: time.Now(),
+	}
+
+	err := adc.Record(ctx, l.adc, l.channels, interval, w, adc.RecordCSV)
+	if ferr := w.w.Flush(); ferr != nil && err == ctx.Err() {
+		return fmt.Errorf("failed to flush records: %w", ferr)
+	}
+	return err
+}
+
+// flushWriter buffers the writes to an io.Writer and flushes them at most every
+// interval.
+type flushWriter struct {
+	w         *bufio.Writer
+	interval  time.Duration
+	lastFlush time.Time
+}
+
+func (f *flushWriter) Write(b []byte) (int, error) {
+	n, err := f.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+
+	if time.Since(f.lastFlush) >= f.interval {
+		if err := f.w.Flush(); err != nil {
+			return n, err
+		}
+		f.lastFlush = time.Now()
+	}
+	return n, nil
+}
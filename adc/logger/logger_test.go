@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testADC is a mocked ADC. The voltage of a channel is the channel divided by 2
+// plus the number of reads of the ADC, or err if set. It cancels the context
+// after the given number of reads.
+type testADC struct {
+	mu     sync.Mutex
+	reads  int
+	cancel func()
+	after  int
+	err    error
+}
+
+func (a *testADC) OutputCode(channel int) (int, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (a *testADC) Voltage(channel int) (float64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.reads++
+	if a.reads == a.after {
+		a.cancel()
+	}
+	if a.err != nil {
+		return 0, a.err
+	}
+	return float64(channel)/2 + float64(a.reads), nil
+}
+
+// testWriter is a bytes.Buffer that can be used concurrently and counts the
+// number of writes.
+type testWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+	err    error
+}
+
+func (w *testWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.writes++
+	return w.buf.Write(b)
+}
+
+// withoutTime returns the CSV records in s without the time of the samples.
+// It fails the test when the time of a sample isn't formatted using RFC 3339.
+func withoutTime(t *testing.T, s string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, ",", 2)
+		_, err := time.Parse(time.RFC3339Nano, fields[0])
+		assert.Nil(t, err)
+		lines[i+1] = fields[1]
+	}
+	return strings.Join(lines, "")
+}
+
+func TestLoggerRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// The context is cancelled while reading the third record, so that
+	// record is dropped.
+	a := &testADC{cancel: cancel, after: 5}
+	w := &testWriter{}
+
+	l := NewLogger(w, a, []int{1, 3})
+	assert.Equal(t, DefaultFlushInterval, l.FlushInterval)
+
+	err := l.Run(ctx, time.Millisecond)
+	assert.Equal(t, context.Canceled, err)
+
+	expected := "time,channel 1,channel 3\n" +
+		"1.5,3.5\n" +
+		"3.5,5.5\n"
+	assert.Equal(t, expected, withoutTime(t, w.buf.String()))
+
+	// Nothing has been flushed before the context has been cancelled.
+	assert.Equal(t, 1, w.writes)
+}
+
+func TestLoggerRunFlushesPeriodically(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &testADC{cancel: cancel, after: 5}
+	w := &testWriter{}
+
+	l := NewLogger(w, a, []int{0, 0})
+	l.FlushInterval = 0
+
+	// The header and every record are flushed immediately.
+	err := l.Run(ctx, time.Millisecond)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 3, w.writes)
+	assert.Equal(t, "time,channel 0,channel 0\n1,2\n3,4\n", withoutTime(t, w.buf.String()))
+}
+
+func TestLoggerRunWithErrors(t *testing.T) {
+	// A channel that can't be read doesn't stop the logger, the error is
+	// logged instead of the voltage.
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &testADC{cancel: cancel, after: 3, err: errors.New("some error")}
+	w := &testWriter{}
+
+	err := NewLogger(w, a, []int{2}).Run(ctx, time.Millisecond)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, "time,channel 2\nsome error\nsome error\n", withoutTime(t, w.buf.String()))
+
+	a = &testADC{}
+	w.err = errors.New("disk full")
+	l := NewLogger(w, a, []int{2})
+	l.FlushInterval = 0
+
+	err = l.Run(context.Background(), time.Millisecond)
+	assert.EqualError(t, err, "failed to write header: disk full")
+
+	// Pending records are flushed when the context is done.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = NewLogger(w, a, []int{2}).Run(ctx, time.Millisecond)
+	assert.EqualError(t, err, "failed to flush records: disk full")
+}
+
+func ExampleLogger() {
+	f, err := os.Create("/var/log/adc.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	// a is any adc.ADC, like a microchip.MCP3008.
+	var a *testADC
+
+	// Sample channel 0 and 1 every second for an hour.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	l := NewLogger(f, a, []int{0, 1})
+	if err := l.Run(ctx, time.Second); err != context.DeadlineExceeded {
+		log.Fatalf("logging stopped: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+// +build linux
+
+package adc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/advancedclimatesystems/io/gpio"
+)
+
+// muxed is an ADC that reads the inputs of an analog multiplexer, like the
+// CD74HC4067, connected to a channel of another ADC.
+type muxed struct {
+	adc     ADC
+	channel int
+	pins    []gpio.GPIO
+	settle  time.Duration
+
+	// sleep waits for the output of the multiplexer to settle.
+	sleep func(time.Duration)
+
+	// m serializes selecting an input and reading it.
+	m sync.Mutex
+}
+
+// NewMuxed returns an ADC that reads the inputs of an analog multiplexer. The
+// output of the multiplexer is connected to channel adcChannel of a, its
+// select inputs to selectPins. The first pin is the least significant select
+// input, S0 of the CD74HC4067. The pins must be outputs.
+//
+// Channel n of the returned ADC is input n of the multiplexer, so it has 2 ^
+// len(selectPins) channels, it implements Sized. Reading a channel sets the select pins to the
+// binary value of the channel, waits settle for the output of the multiplexer
+// to settle and then reads the channel of a. The output code is the output
+// code of a.
+//
+// The ADC is safe for concurrent use, a channel is selected and read without
+// other channels being selected in between.
+func NewMuxed(a ADC, adcChannel int, selectPins []gpio.GPIO, settle time.Duration) ADC {
+	return &muxed{
+		adc:     a,
+		channel: adcChannel,
+		pins:    selectPins,
+		settle:  settle,
+		sleep:   time.Sleep,
+	}
+}
+
+// Channels returns the number of inputs of the multiplexer.
+func (m *muxed) Channels() int {
+	return 1 << uint(len(m.pins))
+}
+
+func (m *muxed) OutputCode(channel int) (int, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if err := m.selectInput(channel); err != nil {
+		return 0, err
+	}
+	return m.adc.OutputCode(m.channel)
+}
+
+func (m *muxed) Voltage(channel int) (float64, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if err := m.selectInput(channel); err != nil {
+		return 0, err
+	}
+	return m.adc.Voltage(m.channel)
+}
+
+// selectInput sets the select pins to the channel and waits for the output of
+// the multiplexer to settle. The caller must hold m.m.
+func (m *muxed) selectInput(channel int) error {
+	channels := m.Channels()
+	if channel < 0 || channel >= channels {
+		return ErrInvalidChannel{Channel: channel, Channels: channels}
+	}
+
+	for i, p := range m.pins {
+		set := p.SetLow
+		if channel>>uint(i)&1 == 1 {
+			set = p.SetHigh
+		}
+
+		if err := set(); err != nil {
+			return fmt.Errorf("failed to select input %d: %w", channel, err)
+		}
+	}
+
+	m.sleep(m.settle)
+	return nil
+}
@@ -0,0 +1,119 @@
+// +build linux
+
+package adc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/stretchr/testify/assert"
+)
+
+// events records what happens in a test.
+type events []string
+
+func (e *events) add(format string, args ...interface{}) {
+	*e = append(*e, fmt.Sprintf(format, args...))
+}
+
+// selectPin is a mocked gpio.GPIO that records the values written to it.
+type selectPin struct {
+	gpio.GPIO
+	name   string
+	events *events
+	err    error
+}
+
+func (p *selectPin) SetHigh() error {
+	p.events.add("%s high", p.name)
+	return p.err
+}
+
+func (p *selectPin) SetLow() error {
+	p.events.add("%s low", p.name)
+	return p.err
+}
+
+// recordingADC is a mocked ADC that records the channels read.
+type recordingADC struct {
+	events *events
+}
+
+func (a recordingADC) OutputCode(channel int) (int, error) {
+	a.events.add("read code of channel %d", channel)
+	return 42, nil
+}
+
+func (a recordingADC) Voltage(channel int) (float64, error) {
+	a.events.add("read voltage of channel %d", channel)
+	return 1.5, nil
+}
+
+func newMuxed(e *events) (*muxed, []*selectPin) {
+	var pins []*selectPin
+	var selectPins []gpio.GPIO
+	for i := 0; i < 4; i++ {
+		p := &selectPin{name: fmt.Sprintf("S%d", i), events: e}
+		pins = append(pins, p)
+		selectPins = append(selectPins, p)
+	}
+
+	m := NewMuxed(recordingADC{events: e}, 3, selectPins, time.Millisecond).(*muxed)
+	m.sleep = func(d time.Duration) {
+		e.add("sleep %v", d)
+	}
+	return m, pins
+}
+
+func TestMuxed(t *testing.T) {
+	e := new(events)
+	m, _ := newMuxed(e)
+	assert.Equal(t, 16, m.Channels())
+
+	v, err := m.Voltage(11)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.5, v)
+	assert.Equal(t, events{
+		"S0 high",
+		"S1 high",
+		"S2 low",
+		"S3 high",
+		"sleep 1ms",
+		"read voltage of channel 3",
+	}, *e)
+
+	*e = nil
+	code, err := m.OutputCode(4)
+	assert.Nil(t, err)
+	assert.Equal(t, 42, code)
+	assert.Equal(t, events{
+		"S0 low",
+		"S1 low",
+		"S2 high",
+		"S3 low",
+		"sleep 1ms",
+		"read code of channel 3",
+	}, *e)
+}
+
+func TestMuxedErrors(t *testing.T) {
+	e := new(events)
+	m, pins := newMuxed(e)
+
+	for _, ch := range []int{-1, 16} {
+		_, err := m.Voltage(ch)
+		assert.Equal(t, ErrInvalidChannel{Channel: ch, Channels: 16}, err)
+		_, err = m.OutputCode(ch)
+		assert.Equal(t, ErrInvalidChannel{Channel: ch, Channels: 16}, err)
+	}
+	assert.Nil(t, *e)
+
+	// The channel isn't read if it can't be selected.
+	pins[1].err = errors.New("some error")
+	_, err := m.Voltage(15)
+	assert.EqualError(t, err, "failed to select input 15: some error")
+	assert.Equal(t, events{"S0 high", "S1 high"}, *e)
+}
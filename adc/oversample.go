@@ -0,0 +1,58 @@
+package adc
+
+import (
+	"math"
+	"sort"
+)
+
+// Reducer reduces the output codes of a number of conversions to a single
+// output code.
+type Reducer func(codes []int) int
+
+// Mean returns the mean of the codes, rounded to the nearest integer.
+func Mean(codes []int) int {
+	sum := 0
+	for _, c := range codes {
+		sum += c
+	}
+	return int(math.Round(float64(sum) / float64(len(codes))))
+}
+
+// Median returns the median of the codes. Unlike the mean, the median isn't
+// affected by a single spike. With an even number of codes, the mean of the 2
+// middle codes is returned, rounded to the nearest integer. The order of codes
+// is changed.
+func Median(codes []int) int {
+	sort.Ints(codes)
+
+	n := len(codes)
+	if n%2 == 1 {
+		return codes[n/2]
+	}
+	return Mean(codes[n/2-1 : n/2+1])
+}
+
+// Oversample calls read n times and reduces the output codes using reduce. When
+// reduce is nil, Mean is used. When n is 1 or lower, read is called once and
+// its result is returned as is. The first error returned by read aborts the
+// sampling.
+func Oversample(n int, reduce Reducer, read func() (int, error)) (int, error) {
+	if n <= 1 {
+		return read()
+	}
+
+	if reduce == nil {
+		reduce = Mean
+	}
+
+	codes := make([]int, n)
+	for i := range codes {
+		code, err := read()
+		if err != nil {
+			return 0, err
+		}
+		codes[i] = code
+	}
+
+	return reduce(codes), nil
+}
@@ -0,0 +1,61 @@
+package adc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMean(t *testing.T) {
+	assert.Equal(t, 2, Mean([]int{1, 2, 3}))
+	assert.Equal(t, 3, Mean([]int{2, 3}))
+	assert.Equal(t, 2, Mean([]int{1, 2, 2, 2}))
+	assert.Equal(t, -3, Mean([]int{-2, -3}))
+}
+
+func TestMedian(t *testing.T) {
+	assert.Equal(t, 2, Median([]int{3, 1000, 2, 1, 2}))
+	assert.Equal(t, 3, Median([]int{4, 1, 2, 1000}))
+	assert.Equal(t, 7, Median([]int{7}))
+}
+
+func TestOversample(t *testing.T) {
+	var tests = []struct {
+		n      int
+		reduce Reducer
+		code   int
+		reads  int
+	}{
+		{0, nil, 10, 1},
+		{1, nil, 10, 1},
+		{2, nil, 15, 2},
+		{4, nil, 275, 4},
+		{4, Median, 25, 4},
+	}
+
+	for _, test := range tests {
+		codes := []int{10, 20, 30, 1040}
+		reads := 0
+		read := func() (int, error) {
+			reads++
+			return codes[reads-1], nil
+		}
+
+		code, err := Oversample(test.n, test.reduce, read)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+		assert.Equal(t, test.reads, reads)
+	}
+
+	reads := 0
+	_, err := Oversample(4, nil, func() (int, error) {
+		reads++
+		if reads == 2 {
+			return 0, errors.New("some error")
+		}
+		return 1, nil
+	})
+	assert.EqualError(t, err, "some error")
+	assert.Equal(t, 2, reads)
+}
@@ -0,0 +1,146 @@
+package adc
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// RecordFormat is the format of the rows written by Record.
+type RecordFormat int
+
+const (
+	// RecordCSV writes a header followed by a CSV record per sample. The
+	// first column is the time of the sample, the following columns
+	// contain the voltages of the channels. When a channel can't be read
+	// its column contains the error instead.
+	RecordCSV RecordFormat = iota
+
+	// RecordJSON writes a JSON object per line, like:
+	//
+	//	{"time":"2020-05-04T12:00:00Z","channels":[{"channel":0,"voltage":1.5},{"channel":1,"error":"some error"}]}
+	RecordJSON
+)
+
+// now returns the time of a sample written by Record.
+var now = time.Now
+
+// recordedChannel is the JSON representation of a channel in a row of Record.
+type recordedChannel struct {
+	Channel int      `json:"channel"`
+	Voltage *float64 `json:"voltage,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// recordedRow is the JSON representation of a row of Record.
+type recordedRow struct {
+	Time     string            `json:"time"`
+	Channels []recordedChannel `json:"channels"`
+}
+
+// Record samples the voltages of channels of a every interval and writes them
+// as timestamped rows to w in the given format, until ctx is done. The time of
+// a sample is formatted using RFC 3339. Every row is flushed once it's been
+// written.
+//
+// An error while reading a channel doesn't stop the recording, it's written
+// in the row instead of the voltage. Record returns ctx.Err() when ctx is done,
+// or an error when a row can't be written. Nothing is written when interval
+// isn't positive or the format is invalid, an error is returned instead.
+//
+// Package adc/logger writes rows in the format of RecordCSV too, but flushes
+// them less often.
+func Record(ctx context.Context, a ADC, channels []int, interval time.Duration, w io.Writer, format RecordFormat) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval of %v is invalid, it must be positive", interval)
+	}
+
+	var write func(row recordedRow) error
+	switch format {
+	case RecordCSV:
+		cw := csv.NewWriter(w)
+		header := []string{"time"}
+		for _, ch := range channels {
+			header = append(header, fmt.Sprintf("channel %d", ch))
+		}
+		cw.Write(header)
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return fmt.Errorf("failed to write header: %w", err)
+		}
+
+		write = func(row recordedRow) error {
+			return writeCSV(cw, row)
+		}
+	case RecordJSON:
+		enc := json.NewEncoder(w)
+		write = func(row recordedRow) error {
+			return enc.Encode(row)
+		}
+	default:
+		return fmt.Errorf("record format %d is invalid", format)
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		row, ok := sampleRow(ctx, a, channels)
+		if !ok {
+			return ctx.Err()
+		}
+		if err := write(row); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// sampleRow reads all channels. It returns false when ctx is done before all
+// channels have been read.
+func sampleRow(ctx context.Context, a ADC, channels []int) (recordedRow, bool) {
+	row := recordedRow{
+		Time:     now().Format(time.RFC3339Nano),
+		Channels: make([]recordedChannel, len(channels)),
+	}
+
+	for i, ch := range channels {
+		row.Channels[i].Channel = ch
+
+		v, err := VoltageContext(ctx, a, ch)
+		if ctx.Err() != nil {
+			return row, false
+		}
+		if err != nil {
+			row.Channels[i].Error = err.Error()
+			continue
+		}
+		row.Channels[i].Voltage = &v
+	}
+	return row, true
+}
+
+// writeCSV writes row as a CSV record and flushes it.
+func writeCSV(w *csv.Writer, row recordedRow) error {
+	record := []string{row.Time}
+	for _, ch := range row.Channels {
+		if ch.Voltage == nil {
+			record = append(record, ch.Error)
+			continue
+		}
+		record = append(record, strconv.FormatFloat(*ch.Voltage, 'f', -1, 64))
+	}
+
+	w.Write(record)
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,107 @@
+package adc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordADC is a mocked ADC. The voltage of a channel is the channel plus
+// the number of reads divided by 4. Channel 7 always fails. It cancels the
+// context after the given number of reads.
+type recordADC struct {
+	m      sync.Mutex
+	reads  int
+	cancel func()
+	after  int
+}
+
+func (a *recordADC) OutputCode(channel int) (int, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (a *recordADC) Voltage(channel int) (float64, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	a.reads++
+	if a.reads == a.after {
+		a.cancel()
+	}
+	if channel == 7 {
+		return 0, fmt.Errorf("channel %d failed", channel)
+	}
+	return float64(channel) + float64(a.reads)/4, nil
+}
+
+// record runs Record with a fixed clock until the ADC has been read n times.
+func record(channels []int, n int, w *bytes.Buffer, format RecordFormat) error {
+	t := time.Date(2020, 5, 4, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time {
+		t = t.Add(time.Second)
+		return t
+	}
+	defer func() { now = time.Now }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := &recordADC{cancel: cancel, after: n}
+	return Record(ctx, a, channels, time.Millisecond, w, format)
+}
+
+func TestRecordCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := record([]int{0, 7, 3}, 7, &buf, RecordCSV)
+	assert.Equal(t, context.Canceled, err)
+
+	assert.Equal(t, "time,channel 0,channel 7,channel 3\n"+
+		"2020-05-04T12:00:01Z,0.25,channel 7 failed,3.75\n"+
+		"2020-05-04T12:00:02Z,1,channel 7 failed,4.5\n", buf.String())
+}
+
+func TestRecordJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := record([]int{0, 7}, 5, &buf, RecordJSON)
+	assert.Equal(t, context.Canceled, err)
+
+	assert.Equal(t, `{"time":"2020-05-04T12:00:01Z","channels":[{"channel":0,"voltage":0.25},{"channel":7,"error":"channel 7 failed"}]}`+"\n"+
+		`{"time":"2020-05-04T12:00:02Z","channels":[{"channel":0,"voltage":0.75},{"channel":7,"error":"channel 7 failed"}]}`+"\n", buf.String())
+}
+
+// errWriter is an io.Writer that fails after the given number of writes.
+type errWriter struct {
+	writes int
+}
+
+func (w *errWriter) Write(b []byte) (int, error) {
+	if w.writes == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.writes--
+	return len(b), nil
+}
+
+func TestRecordErrors(t *testing.T) {
+	a := distortedADC{v: 1, distort: identity}
+	ctx := context.Background()
+
+	err := Record(ctx, a, []int{1}, time.Millisecond, &errWriter{}, RecordCSV)
+	assert.EqualError(t, err, "failed to write header: disk full")
+
+	err = Record(ctx, a, []int{1}, time.Millisecond, &errWriter{writes: 1}, RecordCSV)
+	assert.EqualError(t, err, "failed to write row: disk full")
+
+	err = Record(ctx, a, []int{1}, time.Millisecond, &errWriter{writes: 2}, RecordJSON)
+	assert.EqualError(t, err, "failed to write row: disk full")
+
+	err = Record(ctx, a, []int{1}, time.Millisecond, &errWriter{}, RecordFormat(5))
+	assert.EqualError(t, err, "record format 5 is invalid")
+
+	err = Record(ctx, a, []int{1}, 0, &errWriter{}, RecordCSV)
+	assert.EqualError(t, err, "interval of 0s is invalid, it must be positive")
+}
@@ -0,0 +1,146 @@
+package adc
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSamplerBuffer is the Buffer of a Sampler created by NewSampler.
+const DefaultSamplerBuffer = 16
+
+// Sample is a single reading of a channel of an ADC.
+type Sample struct {
+	Channel int
+	Code    int
+	Voltage float64
+	// Time is the moment the reading has been started.
+	Time time.Time
+	// Err is the error that occurred while reading the channel. When set,
+	// Code and Voltage are 0.
+	Err error
+}
+
+// Sampler reads a channel of an ADC at a fixed rate.
+type Sampler struct {
+	// dropped must be the first field, so it's 64 bit aligned on 32 bit
+	// platforms.
+	dropped uint64
+
+	// Buffer is the number of samples that are buffered for a slow
+	// consumer. When the buffer is full, the oldest sample is dropped.
+	Buffer int
+
+	adc      ADC
+	channel  int
+	interval time.Duration
+}
+
+// NewSampler returns a Sampler that reads channel of a every interval. An
+// error is returned when interval isn't positive, rather than sampling as fast
+// as possible, which would keep the ADC and a CPU busy.
+func NewSampler(a ADC, channel int, interval time.Duration) (*Sampler, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval of %v is invalid, it must be positive", interval)
+	}
+
+	return &Sampler{
+		Buffer:   DefaultSamplerBuffer,
+		adc:      a,
+		channel:  channel,
+		interval: interval,
+	}, nil
+}
+
+// Start starts sampling in a goroutine. The first sample is read immediately.
+// The samples are send on the returned channel, which is closed once ctx is
+// done and the goroutine has stopped.
+//
+// The sampler never blocks on a slow consumer. When the buffer of the channel
+// is full, the oldest sample is dropped to make room for the newest. Use
+// Dropped to find out how many samples have been dropped.
+//
+// When the ADC implements Resolution() float64, the voltage is calculated from
+// the output code. Otherwise both the output code and voltage are read.
+func (s *Sampler) Start(ctx context.Context) <-chan Sample {
+	buf := s.Buffer
+	if buf < 1 {
+		buf = 1
+	}
+	c := make(chan Sample, buf)
+
+	go func() {
+		defer close(c)
+
+		t := time.NewTicker(s.interval)
+		defer t.Stop()
+
+		for {
+			sample := s.read(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			s.send(c, sample)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+
+	return c
+}
+
+// Dropped returns the number of samples that have been dropped, because the
+// consumer was too slow.
+func (s *Sampler) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// read reads the channel once.
+func (s *Sampler) read(ctx context.Context) Sample {
+	sample := Sample{
+		Channel: s.channel,
+		Time:    time.Now(),
+	}
+
+	code, err := OutputCodeContext(ctx, s.adc, s.channel)
+	if err != nil {
+		sample.Err = err
+		return sample
+	}
+
+	var v float64
+	if r, ok := s.adc.(interface{ Resolution() float64 }); ok {
+		v = float64(code) * r.Resolution()
+	} else if v, err = VoltageContext(ctx, s.adc, s.channel); err != nil {
+		sample.Err = err
+		return sample
+	}
+
+	sample.Code = code
+	sample.Voltage = v
+	return sample
+}
+
+// send sends sample on c. When c is full, the oldest sample is dropped.
+func (s *Sampler) send(c chan Sample, sample Sample) {
+	for {
+		select {
+		case c <- sample:
+			return
+		default:
+		}
+
+		// The consumer might have received a sample in the meantime, so
+		// this receive mustn't block either.
+		select {
+		case <-c:
+			atomic.AddUint64(&s.dropped, 1)
+		default:
+		}
+	}
+}
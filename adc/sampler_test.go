@@ -0,0 +1,142 @@
+package adc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingADC is a mocked ADC that returns the number of reads as output
+// code.
+type countingADC struct {
+	mu    sync.Mutex
+	reads int
+	err   error
+}
+
+func (a *countingADC) OutputCode(channel int) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.reads++
+	return a.reads, a.err
+}
+
+func (a *countingADC) Voltage(channel int) (float64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (a *countingADC) Resolution() float64 {
+	return 0.5
+}
+
+func TestSampler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interval := 10 * time.Millisecond
+	s, err := NewSampler(&countingADC{}, 3, interval)
+	assert.Nil(t, err)
+	c := s.Start(ctx)
+
+	var samples []Sample
+	for i := 0; i < 4; i++ {
+		samples = append(samples, <-c)
+	}
+
+	for i, sample := range samples {
+		assert.Nil(t, sample.Err)
+		assert.Equal(t, 3, sample.Channel)
+		assert.Equal(t, i+1, sample.Code)
+		assert.Equal(t, float64(i+1)/2, sample.Voltage)
+	}
+
+	// A ticker might deliver a tick a bit early, but on average it is
+	// never faster than its interval.
+	assert.True(t, samples[3].Time.Sub(samples[0].Time) >= 3*interval-interval/2)
+	assert.Equal(t, uint64(0), s.Dropped())
+
+	// The channel is closed after cancelling the context.
+	cancel()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-c:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("channel hasn't been closed after cancelling context")
+		}
+	}
+}
+
+func TestSamplerDropsOldest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	a := &countingADC{}
+	s, _ := NewSampler(a, 0, time.Millisecond)
+	s.Buffer = 2
+	c := s.Start(ctx)
+
+	// Don't consume anything for a while.
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	var codes []int
+	for sample := range c {
+		codes = append(codes, sample.Code)
+	}
+
+	a.mu.Lock()
+	reads := a.reads
+	a.mu.Unlock()
+
+	// Only the newest samples are left.
+	assert.Len(t, codes, 2)
+	assert.True(t, codes[0] < codes[1])
+	assert.True(t, codes[1] >= reads-1)
+	assert.True(t, s.Dropped() > 0)
+
+	// Every read is either consumed or dropped, except for the read that
+	// was in progress when the context was cancelled.
+	lost := reads - len(codes) - int(s.Dropped())
+	assert.True(t, lost == 0 || lost == 1, "%d reads are lost", lost)
+}
+
+func TestSamplerWithErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, _ := NewSampler(&countingADC{err: errors.New("some error")}, 1, time.Millisecond)
+	c := s.Start(ctx)
+
+	sample := <-c
+	assert.EqualError(t, sample.Err, "some error")
+	assert.Equal(t, 1, sample.Channel)
+	assert.Equal(t, 0, sample.Code)
+
+	// Without a Resolution method, the voltage is read from the ADC.
+	block := make(chan struct{})
+	close(block)
+
+	s, _ = NewSampler(testADC{block: block}, 4, time.Millisecond)
+	c = s.Start(ctx)
+	sample = <-c
+	assert.Nil(t, sample.Err)
+	assert.Equal(t, 4, sample.Code)
+	assert.Equal(t, 2.0, sample.Voltage)
+}
+
+func TestNewSamplerInvalidInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		s, err := NewSampler(&countingADC{}, 0, interval)
+		assert.Nil(t, s)
+		assert.EqualError(t, err, fmt.Sprintf("interval of %v is invalid, it must be positive", interval))
+	}
+}
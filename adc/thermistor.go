@@ -0,0 +1,154 @@
+package adc
+
+import (
+	"fmt"
+	"math"
+)
+
+// railMargin is the fraction of the supply voltage within which the voltage of
+// a thermistor divider is considered to be at a rail.
+const railMargin = 0.005
+
+// kelvin is 0°C in Kelvin.
+const kelvin = 273.15
+
+// ErrOpenCircuit is the error returned by Thermistor when the voltage of the
+// divider is at the rail that means the thermistor isn't connected.
+type ErrOpenCircuit struct {
+	// Volts is the measured voltage.
+	Volts float64
+}
+
+func (e ErrOpenCircuit) Error() string {
+	return fmt.Sprintf("voltage of %.3f V means the thermistor is an open circuit", e.Volts)
+}
+
+// ErrShortCircuit is the error returned by Thermistor when the voltage of the
+// divider is at the rail that means the thermistor is shorted.
+type ErrShortCircuit struct {
+	// Volts is the measured voltage.
+	Volts float64
+}
+
+func (e ErrShortCircuit) Error() string {
+	return fmt.Sprintf("voltage of %.3f V means the thermistor is a short circuit", e.Volts)
+}
+
+// ThermistorConfig describes an NTC thermistor in a voltage divider with a
+// fixed resistor. The resistance of the thermistor is converted to a
+// temperature using either the Beta model or, when one of A, B and C is set,
+// the Steinhart-Hart equation.
+type ThermistorConfig struct {
+	// DividerOhms is the resistance of the fixed resistor.
+	DividerOhms float64
+	// SupplyVolts is the voltage over the divider.
+	SupplyVolts float64
+	// HighSide is true when the thermistor is between the supply and the
+	// input of the ADC, and false when it's between the input and ground.
+	HighSide bool
+
+	// Beta is the B constant of the thermistor in Kelvin.
+	Beta float64
+	// NominalOhms is the resistance of the thermistor at NominalCelsius,
+	// usually 25°C.
+	NominalOhms    float64
+	NominalCelsius float64
+
+	// A, B and C are the coefficients of the Steinhart-Hart equation
+	// 1/T = A + B*ln(R) + C*ln(R)^3.
+	A, B, C float64
+}
+
+// steinhartHart returns true if the Steinhart-Hart equation is used.
+func (c ThermistorConfig) steinhartHart() bool {
+	return c.A != 0 || c.B != 0 || c.C != 0
+}
+
+// validate returns an error if the config can't be used.
+func (c ThermistorConfig) validate() error {
+	if c.DividerOhms <= 0 {
+		return fmt.Errorf("divider resistance of %v ohm is invalid, it must be greater than 0", c.DividerOhms)
+	}
+	if c.SupplyVolts <= 0 {
+		return fmt.Errorf("supply voltage of %v V is invalid, it must be greater than 0", c.SupplyVolts)
+	}
+	if !c.steinhartHart() && (c.Beta <= 0 || c.NominalOhms <= 0) {
+		return fmt.Errorf("either Beta and NominalOhms or the Steinhart-Hart coefficients must be set")
+	}
+	return nil
+}
+
+// celsius returns the temperature at which the thermistor has resistance r.
+func (c ThermistorConfig) celsius(r float64) float64 {
+	ln := math.Log(r)
+	if c.steinhartHart() {
+		return 1/(c.A+c.B*ln+c.C*ln*ln*ln) - kelvin
+	}
+
+	t0 := c.NominalCelsius + kelvin
+	return 1/(1/t0+(ln-math.Log(c.NominalOhms))/c.Beta) - kelvin
+}
+
+// Thermistor reads the temperature of an NTC thermistor in a voltage divider.
+type Thermistor struct {
+	adc     ADC
+	channel int
+	cfg     ThermistorConfig
+}
+
+// NewThermistor returns a Thermistor that reads the voltage of the divider
+// described by cfg from a channel of a. An error is returned when cfg lacks a
+// resistance, a supply voltage or the coefficients of the thermistor.
+func NewThermistor(a ADC, channel int, cfg ThermistorConfig) (*Thermistor, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid thermistor config: %w", err)
+	}
+
+	return &Thermistor{
+		adc:     a,
+		channel: channel,
+		cfg:     cfg,
+	}, nil
+}
+
+// Resistance returns the resistance of the thermistor in ohm. An
+// ErrOpenCircuit or ErrShortCircuit is returned when the voltage is within
+// 0.5% of the supply voltage from a rail.
+func (t *Thermistor) Resistance() (float64, error) {
+	v, err := t.adc.Voltage(t.channel)
+	if err != nil {
+		return 0, err
+	}
+
+	s := t.cfg.SupplyVolts
+	atSupply := v >= s*(1-railMargin)
+	atGround := v <= s*railMargin
+
+	if t.cfg.HighSide {
+		switch {
+		case atGround:
+			return 0, ErrOpenCircuit{Volts: v}
+		case atSupply:
+			return 0, ErrShortCircuit{Volts: v}
+		}
+		return t.cfg.DividerOhms * (s - v) / v, nil
+	}
+
+	switch {
+	case atSupply:
+		return 0, ErrOpenCircuit{Volts: v}
+	case atGround:
+		return 0, ErrShortCircuit{Volts: v}
+	}
+	return t.cfg.DividerOhms * v / (s - v), nil
+}
+
+// Temperature returns the temperature of the thermistor in degrees Celsius.
+// See Resistance for the errors returned.
+func (t *Thermistor) Temperature() (celsius float64, err error) {
+	r, err := t.Resistance()
+	if err != nil {
+		return 0, err
+	}
+	return t.cfg.celsius(r), nil
+}
@@ -0,0 +1,124 @@
+package adc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// dividerVoltage returns the voltage of a divider of a thermistor with
+// resistance r and cfg.
+func dividerVoltage(cfg ThermistorConfig, r float64) float64 {
+	if cfg.HighSide {
+		return cfg.SupplyVolts * cfg.DividerOhms / (r + cfg.DividerOhms)
+	}
+	return cfg.SupplyVolts * r / (r + cfg.DividerOhms)
+}
+
+func TestThermistor(t *testing.T) {
+	beta := ThermistorConfig{
+		DividerOhms:    10000,
+		SupplyVolts:    3.3,
+		Beta:           3950,
+		NominalOhms:    10000,
+		NominalCelsius: 25,
+	}
+	steinhartHart := ThermistorConfig{
+		DividerOhms: 10000,
+		SupplyVolts: 3.3,
+		A:           1.129148e-3,
+		B:           2.34125e-4,
+		C:           8.76741e-8,
+	}
+
+	var tests = []struct {
+		cfg     ThermistorConfig
+		ohms    float64
+		celsius float64
+		delta   float64
+	}{
+		{beta, 105384.69, -20, 0.001},
+		{beta, 33620.60, 0, 0.001},
+		{beta, 10000, 25, 0.001},
+		{beta, 3588.18, 50, 0.001},
+		{beta, 697.52, 100, 0.001},
+
+		// The resistances of a 10k thermistor from its datasheet.
+		{steinhartHart, 95327, -20, 0.5},
+		{steinhartHart, 32650, 0, 0.5},
+		{steinhartHart, 10000, 25, 0.5},
+		{steinhartHart, 3603, 50, 0.5},
+		{steinhartHart, 680.6, 100, 0.5},
+	}
+
+	for _, test := range tests {
+		for _, highSide := range []bool{false, true} {
+			cfg := test.cfg
+			cfg.HighSide = highSide
+
+			v := dividerVoltage(cfg, test.ohms)
+			th, err := NewThermistor(distortedADC{v: v, distort: identity}, 1, cfg)
+			assert.Nil(t, err)
+
+			r, err := th.Resistance()
+			assert.Nil(t, err)
+			assert.InDelta(t, test.ohms, r, 1e-6)
+
+			c, err := th.Temperature()
+			assert.Nil(t, err)
+			assert.InDelta(t, test.celsius, c, test.delta, "%v ohm", test.ohms)
+		}
+	}
+}
+
+func TestThermistorAtRail(t *testing.T) {
+	cfg := ThermistorConfig{DividerOhms: 10000, SupplyVolts: 3.3, Beta: 3950, NominalOhms: 10000, NominalCelsius: 25}
+
+	var tests = []struct {
+		highSide bool
+		v        float64
+		err      error
+	}{
+		{false, 3.3, ErrOpenCircuit{Volts: 3.3}},
+		{false, 3.29, ErrOpenCircuit{Volts: 3.29}},
+		{false, 0, ErrShortCircuit{Volts: 0}},
+		{true, 0.01, ErrOpenCircuit{Volts: 0.01}},
+		{true, 3.3, ErrShortCircuit{Volts: 3.3}},
+	}
+
+	for _, test := range tests {
+		cfg.HighSide = test.highSide
+		th, _ := NewThermistor(distortedADC{v: test.v, distort: identity}, 1, cfg)
+
+		_, err := th.Temperature()
+		assert.Equal(t, test.err, err)
+	}
+
+	th, _ := NewThermistor(distortedADC{v: 3.3, distort: identity}, 1, cfg)
+	_, err := th.Temperature()
+	assert.EqualError(t, err, "voltage of 3.300 V means the thermistor is a short circuit")
+	var short ErrShortCircuit
+	assert.True(t, errors.As(err, &short))
+}
+
+func TestThermistorErrors(t *testing.T) {
+	var tests = []struct {
+		cfg ThermistorConfig
+		err string
+	}{
+		{ThermistorConfig{SupplyVolts: 3.3, Beta: 3950, NominalOhms: 10000}, "invalid thermistor config: divider resistance of 0 ohm is invalid, it must be greater than 0"},
+		{ThermistorConfig{DividerOhms: 10000, Beta: 3950, NominalOhms: 10000}, "invalid thermistor config: supply voltage of 0 V is invalid, it must be greater than 0"},
+		{ThermistorConfig{DividerOhms: 10000, SupplyVolts: 3.3, Beta: 3950}, "invalid thermistor config: either Beta and NominalOhms or the Steinhart-Hart coefficients must be set"},
+	}
+
+	for _, test := range tests {
+		_, err := NewThermistor(distortedADC{distort: identity}, 1, test.cfg)
+		assert.EqualError(t, err, test.err)
+	}
+
+	cfg := ThermistorConfig{DividerOhms: 10000, SupplyVolts: 3.3, Beta: 3950, NominalOhms: 10000, NominalCelsius: 25}
+	th, _ := NewThermistor(distortedADC{err: errors.New("some error"), distort: identity}, 1, cfg)
+	_, err := th.Temperature()
+	assert.EqualError(t, err, "some error")
+}
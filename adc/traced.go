@@ -0,0 +1,91 @@
+package adc
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Tracer is an ADC that logs every call of another ADC, with the channel, the
+// output code or voltage, the duration of the call and the error. It's meant
+// for debugging analog issues in the field.
+//
+// Tracer is safe for concurrent use if the inner ADC is. LogEvery isn't
+// guarded, so set it before the first call and don't change it after that.
+type Tracer struct {
+	// calls is the number of calls so far. It's the first field to keep
+	// it 64 bits aligned on 32 bits platforms.
+	calls uint64
+
+	// LogEvery limits the logging of calls in loops that read at a high
+	// rate, only 1 of every LogEvery calls is logged. Calls that fail are
+	// always logged. When 0 or 1 every call is logged.
+	LogEvery int
+
+	inner ADC
+	logf  func(format string, args ...interface{})
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// Traced returns a Tracer that logs the calls of inner using logf, like
+// log.Printf. When logf is nil nothing is logged and the calls go straight to
+// inner.
+func Traced(inner ADC, logf func(format string, args ...interface{})) *Tracer {
+	return &Tracer{
+		inner: inner,
+		logf:  logf,
+		now:   time.Now,
+	}
+}
+
+// OutputCode returns the output code of a channel of the inner ADC.
+func (t *Tracer) OutputCode(channel int) (int, error) {
+	if t.logf == nil {
+		return t.inner.OutputCode(channel)
+	}
+
+	start := t.now()
+	code, err := t.inner.OutputCode(channel)
+	if !t.sample(err) {
+		return code, err
+	}
+
+	d := t.now().Sub(start)
+	if err != nil {
+		t.logf("adc: OutputCode(%d) failed after %v: %v", channel, d, err)
+	} else {
+		t.logf("adc: OutputCode(%d) = %d in %v", channel, code, d)
+	}
+	return code, err
+}
+
+// Voltage returns the voltage of a channel of the inner ADC.
+func (t *Tracer) Voltage(channel int) (float64, error) {
+	if t.logf == nil {
+		return t.inner.Voltage(channel)
+	}
+
+	start := t.now()
+	v, err := t.inner.Voltage(channel)
+	if !t.sample(err) {
+		return v, err
+	}
+
+	d := t.now().Sub(start)
+	if err != nil {
+		t.logf("adc: Voltage(%d) failed after %v: %v", channel, d, err)
+	} else {
+		t.logf("adc: Voltage(%d) = %.6f V in %v", channel, v, d)
+	}
+	return v, err
+}
+
+// sample counts a call and returns true if it must be logged.
+func (t *Tracer) sample(err error) bool {
+	n := atomic.AddUint64(&t.calls, 1)
+	if err != nil || t.LogEvery <= 1 {
+		return true
+	}
+	return (n-1)%uint64(t.LogEvery) == 0
+}
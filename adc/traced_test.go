@@ -0,0 +1,102 @@
+package adc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tracedADC returns a Tracer of a that logs to the returned buffer. Every call
+// of the inner ADC takes 1.5 ms.
+func tracedADC(a ADC) (*Tracer, *bytes.Buffer) {
+	var buf bytes.Buffer
+	t := Traced(a, log.New(&buf, "", 0).Printf)
+
+	now := time.Unix(0, 0)
+	t.now = func() time.Time {
+		now = now.Add(1500 * time.Microsecond)
+		return now
+	}
+	return t, &buf
+}
+
+func TestTraced(t *testing.T) {
+	tr, buf := tracedADC(distortedADC{v: 1.25, distort: identity})
+
+	code, err := tr.OutputCode(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 42, code)
+
+	v, err := tr.Voltage(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.25, v)
+
+	assert.Equal(t, "adc: OutputCode(3) = 42 in 1.5ms\nadc: Voltage(3) = 1.250000 V in 1.5ms\n", buf.String())
+
+	tr, buf = tracedADC(distortedADC{err: errors.New("some error"), distort: identity})
+	_, err = tr.OutputCode(1)
+	assert.EqualError(t, err, "some error")
+	_, err = tr.Voltage(1)
+	assert.EqualError(t, err, "some error")
+
+	assert.Equal(t, "adc: OutputCode(1) failed after 1.5ms: some error\nadc: Voltage(1) failed after 1.5ms: some error\n", buf.String())
+}
+
+func TestTracedLogEvery(t *testing.T) {
+	a := &failingADC{}
+	tr, buf := tracedADC(a)
+	tr.LogEvery = 3
+
+	for i := 0; i < 7; i++ {
+		a.fail = i == 4
+		_, _ = tr.OutputCode(i)
+	}
+
+	// Calls 0, 3 and 6 are logged, and call 4 because it failed.
+	assert.Equal(t, "adc: OutputCode(0) = 0 in 1.5ms\n"+
+		"adc: OutputCode(3) = 3 in 1.5ms\n"+
+		"adc: OutputCode(4) failed after 1.5ms: channel 4 failed\n"+
+		"adc: OutputCode(6) = 6 in 1.5ms\n", buf.String())
+}
+
+func TestTracedWithoutLogf(t *testing.T) {
+	tr := Traced(distortedADC{v: 2, distort: identity}, nil)
+	tr.now = func() time.Time {
+		t.Fatal("the time must not be read without logf")
+		return time.Time{}
+	}
+
+	v, err := tr.Voltage(1)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(2), v)
+}
+
+// failingADC is a mocked ADC that returns the channel as output code, or an
+// error if fail is set.
+type failingADC struct {
+	fail bool
+}
+
+func (a *failingADC) OutputCode(channel int) (int, error) {
+	if a.fail {
+		return 0, fmt.Errorf("channel %d failed", channel)
+	}
+	return channel, nil
+}
+
+func (a *failingADC) Voltage(channel int) (float64, error) {
+	code, err := a.OutputCode(channel)
+	return float64(code), err
+}
+
+func BenchmarkTracedWithoutLogf(b *testing.B) {
+	tr := Traced(distortedADC{v: 2, distort: identity}, nil)
+	for i := 0; i < b.N; i++ {
+		_, _ = tr.Voltage(1)
+	}
+}
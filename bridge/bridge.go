@@ -0,0 +1,50 @@
+// Package bridge connects ADCs to DACs, like a DAC output that follows an ADC
+// input.
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/dac"
+)
+
+// Follow reads the voltage of channel srcCh of src every interval and sets
+// channel dstCh of dst to that voltage scaled by scale, until ctx is done. The
+// first voltage is read immediately. When scale is nil the voltage is set as
+// is.
+//
+// Follow returns ctx.Err() when ctx is done. An error of reading src or
+// setting dst stops following and is returned. An interval that isn't positive
+// is an error too, src isn't read then.
+func Follow(ctx context.Context, src adc.ADC, srcCh int, dst dac.DAC, dstCh int, scale func(float64) float64, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("interval of %v is invalid, it must be positive", interval)
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		v, err := src.Voltage(srcCh)
+		if err != nil {
+			return fmt.Errorf("failed to read channel %d: %w", srcCh, err)
+		}
+
+		if scale != nil {
+			v = scale(v)
+		}
+
+		if err := dst.SetVoltage(v, dstCh); err != nil {
+			return fmt.Errorf("failed to set voltage of channel %d: %w", dstCh, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package bridge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testADC is a mocked ADC. The voltage of a read is the number of reads. It
+// cancels the context after the given number of reads.
+type testADC struct {
+	reads  int
+	cancel func()
+	after  int
+	err    error
+}
+
+func (a *testADC) OutputCode(channel int) (int, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (a *testADC) Voltage(channel int) (float64, error) {
+	if channel != 2 {
+		return 0, errors.New("wrong channel")
+	}
+
+	a.reads++
+	if a.reads == a.after {
+		a.cancel()
+	}
+	return float64(a.reads), a.err
+}
+
+// testDAC is a mocked DAC that records the voltages set.
+type testDAC struct {
+	voltages []float64
+	err      error
+}
+
+func (d *testDAC) SetVoltage(v float64, channel int) error {
+	if channel != 5 {
+		return errors.New("wrong channel")
+	}
+
+	d.voltages = append(d.voltages, v)
+	return d.err
+}
+
+func (d *testDAC) SetInputCode(code, channel int) error {
+	return errors.New("not implemented")
+}
+
+func TestFollow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := &testADC{cancel: cancel, after: 3}
+	dst := &testDAC{}
+
+	err := Follow(ctx, src, 2, dst, 5, func(v float64) float64 { return 2*v + 0.5 }, time.Millisecond)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []float64{2.5, 4.5, 6.5}, dst.voltages)
+
+	// Without a scale function the voltage is set as is.
+	ctx, cancel = context.WithCancel(context.Background())
+	src = &testADC{cancel: cancel, after: 2}
+	dst = &testDAC{}
+
+	err = Follow(ctx, src, 2, dst, 5, nil, time.Millisecond)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []float64{1, 2}, dst.voltages)
+}
+
+func TestFollowErrors(t *testing.T) {
+	src := &testADC{err: errors.New("some error")}
+	dst := &testDAC{}
+
+	err := Follow(context.Background(), src, 2, dst, 5, nil, time.Millisecond)
+	assert.EqualError(t, err, "failed to read channel 2: some error")
+	assert.Nil(t, dst.voltages)
+
+	src = &testADC{}
+	dst = &testDAC{err: errors.New("some error")}
+
+	err = Follow(context.Background(), src, 2, dst, 5, nil, time.Millisecond)
+	assert.EqualError(t, err, "failed to set voltage of channel 5: some error")
+	assert.Equal(t, []float64{1}, dst.voltages)
+
+	dst = &testDAC{}
+	err = Follow(context.Background(), src, 2, dst, 5, nil, 0)
+	assert.EqualError(t, err, "interval of 0s is invalid, it must be positive")
+	assert.Nil(t, dst.voltages)
+}
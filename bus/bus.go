@@ -0,0 +1,38 @@
+// Package bus defines the interfaces of the connections the drivers in this
+// repository use to talk to their devices. The drivers don't depend on the
+// concrete *i2c.Device and *spi.Device of golang.org/x/exp/io, but on these
+// small interfaces. Those devices implement the interfaces, but so can a fake
+// connection in a test.
+//
+// LogI2C and LogSPI wrap a connection to log the bytes on the wire. The
+// drivers do so when their Logger field is set.
+//
+// TimeoutI2C and TimeoutSPI wrap a connection to fail transfers that don't
+// finish in time, so a stuck bus doesn't hang the program. Wrap the connection
+// before passing it to a driver.
+package bus
+
+import (
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/spi"
+)
+
+// I2C is the interface of a connection to a device on an I2C bus.
+type I2C interface {
+	// Read reads len(buf) bytes from the device.
+	Read(buf []byte) error
+	// Write writes buf to the device.
+	Write(buf []byte) error
+}
+
+// SPI is the interface of a connection to a device on a SPI bus.
+type SPI interface {
+	// Tx performs a duplex transmission. It writes w to the device and
+	// reads len(r) bytes from it into r.
+	Tx(w, r []byte) error
+}
+
+var (
+	_ I2C = (*i2c.Device)(nil)
+	_ SPI = (*spi.Device)(nil)
+)
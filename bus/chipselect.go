@@ -0,0 +1,61 @@
+package bus
+
+import (
+	"fmt"
+	"sync"
+)
+
+var _ SPI = (*ChipSelect)(nil)
+
+// Pin is an output pin. gpio.GPIO implements it.
+type Pin interface {
+	SetHigh() error
+	SetLow() error
+}
+
+// ChipSelect is a SPI connection that uses a GPIO pin as chip select. It's
+// useful when more devices share a SPI bus than the SPI controller has chip
+// select lines. The chip select is active low.
+//
+// ChipSelect can be used concurrently. Devices that share a bus use their own
+// ChipSelect though, so transfers to different devices must be serialized by
+// the caller, for example by giving their drivers the same sync.Locker.
+type ChipSelect struct {
+	conn SPI
+	cs   Pin
+
+	m sync.Mutex
+}
+
+// NewChipSelect returns a ChipSelect that performs its transfers using conn and
+// drives cs low during every transfer. The pin must be configured as an output
+// already. NewChipSelect drives it high, so the device is deselected.
+func NewChipSelect(conn SPI, cs Pin) (*ChipSelect, error) {
+	if err := cs.SetHigh(); err != nil {
+		return nil, fmt.Errorf("failed to deselect device: %w", err)
+	}
+
+	return &ChipSelect{
+		conn: conn,
+		cs:   cs,
+	}, nil
+}
+
+// Tx drives the chip select low, performs the transfer and drives the chip
+// select high again. The chip select is also driven high when the transfer
+// fails.
+func (c *ChipSelect) Tx(w, r []byte) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if err := c.cs.SetLow(); err != nil {
+		return fmt.Errorf("failed to select device: %w", err)
+	}
+
+	err := c.conn.Tx(w, r)
+
+	if csErr := c.cs.SetHigh(); csErr != nil && err == nil {
+		return fmt.Errorf("failed to deselect device: %w", csErr)
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package bus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testLog records the calls to a testPin and testSPI.
+type testLog []string
+
+type testPin struct {
+	log *testLog
+	err error
+}
+
+func (p testPin) SetHigh() error {
+	*p.log = append(*p.log, "high")
+	return p.err
+}
+
+func (p testPin) SetLow() error {
+	*p.log = append(*p.log, "low")
+	return p.err
+}
+
+type testSPI struct {
+	log *testLog
+	err error
+}
+
+func (s testSPI) Tx(w, r []byte) error {
+	*s.log = append(*s.log, "tx")
+	copy(r, w)
+	return s.err
+}
+
+func TestChipSelect(t *testing.T) {
+	log := &testLog{}
+	c, err := NewChipSelect(testSPI{log: log}, testPin{log: log})
+	assert.Nil(t, err)
+	assert.Equal(t, &testLog{"high"}, log)
+
+	r := make([]byte, 2)
+	assert.Nil(t, c.Tx([]byte{1, 2}, r))
+	assert.Equal(t, []byte{1, 2}, r)
+	assert.Equal(t, &testLog{"high", "low", "tx", "high"}, log)
+
+	// The device is deselected when the transfer fails.
+	log = &testLog{}
+	c, _ = NewChipSelect(testSPI{log: log, err: errors.New("some error")}, testPin{log: log})
+	assert.EqualError(t, c.Tx([]byte{1}, make([]byte, 1)), "some error")
+	assert.Equal(t, &testLog{"high", "low", "tx", "high"}, log)
+}
+
+func TestChipSelectWithFailingPin(t *testing.T) {
+	log := &testLog{}
+	_, err := NewChipSelect(testSPI{log: log}, testPin{log: log, err: errors.New("some error")})
+	assert.EqualError(t, err, "failed to deselect device: some error")
+
+	c := &ChipSelect{conn: testSPI{log: log}, cs: testPin{log: log, err: errors.New("some error")}}
+	assert.EqualError(t, c.Tx(nil, nil), "failed to select device: some error")
+}
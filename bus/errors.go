@@ -0,0 +1,30 @@
+package bus
+
+import "errors"
+
+// ErrFailure matches every error returned by a connection, like a transfer that
+// hasn't been acknowledged. Drivers wrap those errors using Error, so a caller
+// can use errors.Is(err, bus.ErrFailure) to tell a failing bus, which might be
+// worth a retry, apart from a programming error.
+var ErrFailure = errors.New("bus failure")
+
+// Error wraps an error returned by a connection. Its message is the message of
+// the wrapped error. errors.Is reports true when it's compared to ErrFailure or
+// to the wrapped error.
+type Error struct {
+	Err error
+}
+
+func (e Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrFailure.
+func (e Error) Is(target error) bool {
+	return target == ErrFailure
+}
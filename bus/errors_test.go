@@ -0,0 +1,18 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	err := fmt.Errorf("failed to read channel 1: %w", Error{io.EOF})
+	assert.EqualError(t, err, "failed to read channel 1: EOF")
+	assert.True(t, errors.Is(err, ErrFailure))
+	assert.True(t, errors.Is(err, io.EOF))
+	assert.False(t, errors.Is(fmt.Errorf("failed: %w", io.EOF), ErrFailure))
+}
@@ -0,0 +1,64 @@
+package bus
+
+// Logger logs a formatted message. *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// LogI2C returns an I2C that logs every Read and Write of conn using l, with
+// the bytes in hex. It returns conn when l is nil, so nothing is logged then.
+func LogI2C(conn I2C, l Logger) I2C {
+	if l == nil {
+		return conn
+	}
+	return &loggedI2C{conn: conn, l: l}
+}
+
+// LogSPI returns a SPI that logs every Tx of conn using l, with the bytes in
+// hex. It returns conn when l is nil, so nothing is logged then.
+func LogSPI(conn SPI, l Logger) SPI {
+	if l == nil {
+		return conn
+	}
+	return &loggedSPI{conn: conn, l: l}
+}
+
+type loggedI2C struct {
+	conn I2C
+	l    Logger
+}
+
+func (c *loggedI2C) Read(buf []byte) error {
+	if err := c.conn.Read(buf); err != nil {
+		c.l.Printf("i2c: read of %d bytes failed: %v", len(buf), err)
+		return err
+	}
+
+	c.l.Printf("i2c: read % x", buf)
+	return nil
+}
+
+func (c *loggedI2C) Write(buf []byte) error {
+	if err := c.conn.Write(buf); err != nil {
+		c.l.Printf("i2c: write % x failed: %v", buf, err)
+		return err
+	}
+
+	c.l.Printf("i2c: write % x", buf)
+	return nil
+}
+
+type loggedSPI struct {
+	conn SPI
+	l    Logger
+}
+
+func (c *loggedSPI) Tx(w, r []byte) error {
+	if err := c.conn.Tx(w, r); err != nil {
+		c.l.Printf("spi: tx % x failed: %v", w, err)
+		return err
+	}
+
+	c.l.Printf("spi: tx % x, rx % x", w, r)
+	return nil
+}
@@ -0,0 +1,73 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Printf makes a testLog a Logger.
+func (l *testLog) Printf(format string, v ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+type testI2C struct {
+	err error
+}
+
+func (c testI2C) Read(buf []byte) error {
+	for i := range buf {
+		buf[i] = byte(0xa0 + i)
+	}
+	return c.err
+}
+
+func (c testI2C) Write(buf []byte) error {
+	return c.err
+}
+
+func TestLogI2C(t *testing.T) {
+	log := &testLog{}
+	conn := LogI2C(testI2C{}, log)
+
+	assert.Nil(t, conn.Write([]byte{0x01, 0x85, 0x83}))
+	assert.Nil(t, conn.Read(make([]byte, 2)))
+
+	conn = LogI2C(testI2C{err: errors.New("some error")}, log)
+	assert.EqualError(t, conn.Write([]byte{0xff}), "some error")
+	assert.EqualError(t, conn.Read(make([]byte, 3)), "some error")
+
+	assert.Equal(t, &testLog{
+		"i2c: write 01 85 83",
+		"i2c: read a0 a1",
+		"i2c: write ff failed: some error",
+		"i2c: read of 3 bytes failed: some error",
+	}, log)
+}
+
+func TestLogSPI(t *testing.T) {
+	log := &testLog{}
+	conn := LogSPI(testSPI{log: log}, log)
+
+	assert.Nil(t, conn.Tx([]byte{0x01, 0x80, 0x00}, make([]byte, 3)))
+
+	conn = LogSPI(testSPI{log: log, err: errors.New("some error")}, log)
+	assert.EqualError(t, conn.Tx([]byte{0x01}, make([]byte, 1)), "some error")
+
+	assert.Equal(t, &testLog{
+		"tx",
+		"spi: tx 01 80 00, rx 01 80 00",
+		"tx",
+		"spi: tx 01 failed: some error",
+	}, log)
+}
+
+func TestLogWithoutLogger(t *testing.T) {
+	i2c := testI2C{}
+	assert.Equal(t, i2c, LogI2C(i2c, nil))
+
+	spi := testSPI{}
+	assert.Equal(t, spi, LogSPI(spi, nil))
+}
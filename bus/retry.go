@@ -0,0 +1,32 @@
+package bus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Retry calls op until it succeeds, but at most attempts times. It waits
+// backoff between 2 attempts. Only errors matching ErrFailure are retried,
+// other errors, like an invalid channel, are returned immediately.
+//
+// When all attempts fail, the error of the last attempt is returned, annotated
+// with the number of attempts. When attempts is 1 or lower, op is called once
+// and its error is returned as is.
+func Retry(op func() error, attempts int, backoff time.Duration) error {
+	for i := 1; ; i++ {
+		err := op()
+		if err == nil || !errors.Is(err, ErrFailure) {
+			return err
+		}
+
+		if i >= attempts {
+			if attempts <= 1 {
+				return err
+			}
+			return fmt.Errorf("failed after %d attempts: %w", attempts, err)
+		}
+
+		time.Sleep(backoff)
+	}
+}
@@ -0,0 +1,65 @@
+package bus
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		attempts int
+		failures int
+		calls    int
+		err      string
+	}{
+		{0, 0, 1, ""},
+		{0, 1, 1, "EOF"},
+		{1, 1, 1, "EOF"},
+		{3, 2, 3, ""},
+		{3, 3, 3, "failed after 3 attempts: EOF"},
+		{3, 5, 3, "failed after 3 attempts: EOF"},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		err := Retry(func() error {
+			calls++
+			if calls <= test.failures {
+				return Error{io.EOF}
+			}
+			return nil
+		}, test.attempts, time.Microsecond)
+
+		assert.Equal(t, test.calls, calls)
+		if test.err == "" {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.err)
+		assert.True(t, errors.Is(err, ErrFailure))
+	}
+}
+
+func TestRetryDoesntRetryOtherErrors(t *testing.T) {
+	calls := 0
+	err := Retry(func() error {
+		calls++
+		return io.EOF
+	}, 3, time.Microsecond)
+
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	start := time.Now()
+	_ = Retry(func() error {
+		return Error{io.EOF}
+	}, 3, 10*time.Millisecond)
+
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+}
@@ -0,0 +1,114 @@
+package bus
+
+import (
+	"errors"
+	"time"
+
+	"github.com/advancedclimatesystems/io/internal/errs"
+)
+
+// ErrTimeout matches the errors returned by the connections of TimeoutI2C and
+// TimeoutSPI when a transfer didn't finish in time.
+var ErrTimeout = errors.New("bus timeout")
+
+// TimeoutI2C returns an I2C that fails every Read and Write of conn that takes
+// longer than d with an error matching ErrTimeout. It returns conn when d isn't
+// greater than 0, so there's no timeout then.
+//
+// A transfer that timed out keeps running in the background, conn can't be
+// interrupted. The next transfer waits for it, so it times out as well when
+// the bus is still stuck. The buffers of a transfer that timed out aren't
+// touched anymore.
+func TimeoutI2C(conn I2C, d time.Duration) I2C {
+	if d <= 0 {
+		return conn
+	}
+	return &timeoutI2C{conn: conn, d: d, busy: make(chan struct{}, 1)}
+}
+
+// TimeoutSPI returns a SPI that fails every Tx of conn that takes longer than d
+// with an error matching ErrTimeout, like TimeoutI2C. It returns conn when d
+// isn't greater than 0.
+func TimeoutSPI(conn SPI, d time.Duration) SPI {
+	if d <= 0 {
+		return conn
+	}
+	return &timeoutSPI{conn: conn, d: d, busy: make(chan struct{}, 1)}
+}
+
+type timeoutI2C struct {
+	conn I2C
+	d    time.Duration
+
+	// busy holds a value while a transfer is running.
+	busy chan struct{}
+}
+
+func (c *timeoutI2C) Read(buf []byte) error {
+	in := make([]byte, len(buf))
+	err := timeout(c.busy, c.d, "i2c read", func() error {
+		return c.conn.Read(in)
+	})
+	if err != nil {
+		return err
+	}
+
+	copy(buf, in)
+	return nil
+}
+
+func (c *timeoutI2C) Write(buf []byte) error {
+	out := append([]byte(nil), buf...)
+	return timeout(c.busy, c.d, "i2c write", func() error {
+		return c.conn.Write(out)
+	})
+}
+
+type timeoutSPI struct {
+	conn SPI
+	d    time.Duration
+
+	// busy holds a value while a transfer is running.
+	busy chan struct{}
+}
+
+func (c *timeoutSPI) Tx(w, r []byte) error {
+	out := append([]byte(nil), w...)
+	in := make([]byte, len(r))
+	err := timeout(c.busy, c.d, "spi tx", func() error {
+		return c.conn.Tx(out, in)
+	})
+	if err != nil {
+		return err
+	}
+
+	copy(r, in)
+	return nil
+}
+
+// timeout calls op in a goroutine and returns its error, or an error matching
+// ErrTimeout when op hasn't returned within d. It waits for the transfer
+// holding busy first, that counts towards d as well.
+func timeout(busy chan struct{}, d time.Duration, name string, op func() error) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case busy <- struct{}{}:
+	case <-t.C:
+		return errs.New(ErrTimeout, "%s didn't start within %v, the previous transfer is still busy", name, d)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+		<-busy
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-t.C:
+		return errs.New(ErrTimeout, "%s didn't finish within %v", name, d)
+	}
+}
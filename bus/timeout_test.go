@@ -0,0 +1,83 @@
+package bus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stuckI2C is an I2C that blocks until release is closed.
+type stuckI2C struct {
+	release chan struct{}
+}
+
+func (c stuckI2C) Read(buf []byte) error {
+	<-c.release
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	return nil
+}
+
+func (c stuckI2C) Write(buf []byte) error {
+	<-c.release
+	return nil
+}
+
+func TestTimeoutI2C(t *testing.T) {
+	conn := TimeoutI2C(testI2C{}, time.Second)
+
+	buf := make([]byte, 2)
+	assert.Nil(t, conn.Read(buf))
+	assert.Equal(t, []byte{0xa0, 0xa1}, buf)
+	assert.Nil(t, conn.Write([]byte{0x01}))
+
+	conn = TimeoutI2C(testI2C{err: errors.New("some error")}, time.Second)
+	assert.EqualError(t, conn.Read(buf), "some error")
+	assert.EqualError(t, conn.Write([]byte{0x01}), "some error")
+}
+
+func TestTimeoutI2CStuck(t *testing.T) {
+	stuck := stuckI2C{release: make(chan struct{})}
+	conn := TimeoutI2C(stuck, 50*time.Millisecond)
+
+	buf := make([]byte, 2)
+	err := conn.Read(buf)
+	assert.EqualError(t, err, "i2c read didn't finish within 50ms")
+	assert.True(t, errors.Is(err, ErrTimeout))
+
+	// The read is still running, so the write can't start.
+	err = conn.Write([]byte{0x01})
+	assert.EqualError(t, err, "i2c write didn't start within 50ms, the previous transfer is still busy")
+	assert.True(t, errors.Is(err, ErrTimeout))
+
+	// Once the bus recovers the transfers succeed again, the read that
+	// timed out doesn't touch buf anymore.
+	close(stuck.release)
+	assert.Nil(t, conn.Write([]byte{0x01}))
+	assert.Equal(t, []byte{0x00, 0x00}, buf)
+	assert.Nil(t, conn.Read(buf))
+	assert.Equal(t, []byte{0xff, 0xff}, buf)
+}
+
+func TestTimeoutSPI(t *testing.T) {
+	log := &testLog{}
+	conn := TimeoutSPI(testSPI{log: log}, time.Second)
+
+	r := make([]byte, 2)
+	assert.Nil(t, conn.Tx([]byte{0x01, 0x02}, r))
+	assert.Equal(t, []byte{0x01, 0x02}, r)
+
+	conn = TimeoutSPI(testSPI{log: log, err: errors.New("some error")}, time.Second)
+	assert.EqualError(t, conn.Tx([]byte{0x01}, r), "some error")
+}
+
+func TestTimeoutWithoutDuration(t *testing.T) {
+	i2c := testI2C{}
+	assert.Equal(t, i2c, TimeoutI2C(i2c, 0))
+
+	spi := testSPI{}
+	assert.Equal(t, spi, TimeoutSPI(spi, 0))
+}
@@ -0,0 +1,24 @@
+package dac
+
+import "math"
+
+// VoltageToCode returns the input code for which a DAC with the given
+// resolution in bits and reference voltage outputs the voltage closest to v.
+// The code is rounded to the nearest integer, halfway away from zero. It isn't
+// clamped, so a voltage out of the range of the DAC results in a code that is
+// out of range too.
+func VoltageToCode(v float64, resolution int, vref float64) int {
+	return int(math.Round(v * maxCode(resolution) / vref))
+}
+
+// CodeToVoltage returns the output voltage of a DAC with the given resolution
+// in bits and reference voltage for the input code. The highest code results
+// in vref.
+func CodeToVoltage(code, resolution int, vref float64) float64 {
+	return float64(code) * vref / maxCode(resolution)
+}
+
+// maxCode returns the highest input code of a DAC with the given resolution.
+func maxCode(resolution int) float64 {
+	return math.Pow(2, float64(resolution)) - 1
+}
@@ -0,0 +1,60 @@
+package dac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoltageToCode(t *testing.T) {
+	tests := []struct {
+		v          float64
+		resolution int
+		vref       float64
+		code       int
+	}{
+		{0, 8, 10, 0},
+		{10, 8, 10, 255},
+		{5, 8, 10, 128},
+		{5, 12, 10, 2048},
+		{2.5, 12, 5, 2048},
+
+		// Halfway between 2 codes rounds away from zero.
+		{0.5, 8, 255, 1},
+		{0.49, 8, 255, 0},
+		{1.5, 8, 255, 2},
+
+		// Out of range voltages aren't clamped.
+		{11, 8, 10, 281},
+		{-1, 8, 10, -26},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.code, VoltageToCode(test.v, test.resolution, test.vref), "%v V", test.v)
+	}
+}
+
+func TestCodeToVoltage(t *testing.T) {
+	tests := []struct {
+		code       int
+		resolution int
+		vref       float64
+		v          float64
+	}{
+		{0, 8, 10, 0},
+		{255, 8, 10, 10},
+		{1, 8, 2.55, 0.01},
+		{4095, 12, 5, 5},
+		{1023, 10, 3.3, 3.3},
+	}
+
+	for _, test := range tests {
+		assert.InDelta(t, test.v, CodeToVoltage(test.code, test.resolution, test.vref), 1e-12)
+	}
+
+	// Converting a code to a voltage and back results in the same code.
+	for code := 0; code < 4096; code++ {
+		v := CodeToVoltage(code, 12, 3.3)
+		assert.Equal(t, code, VoltageToCode(v, 12, 3.3))
+	}
+}
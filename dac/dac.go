@@ -1,6 +1,35 @@
 // Package dac defines the DAC
 package dac
 
+import (
+	"errors"
+
+	"github.com/advancedclimatesystems/io/bus"
+)
+
+var (
+	// ErrInvalidChannel matches the errors returned by a DAC when a
+	// channel is used that the DAC doesn't have.
+	ErrInvalidChannel = errors.New("invalid channel")
+
+	// ErrCodeOutOfRange matches the errors returned by a DAC when an input
+	// code is used that is out of the range of the DAC.
+	ErrCodeOutOfRange = errors.New("input code out of range")
+
+	// ErrBusFailure matches the errors returned by a DAC when the
+	// connection to the DAC failed. It is the same error as
+	// bus.ErrFailure.
+	ErrBusFailure = bus.ErrFailure
+
+	// ErrUnsupported is returned by SelfTest for a DAC that can't read
+	// back its output.
+	ErrUnsupported = errors.New("unsupported by DAC")
+
+	// ErrSelfTestFailed matches the errors returned by a self-test when a
+	// channel doesn't read back the code that has been written to it.
+	ErrSelfTestFailed = errors.New("self-test failed")
+)
+
 // DAC is the interface to set the output voltage(s) of a Digital Analog
 // Converter.
 type DAC interface {
@@ -11,3 +40,14 @@ type DAC interface {
 	// and including 0 - (max resolution of DAC - 1).
 	SetInputCode(code, channel int) error
 }
+
+// MultiChannelDAC is a DAC that sets the output voltages of all its channels
+// with a single call.
+type MultiChannelDAC interface {
+	DAC
+
+	// SetVoltages sets the output voltages of all channels. The number of
+	// voltages must be equal to the number of channels of the DAC. The
+	// first voltage is the voltage of the first channel, and so on.
+	SetVoltages(voltages []float64) error
+}
@@ -0,0 +1,22 @@
+package dac
+
+// SelfTester is a DAC that can test that it's alive by reading back what has
+// been written to it.
+type SelfTester interface {
+	DAC
+
+	// SelfTest writes the mid-scale code to every channel and reads it
+	// back. An error matching ErrSelfTestFailed is returned when a
+	// channel reads back another code. The outputs are left at mid-scale.
+	SelfTest() error
+}
+
+// SelfTest runs the self-test of d, see SelfTester. ErrUnsupported is returned
+// when d can't read back its output.
+func SelfTest(d DAC) error {
+	t, ok := d.(SelfTester)
+	if !ok {
+		return ErrUnsupported
+	}
+	return t.SelfTest()
+}
@@ -0,0 +1,27 @@
+package dac
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testSelfTester is a mocked DAC that returns err from its self-test.
+type testSelfTester struct {
+	testDAC
+	err error
+}
+
+func (d testSelfTester) SelfTest() error {
+	return d.err
+}
+
+func TestSelfTest(t *testing.T) {
+	assert.Nil(t, SelfTest(testSelfTester{}))
+
+	err := errors.New("some error")
+	assert.Equal(t, err, SelfTest(testSelfTester{err: err}))
+
+	assert.Equal(t, ErrUnsupported, SelfTest(testDAC{}))
+}
@@ -0,0 +1,91 @@
+package dac
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Tracer is a DAC that logs every call of another DAC, with the channel, the
+// input code or voltage, the duration of the call and the error. It's meant
+// for debugging analog issues in the field.
+//
+// Tracer is safe for concurrent use if the inner DAC is. LogEvery isn't
+// guarded, so set it before the first call and don't change it after that.
+type Tracer struct {
+	// calls is the number of calls so far. It's the first field to keep
+	// it 64 bits aligned on 32 bits platforms.
+	calls uint64
+
+	// LogEvery limits the logging of calls in loops that write at a high
+	// rate, only 1 of every LogEvery calls is logged. Calls that fail are
+	// always logged. When 0 or 1 every call is logged.
+	LogEvery int
+
+	inner DAC
+	logf  func(format string, args ...interface{})
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// Traced returns a Tracer that logs the calls of inner using logf, like
+// log.Printf. When logf is nil nothing is logged and the calls go straight to
+// inner.
+func Traced(inner DAC, logf func(format string, args ...interface{})) *Tracer {
+	return &Tracer{
+		inner: inner,
+		logf:  logf,
+		now:   time.Now,
+	}
+}
+
+// SetVoltage sets the output voltage of a channel of the inner DAC.
+func (t *Tracer) SetVoltage(voltage float64, channel int) error {
+	if t.logf == nil {
+		return t.inner.SetVoltage(voltage, channel)
+	}
+
+	start := t.now()
+	err := t.inner.SetVoltage(voltage, channel)
+	if !t.sample(err) {
+		return err
+	}
+
+	d := t.now().Sub(start)
+	if err != nil {
+		t.logf("dac: SetVoltage(%.6f V, %d) failed after %v: %v", voltage, channel, d, err)
+	} else {
+		t.logf("dac: SetVoltage(%.6f V, %d) in %v", voltage, channel, d)
+	}
+	return err
+}
+
+// SetInputCode sets the input code of a channel of the inner DAC.
+func (t *Tracer) SetInputCode(code, channel int) error {
+	if t.logf == nil {
+		return t.inner.SetInputCode(code, channel)
+	}
+
+	start := t.now()
+	err := t.inner.SetInputCode(code, channel)
+	if !t.sample(err) {
+		return err
+	}
+
+	d := t.now().Sub(start)
+	if err != nil {
+		t.logf("dac: SetInputCode(%d, %d) failed after %v: %v", code, channel, d, err)
+	} else {
+		t.logf("dac: SetInputCode(%d, %d) in %v", code, channel, d)
+	}
+	return err
+}
+
+// sample counts a call and returns true if it must be logged.
+func (t *Tracer) sample(err error) bool {
+	n := atomic.AddUint64(&t.calls, 1)
+	if err != nil || t.LogEvery <= 1 {
+		return true
+	}
+	return (n-1)%uint64(t.LogEvery) == 0
+}
@@ -0,0 +1,52 @@
+package dac
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testDAC is a mocked DAC that fails for negative channels.
+type testDAC struct{}
+
+func (testDAC) SetVoltage(voltage float64, channel int) error {
+	if channel < 0 {
+		return errors.New("some error")
+	}
+	return nil
+}
+
+func (testDAC) SetInputCode(code, channel int) error {
+	return testDAC{}.SetVoltage(0, channel)
+}
+
+func TestTraced(t *testing.T) {
+	var buf bytes.Buffer
+	tr := Traced(testDAC{}, log.New(&buf, "", 0).Printf)
+	tr.LogEvery = 2
+
+	now := time.Unix(0, 0)
+	tr.now = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+
+	assert.Nil(t, tr.SetVoltage(1.5, 1))
+	assert.Nil(t, tr.SetVoltage(1.6, 1))
+	assert.Nil(t, tr.SetInputCode(512, 2))
+	assert.EqualError(t, tr.SetInputCode(512, -1), "some error")
+	assert.EqualError(t, tr.SetVoltage(2, -1), "some error")
+
+	assert.Equal(t, "dac: SetVoltage(1.500000 V, 1) in 1ms\n"+
+		"dac: SetInputCode(512, 2) in 1ms\n"+
+		"dac: SetInputCode(512, -1) failed after 1ms: some error\n"+
+		"dac: SetVoltage(2.000000 V, -1) failed after 1ms: some error\n", buf.String())
+
+	tr = Traced(testDAC{}, nil)
+	assert.Nil(t, tr.SetInputCode(1, 1))
+	assert.EqualError(t, tr.SetVoltage(1, -1), "some error")
+}
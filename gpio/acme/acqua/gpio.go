@@ -0,0 +1,114 @@
+// +build linux
+
+// Package acqua contains GPIO drivers for the Acme Systems Acqua A5
+//
+// The Acqua A5 exposes the GPIO pins of its SoC on the 50 pins J1, J2 and J3
+// connectors. This package implements all GPIO operations such as
+// getting/setting the value, setting the direction and changing the active
+// low. The package provides a mapping between the name of a pin on the J1
+// connector, like J1.9, and the atmel ID of the pin.
+// https://www.acmesystems.it/acqua
+package acqua
+
+import (
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/advancedclimatesystems/io/gpio/acme/internal/atmel"
+	"github.com/advancedclimatesystems/io/gpio/board"
+)
+
+// boardName is the name under which the mapping of the board is registered.
+const boardName = "acqua-a5"
+
+func init() {
+	board.RegisterWithBase(boardName, atmel.Mapping(acquaID), atmel.PinBase)
+}
+
+// NewPin creates a new pin with a kernel ID based on the pin name found
+// here: https://www.acmesystems.it/acqua. Pin names have the format
+// J1.<number>, for example J1.9.
+func NewPin(id string) (gpio.GPIO, error) {
+	return board.NewPin(boardName, id)
+}
+
+// NewPinWithWatcher is like NewPin, but the edge events of the pin are handled
+// by w. The caller is responsible for starting, stopping and closing w.
+func NewPinWithWatcher(id string, w gpio.Watcher) (gpio.GPIO, error) {
+	return board.NewPinWithWatcher(boardName, id, w)
+}
+
+// NewPins creates, exports and configures the pins described by specs. The
+// pins are returned by their name. If one of the pins fails, the pins created
+// so far are closed.
+func NewPins(specs []board.PinSpec) (map[string]gpio.GPIO, error) {
+	return board.NewPins(boardName, specs)
+}
+
+// Adopt adopts a pin that has been exported already, for example by another
+// process. The pin isn't modified, its current configuration is returned.
+func Adopt(id string) (gpio.GPIO, gpio.AdoptedState, error) {
+	return board.Adopt(boardName, id)
+}
+
+// ClosePin unexports a pin created by NewPin.
+func ClosePin(p gpio.GPIO) error {
+	return board.ClosePin(p)
+}
+
+// Shutdown unexports all pins created by NewPin and stops the watcher that
+// handles their edge events. The watcher is shared by all board packages, so
+// pins created by other board packages are unexported too.
+func Shutdown() error {
+	return board.Shutdown()
+}
+
+// UnexportAll unexports all pins created by NewPin. Pins created by other
+// board packages are unexported too.
+func UnexportAll() error {
+	return board.UnexportAll()
+}
+
+// acquaID provides a mapping between the name of the pin on the J1 connector
+// and the name of the pin of the SoC. Pins of the connector that aren't
+// connected to a GPIO, like the power and ground pins, are omitted.
+var acquaID = map[string]string{
+	"J1.9":  "PE25",
+	"J1.10": "PE26",
+	"J1.11": "PE27",
+	"J1.12": "PE28",
+	"J1.13": "PE29",
+	"J1.14": "PE30",
+	"J1.15": "PE31",
+	"J1.16": "PE24",
+	"J1.17": "PE23",
+	"J1.18": "PE22",
+	"J1.19": "PE21",
+	"J1.20": "PE20",
+	"J1.21": "PE19",
+	"J1.22": "PE18",
+	"J1.23": "PE17",
+	"J1.24": "PE16",
+	"J1.25": "PE15",
+	"J1.26": "PE14",
+	"J1.27": "PE13",
+	"J1.28": "PE12",
+	"J1.29": "PE11",
+	"J1.30": "PE10",
+	"J1.31": "PE9",
+	"J1.32": "PE8",
+	"J1.33": "PE7",
+	"J1.34": "PE6",
+	"J1.35": "PE5",
+	"J1.36": "PE4",
+	"J1.37": "PE3",
+	"J1.38": "PE2",
+	"J1.39": "PE1",
+	"J1.40": "PE0",
+	"J1.41": "PD31",
+	"J1.42": "PD30",
+	"J1.43": "PD29",
+	"J1.44": "PD28",
+	"J1.45": "PD27",
+	"J1.46": "PD26",
+	"J1.47": "PD25",
+	"J1.48": "PD24",
+}
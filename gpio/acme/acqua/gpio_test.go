@@ -0,0 +1,62 @@
+package acqua
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/advancedclimatesystems/io/gpio/acme/internal/atmel"
+	"github.com/advancedclimatesystems/io/gpio/board"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKernelID(t *testing.T) {
+	tests := []struct {
+		id  string
+		err error
+		kid int
+	}{
+		{"J1.9", nil, 153},
+		{"J1.40", nil, 128},
+		{"J1.41", nil, 127},
+		{"J1.48", nil, 120},
+
+		{"J1.1", errors.New("id J1.1 not known"), 0},
+		{"J1.51", errors.New("id J1.51 not known"), 0},
+		{"PE25", errors.New("id PE25 not known"), 0},
+	}
+	for _, test := range tests {
+		kid, err := board.KernelID(boardName, test.id)
+		assert.Equal(t, test.kid, kid)
+		if test.err == nil {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.err.Error())
+		assert.True(t, errors.Is(err, gpio.ErrInvalidPin))
+	}
+}
+
+func TestMappingIsValid(t *testing.T) {
+	assert.NotPanics(t, func() { atmel.Mapping(acquaID) })
+}
+
+func ExampleNewPin() {
+	outPin, _ := NewPin("J1.9")
+	_ = outPin.SetDirection(gpio.OutDirection)
+
+	inPin, _ := NewPin("J1.10")
+	_ = inPin.SetDirection(gpio.InDirection)
+	_ = inPin.SetEdge(gpio.RisingEdge, func(p *gpio.Pin) {
+		log.Printf("wow")
+	})
+
+	for i := 0; i < 4; i++ {
+		_ = outPin.SetHigh()
+		time.Sleep(1000 * time.Millisecond)
+		_ = outPin.SetLow()
+		time.Sleep(1000 * time.Millisecond)
+	}
+}
@@ -0,0 +1,103 @@
+// +build linux
+
+// Package arietta contains GPIO drivers for the Acme Systems Arietta G25
+//
+// The Arietta G25 exposes the GPIO pins of its SoC on the 40 pins J4
+// connector. This package implements all GPIO operations such as
+// getting/setting the value, setting the direction and changing the active
+// low. The package provides a mapping between the name of a pin on the J4
+// connector, like J4.7, and the atmel ID of the pin.
+// https://www.acmesystems.it/arietta
+package arietta
+
+import (
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/advancedclimatesystems/io/gpio/acme/internal/atmel"
+	"github.com/advancedclimatesystems/io/gpio/board"
+)
+
+// boardName is the name under which the mapping of the board is registered.
+const boardName = "arietta-g25"
+
+func init() {
+	board.RegisterWithBase(boardName, atmel.Mapping(ariettaID), atmel.PinBase)
+}
+
+// NewPin creates a new pin with a kernel ID based on the pin name found
+// here: https://www.acmesystems.it/arietta. Pin names have the format
+// J4.<number>, for example J4.7.
+func NewPin(id string) (gpio.GPIO, error) {
+	return board.NewPin(boardName, id)
+}
+
+// NewPinWithWatcher is like NewPin, but the edge events of the pin are handled
+// by w. The caller is responsible for starting, stopping and closing w.
+func NewPinWithWatcher(id string, w gpio.Watcher) (gpio.GPIO, error) {
+	return board.NewPinWithWatcher(boardName, id, w)
+}
+
+// NewPins creates, exports and configures the pins described by specs. The
+// pins are returned by their name. If one of the pins fails, the pins created
+// so far are closed.
+func NewPins(specs []board.PinSpec) (map[string]gpio.GPIO, error) {
+	return board.NewPins(boardName, specs)
+}
+
+// Adopt adopts a pin that has been exported already, for example by another
+// process. The pin isn't modified, its current configuration is returned.
+func Adopt(id string) (gpio.GPIO, gpio.AdoptedState, error) {
+	return board.Adopt(boardName, id)
+}
+
+// ClosePin unexports a pin created by NewPin.
+func ClosePin(p gpio.GPIO) error {
+	return board.ClosePin(p)
+}
+
+// Shutdown unexports all pins created by NewPin and stops the watcher that
+// handles their edge events. The watcher is shared by all board packages, so
+// pins created by other board packages are unexported too.
+func Shutdown() error {
+	return board.Shutdown()
+}
+
+// UnexportAll unexports all pins created by NewPin. Pins created by other
+// board packages are unexported too.
+func UnexportAll() error {
+	return board.UnexportAll()
+}
+
+// ariettaID provides a mapping between the name of the pin on the J4
+// connector and the name of the pin of the SoC. Pins of the connector that
+// aren't connected to a GPIO, like the power and ground pins, are omitted.
+var ariettaID = map[string]string{
+	"J4.7":  "PA23",
+	"J4.8":  "PA22",
+	"J4.10": "PA21",
+	"J4.11": "PA24",
+	"J4.12": "PA31",
+	"J4.13": "PA25",
+	"J4.14": "PA30",
+	"J4.15": "PA26",
+	"J4.17": "PA27",
+	"J4.19": "PA28",
+	"J4.21": "PA29",
+	"J4.23": "PA0",
+	"J4.24": "PA1",
+	"J4.25": "PA8",
+	"J4.26": "PA7",
+	"J4.27": "PA6",
+	"J4.28": "PA5",
+	"J4.29": "PC28",
+	"J4.30": "PC27",
+	"J4.31": "PC4",
+	"J4.32": "PC31",
+	"J4.33": "PC3",
+	"J4.34": "PB11",
+	"J4.35": "PC2",
+	"J4.36": "PB12",
+	"J4.37": "PC1",
+	"J4.38": "PB13",
+	"J4.39": "PC0",
+	"J4.40": "PB14",
+}
@@ -0,0 +1,63 @@
+package arietta
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/advancedclimatesystems/io/gpio/acme/internal/atmel"
+	"github.com/advancedclimatesystems/io/gpio/board"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetKernelID(t *testing.T) {
+	tests := []struct {
+		id  string
+		err error
+		kid int
+	}{
+		{"J4.7", nil, 23},
+		{"J4.23", nil, 0},
+		{"J4.34", nil, 43},
+		{"J4.39", nil, 64},
+		{"J4.32", nil, 95},
+
+		{"J4.1", errors.New("id J4.1 not known"), 0},
+		{"J4.41", errors.New("id J4.41 not known"), 0},
+		{"PA23", errors.New("id PA23 not known"), 0},
+	}
+	for _, test := range tests {
+		kid, err := board.KernelID(boardName, test.id)
+		assert.Equal(t, test.kid, kid)
+		if test.err == nil {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.err.Error())
+		assert.True(t, errors.Is(err, gpio.ErrInvalidPin))
+	}
+}
+
+func TestMappingIsValid(t *testing.T) {
+	assert.NotPanics(t, func() { atmel.Mapping(ariettaID) })
+}
+
+func ExampleNewPin() {
+	outPin, _ := NewPin("J4.29")
+	_ = outPin.SetDirection(gpio.OutDirection)
+
+	inPin, _ := NewPin("J4.31")
+	_ = inPin.SetDirection(gpio.InDirection)
+	_ = inPin.SetEdge(gpio.RisingEdge, func(p *gpio.Pin) {
+		log.Printf("wow")
+	})
+
+	for i := 0; i < 4; i++ {
+		_ = outPin.SetHigh()
+		time.Sleep(1000 * time.Millisecond)
+		_ = outPin.SetLow()
+		time.Sleep(1000 * time.Millisecond)
+	}
+}
@@ -12,97 +12,226 @@ package g25
 import (
 	"fmt"
 	"io/ioutil"
+	"regexp"
+	"sort"
 	"strconv"
 
 	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/advancedclimatesystems/io/gpio/acme/internal/atmel"
+	"github.com/advancedclimatesystems/io/gpio/board"
+	"github.com/advancedclimatesystems/io/internal/errs"
 )
 
-var w gpio.Watcher
+const (
+	// boardName is the name under which the mapping for kernels with version
+	// 3.x or later is registered.
+	boardName = "aria-g25"
+	// boardName26 is the name under which the mapping for kernels with version
+	// 2.6 is registered.
+	boardName26 = "aria-g25-2.6"
+)
+
+// names26 and names31 map the kernel IDs of kernel 2.6 and of kernel 3.x or
+// later to the pin names.
+var (
+	names26 = make(map[int]string, len(g25Id))
+	names31 = make(map[int]string, len(g25Id))
+)
+
+// PinInfo contains all identifiers of a pin.
+type PinInfo struct {
+	// PinName is the name of the pin as printed on the board, like N2 or E10.
+	PinName string
+	// ICPin is the name of the pin of the SoC, like PC0 or PC26.
+	ICPin string
+	// KernelID26 is the kernel ID of the pin for kernel version 2.6.
+	KernelID26 int
+	// KernelID31 is the kernel ID of the pin for kernel version 3.1 and
+	// later.
+	KernelID31 int
+}
+
+func init() {
+	ids26 := make(map[string]int, len(g25Id))
+	ids31 := make(map[string]int, len(g25Id))
+	for name, id := range g25Id {
+		ids26[name] = id.KernelID26
+		ids31[name] = id.KernelID31
+		names26[id.KernelID26] = name
+		names31[id.KernelID31] = name
+	}
+
+	// The kernel IDs of kernel 2.6 are 32 higher than those of later kernels,
+	// the exported folders have the same names though.
+	board.RegisterWithBase(boardName26, ids26, func(kernelID int) string {
+		return atmel.PinBase(kernelID - 32)
+	})
+	board.RegisterWithBase(boardName, ids31, atmel.PinBase)
+}
 
 // NewPin creates a new pin with a kernel ID based on the pin name found
-// here: https://www.acmesystems.it/aria. It assumes the kernel has version 3.1x
-// if this is not the case, use the NewPinV26 instead.
+// here: https://www.acmesystems.it/aria. The kernel ID depends on the version
+// of the running kernel.
 func NewPin(id string) (gpio.GPIO, error) {
-	k, err := getKernelVersion()
+	k, _, err := getKernelVersion()
 	if err != nil {
 		return nil, err
 	}
 
-	kernelID, err := getkernelID(k, id)
+	return board.NewPin(boardFor(k), id)
+}
+
+// NewPinWithWatcher is like NewPin, but the edge events of the pin are handled
+// by w. The caller is responsible for starting, stopping and closing w.
+func NewPinWithWatcher(id string, w gpio.Watcher) (gpio.GPIO, error) {
+	k, _, err := getKernelVersion()
 	if err != nil {
 		return nil, err
 	}
 
-	if err := setupWatcher(); err != nil {
+	return board.NewPinWithWatcher(boardFor(k), id, w)
+}
+
+// NewPins creates, exports and configures the pins described by specs. The
+// pins are returned by their name. If one of the pins fails, the pins created
+// so far are closed.
+func NewPins(specs []board.PinSpec) (map[string]gpio.GPIO, error) {
+	k, _, err := getKernelVersion()
+	if err != nil {
 		return nil, err
 	}
 
-	// The file created by export is always called pio, followed by ic pin ID,
-	// but without the fist character. So exporting N2 gives an file called pioC0.
-	gpio := gpio.NewPin(kernelID, fmt.Sprintf("pio%v", g25Id[id].icPin[1:]), w)
-	if err := gpio.Export(); err != nil {
-		return nil, err
+	return board.NewPins(boardFor(k), specs)
+}
+
+// Adopt adopts a pin that has been exported already, for example by another
+// process. The pin isn't modified, its current configuration is returned.
+func Adopt(id string) (gpio.GPIO, gpio.AdoptedState, error) {
+	k, _, err := getKernelVersion()
+	if err != nil {
+		return nil, gpio.AdoptedState{}, err
 	}
-	return gpio, nil
-}
-
-// setupWatcher creates a new watcher and starts it, if its not already running.
-func setupWatcher() error {
-	// A Watcher only needs to be setup once, but an error can't be handled in an
-	// init function.
-	var err error
-	if w == nil {
-		w, err = gpio.NewWatcher()
-		if err != nil {
-			return err
-		}
-		go func() {
-			err = w.Watch()
-			defer w.Close()
-		}()
+
+	return board.Adopt(boardFor(k), id)
+}
+
+// ClosePin unexports a pin created by NewPin.
+func ClosePin(p gpio.GPIO) error {
+	return board.ClosePin(p)
+}
+
+// Shutdown unexports all pins created by NewPin and stops the watcher that
+// handles their edge events. The watcher is shared by all board packages, so
+// pins created by other board packages are unexported too.
+func Shutdown() error {
+	return board.Shutdown()
+}
+
+// UnexportAll unexports all pins created by NewPin. Pins created by other
+// board packages are unexported too.
+func UnexportAll() error {
+	return board.UnexportAll()
+}
+
+// NameForKernelID returns the name of the pin with the given kernel ID, like E2
+// for kernel ID 86. The kernel ID depends on the version of the running
+// kernel. The second return value is false if no pin has the kernel ID. An
+// error is returned when the version of the running kernel can't be read.
+func NameForKernelID(id int) (string, bool, error) {
+	k, _, err := getKernelVersion()
+	if err != nil {
+		return "", false, err
 	}
-	return err
+
+	name, ok := nameForKernelID(k, id)
+	return name, ok, nil
 }
 
-// getKernelID returns the corrent kernel ID, based on the kernel version and id.
-func getkernelID(k int, id string) (int, error) {
-	pinID, ok := g25Id[id]
+// Pins returns the identifiers of all pins, ordered by kernel ID.
+func Pins() []PinInfo {
+	pins := make([]PinInfo, 0, len(g25Id))
+	for _, id := range g25Id {
+		pins = append(pins, id)
+	}
+
+	sort.Slice(pins, func(i, j int) bool {
+		return pins[i].KernelID31 < pins[j].KernelID31
+	})
+	return pins
+}
+
+// Info returns the identifiers of the pin with the given name.
+func Info(name string) (PinInfo, error) {
+	id, ok := g25Id[name]
 	if !ok {
-		return 0, fmt.Errorf("id %v not known", id)
+		return PinInfo{}, errs.New(gpio.ErrInvalidPin, "id %v not known", name)
 	}
+	return id, nil
+}
 
-	var kernelID int
+// nameForKernelID returns the name of the pin with the given kernel ID for
+// kernel version k.
+func nameForKernelID(k, id int) (string, bool) {
+	names := names31
 	if k < 3 {
-		kernelID = pinID.kernelID26
-	} else {
-		kernelID = pinID.kernelID31
+		names = names26
 	}
-	return kernelID, nil
+
+	name, ok := names[id]
+	return name, ok
 }
 
-// getkernelVersion get s the kernel version from /proc/version
-func getKernelVersion() (int, error) {
+// boardFor returns the name under which the mapping for kernel version k is
+// registered.
+func boardFor(k int) string {
+	if k < 3 {
+		return boardName26
+	}
+	return boardName
+}
+
+// getKernelID returns the corrent kernel ID, based on the kernel version and id.
+func getkernelID(k int, id string) (int, error) {
+	return board.KernelID(boardFor(k), id)
+}
+
+// versionRe matches the major and minor version in /proc/version, like
+// "Linux version 4.19.66+ (...)".
+var versionRe = regexp.MustCompile(`Linux version (\d+)\.(\d+)`)
+
+// getKernelVersion returns the major and minor version of the running kernel.
+func getKernelVersion() (int, int, error) {
 	data, err := ioutil.ReadFile("/proc/version")
-	if err != nil || len(data) < 15 {
-		return 0, err
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseKernelVersion(string(data))
+}
+
+// parseKernelVersion returns the major and minor version found in the content
+// of /proc/version.
+func parseKernelVersion(version string) (int, int, error) {
+	m := versionRe.FindStringSubmatch(version)
+	if m == nil {
+		return 0, 0, fmt.Errorf("failed to find kernel version in %q", version)
+	}
+
+	// The regexp only matches digits, so these conversions can only fail
+	// on overflow.
+	major, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	minor, err := strconv.Atoi(m[2])
+	if err != nil {
+		return 0, 0, err
 	}
-	// The 14th byte should be the major version, which is what we are loooking for.
-	return strconv.Atoi(string(data[14]))
+	return major, minor, nil
 }
 
 // g25Id provides a mapping between the "pin name" and all other indentifiers
 // of a pin.
-var g25Id = map[string]struct {
-	// Names such as N2, N3, E10, E6
-	pinName string
-	// Names such as PC0, PC1, PC30, PC26. These are used to figure out the
-	// name of the file created by exporting the pin.
-	icPin string
-	// Kernel ID for version 2.6.
-	kernelID26 int
-	// Kernel ID for version 3.1.
-	kernelID31 int
-}{
+var g25Id = map[string]PinInfo{
 	"N2":  {"N2", "PC0", 96, 64},
 	"N3":  {"N3", "PC1", 97, 65},
 	"N4":  {"N4", "PC2", 98, 66},
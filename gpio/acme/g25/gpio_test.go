@@ -33,6 +33,89 @@ func TestGetKernelID(t *testing.T) {
 	for _, test := range tests {
 		kid, err := getkernelID(test.kv, test.id)
 		assert.Equal(t, test.kid, kid)
+		if test.err == nil {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.err.Error())
+		assert.True(t, errors.Is(err, gpio.ErrInvalidPin))
+	}
+}
+
+// TestNameForKernelID tests if the name of every pin can be found back using
+// its kernel ID, for both kernel versions.
+func TestNameForKernelID(t *testing.T) {
+	for _, kv := range []int{2, 3} {
+		for _, info := range Pins() {
+			kid, err := getkernelID(kv, info.PinName)
+			assert.Nil(t, err)
+
+			name, ok := nameForKernelID(kv, kid)
+			assert.True(t, ok)
+			assert.Equal(t, info.PinName, name)
+		}
+	}
+
+	name, ok := nameForKernelID(3, 86)
+	assert.True(t, ok)
+	assert.Equal(t, "E2", name)
+
+	name, ok = nameForKernelID(2, 118)
+	assert.True(t, ok)
+	assert.Equal(t, "E2", name)
+
+	_, ok = nameForKernelID(3, 999)
+	assert.False(t, ok)
+
+	// The running kernel is used to pick the mapping.
+	k, _, err := getKernelVersion()
+	assert.Nil(t, err)
+	kid, _ := getkernelID(k, "E2")
+	name, ok, err = NameForKernelID(kid)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "E2", name)
+}
+
+func TestPins(t *testing.T) {
+	pins := Pins()
+	assert.Len(t, pins, len(g25Id))
+	assert.Equal(t, PinInfo{"S23", "PA0", 32, 0}, pins[0])
+
+	for i := 1; i < len(pins); i++ {
+		assert.True(t, pins[i-1].KernelID31 < pins[i].KernelID31)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	info, err := Info("E2")
+	assert.Nil(t, err)
+	assert.Equal(t, PinInfo{"E2", "PC22", 118, 86}, info)
+
+	_, err = Info("W0")
+	assert.EqualError(t, err, "id W0 not known")
+}
+
+func TestParseKernelVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		major   int
+		minor   int
+		err     error
+	}{
+		{"Linux version 2.6.39 (root@debian) (gcc version 4.4.5) #1 PREEMPT", 2, 6, nil},
+		{"Linux version 3.18.14 (acme@acme) (gcc version 4.8.2) #1 Fri", 3, 18, nil},
+		{"Linux version 4.19.66+ (dom@buildbot) (gcc version 4.9.3) #1253", 4, 19, nil},
+		{"Linux version 10.2.0-rc1 (gcc version 9.2.1) #1 SMP", 10, 2, nil},
+
+		{"", 0, 0, errors.New(`failed to find kernel version in ""`)},
+		{"Linux version x.y", 0, 0, errors.New(`failed to find kernel version in "Linux version x.y"`)},
+	}
+
+	for _, test := range tests {
+		major, minor, err := parseKernelVersion(test.version)
+		assert.Equal(t, test.major, major)
+		assert.Equal(t, test.minor, minor)
 		assert.Equal(t, test.err, err)
 	}
 }
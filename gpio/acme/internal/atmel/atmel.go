@@ -0,0 +1,50 @@
+// Package atmel contains the logic shared by the GPIO drivers for the boards
+// of Acme Systems. All of these boards are built around an Atmel SoC and expose
+// the GPIO pins of that SoC via sysfs in the same way. The only thing that
+// differs between the boards is the mapping between the name of a pin on the
+// connector of the board and the pin of the SoC.
+package atmel
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// KernelID returns the kernel ID of a pin of the SoC, like PA23 or PC0, for
+// kernels with version 3.x or later. Every bank of the SoC contains 32 pins.
+// The pins of bank A are numbered 0 to 31, the pins of bank B 32 to 63, etc.
+func KernelID(icPin string) (int, error) {
+	if len(icPin) < 3 || icPin[0] != 'P' || icPin[1] < 'A' || icPin[1] > 'E' {
+		return 0, fmt.Errorf("%v is not a valid pin", icPin)
+	}
+
+	n, err := strconv.Atoi(icPin[2:])
+	if err != nil || n < 0 || n > 31 {
+		return 0, fmt.Errorf("%v is not a valid pin", icPin)
+	}
+
+	return int(icPin[1]-'A')*32 + n, nil
+}
+
+// PinBase returns the name of the folder created when exporting the pin with
+// the given kernel ID. The folder is always called pio, followed by ic pin ID,
+// but without the fist character. So exporting PC0 gives an folder called
+// pioC0.
+func PinBase(kernelID int) string {
+	return fmt.Sprintf("pio%c%d", 'A'+kernelID/32, kernelID%32)
+}
+
+// Mapping converts a mapping between pin names and pins of the SoC to a
+// mapping between pin names and kernel IDs. It panics when the mapping
+// contains an invalid pin of the SoC.
+func Mapping(pins map[string]string) map[string]int {
+	mapping := make(map[string]int, len(pins))
+	for name, icPin := range pins {
+		kernelID, err := KernelID(icPin)
+		if err != nil {
+			panic(err)
+		}
+		mapping[name] = kernelID
+	}
+	return mapping
+}
@@ -0,0 +1,62 @@
+package atmel
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKernelID(t *testing.T) {
+	tests := []struct {
+		icPin string
+		kid   int
+		err   error
+	}{
+		{"PA0", 0, nil},
+		{"PA23", 23, nil},
+		{"PB11", 43, nil},
+		{"PC0", 64, nil},
+		{"PC31", 95, nil},
+		{"PD20", 116, nil},
+		{"PE31", 159, nil},
+
+		{"PA32", 0, errors.New("PA32 is not a valid pin")},
+		{"PF1", 0, errors.New("PF1 is not a valid pin")},
+		{"XA1", 0, errors.New("XA1 is not a valid pin")},
+		{"PA", 0, errors.New("PA is not a valid pin")},
+		{"PAx", 0, errors.New("PAx is not a valid pin")},
+	}
+
+	for _, test := range tests {
+		kid, err := KernelID(test.icPin)
+		assert.Equal(t, test.kid, kid)
+		assert.Equal(t, test.err, err)
+	}
+}
+
+func TestPinBase(t *testing.T) {
+	tests := []struct {
+		kid     int
+		pinBase string
+	}{
+		{0, "pioA0"},
+		{23, "pioA23"},
+		{43, "pioB11"},
+		{64, "pioC0"},
+		{159, "pioE31"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.pinBase, PinBase(test.kid))
+	}
+}
+
+func TestMapping(t *testing.T) {
+	assert.Equal(t, map[string]int{"J4.7": 23, "J4.39": 64}, Mapping(map[string]string{
+		"J4.7":  "PA23",
+		"J4.39": "PC0",
+	}))
+
+	assert.Panics(t, func() { Mapping(map[string]string{"J4.7": "PX23"}) })
+}
@@ -0,0 +1,479 @@
+// +build linux
+
+// Package board creates GPIO pins using the name a pin has on a board, like
+// N16 on the Aria G25 or J4.7 on the Arietta G25. Every board has its own
+// mapping between the names of the pins and the kernel IDs. These mappings are
+// registered with Register. A mapping for a board that isn't supported by one
+// of the board packages can be loaded from a JSON file using LoadFile.
+//
+// All pins created by NewPin share a single Watcher. The Watcher is
+// started when an edge is set on one of the pins for the first time, so a
+// process that only uses pins as outputs never starts one. Call Shutdown to
+// unexport these pins and to stop the Watcher.
+package board
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/advancedclimatesystems/io/internal/errs"
+)
+
+type board struct {
+	// pins maps the name of a pin to its kernel ID.
+	pins map[string]int
+
+	// pinBase returns the name of the folder created when exporting the pin
+	// with the given kernel ID.
+	pinBase func(kernelID int) string
+}
+
+var (
+	m      sync.Mutex
+	boards = make(map[string]board)
+
+	w gpio.Watcher
+	// watchDone is closed when the goroutine running w has returned.
+	watchDone chan struct{}
+	// files are the files added to the Watcher before it has been started.
+	files []*os.File
+	// watchErr is the error that stopped the Watcher. Once set, NewPin fails
+	// until Shutdown is called.
+	watchErr error
+	// pins contains all pins created by NewPin that haven't been closed yet.
+	pins []gpio.GPIO
+
+	// newWatcher, newPin and adoptPin are replaced during tests, so no epoll
+	// loop is started and no sysfs files are written.
+	newWatcher = gpio.NewWatcher
+	newPin     = func(kernelID int, pinBase string, w gpio.Watcher) gpio.GPIO {
+		return gpio.NewPin(kernelID, pinBase, w)
+	}
+	adoptPin = func(kernelID int, pinBase string, w gpio.Watcher) (gpio.GPIO, gpio.AdoptedState, error) {
+		p, s, err := gpio.Adopt(kernelID, pinBase, w)
+		if err != nil {
+			return nil, s, err
+		}
+		return p, s, nil
+	}
+)
+
+// Register registers the mapping between the pin names and kernel IDs of a
+// board under the given name. The folder created by exporting a pin is assumed
+// to be named gpio<kernelID>. Register panics when a board with the same name
+// has been registered already.
+func Register(name string, mapping map[string]int) {
+	RegisterWithBase(name, mapping, defaultPinBase)
+}
+
+// RegisterWithBase is like Register, but it is used for boards that don't name
+// the folder created by exporting a pin gpio<kernelID>. The function pinBase
+// returns the name of that folder for a kernel ID.
+func RegisterWithBase(name string, mapping map[string]int, pinBase func(kernelID int) string) {
+	if err := register(name, mapping, pinBase); err != nil {
+		panic(err)
+	}
+}
+
+// Load reads the mapping between pin names and kernel IDs from r and registers
+// it under the given name. The mapping must be a JSON object with the pin names
+// as keys and the kernel IDs as values, like:
+//
+//	{"J4.7": 23, "J4.8": 22}
+func Load(name string, r io.Reader) error {
+	var mapping map[string]int
+	if err := json.NewDecoder(r).Decode(&mapping); err != nil {
+		return fmt.Errorf("failed to decode mapping of board %v: %w", name, err)
+	}
+
+	return register(name, mapping, defaultPinBase)
+}
+
+// LoadFile is like Load, but reads the mapping from the file at path.
+func LoadFile(name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return Load(name, f)
+}
+
+// NewPin creates and exports the pin with the given name on the board
+// registered under boardName.
+func NewPin(boardName, pinName string) (gpio.GPIO, error) {
+	return NewPinWithWatcher(boardName, pinName, nil)
+}
+
+// NewPinWithWatcher is like NewPin, but the edge events of the pin are handled
+// by w instead of by the Watcher shared by the pins created by NewPin. This
+// allows a single Watcher to be shared with code outside this package. This
+// package doesn't start, stop or close w, the caller is responsible for that.
+// Shutdown does unexport the pin. If w is nil the shared Watcher is used.
+func NewPinWithWatcher(boardName, pinName string, w gpio.Watcher) (gpio.GPIO, error) {
+	kernelID, pinBase, err := lookup(boardName, pinName)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if w == nil {
+		if watchErr != nil {
+			return nil, watchErr
+		}
+		w = lazyWatcher{}
+	}
+
+	p := newPin(kernelID, pinBase, w)
+	if err := p.Export(); err != nil {
+		return nil, err
+	}
+	pins = append(pins, p)
+	return p, nil
+}
+
+// Adopt is like NewPin, but it doesn't export the pin. Instead it adopts a pin
+// that has been exported already, see gpio.Adopt. The current configuration of
+// the pin is returned as well. An adopted pin is unexported by ClosePin and
+// Shutdown, just like the pins created by NewPin.
+func Adopt(boardName, pinName string) (gpio.GPIO, gpio.AdoptedState, error) {
+	kernelID, pinBase, err := lookup(boardName, pinName)
+	if err != nil {
+		return nil, gpio.AdoptedState{}, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if watchErr != nil {
+		return nil, gpio.AdoptedState{}, watchErr
+	}
+
+	p, s, err := adoptPin(kernelID, pinBase, lazyWatcher{})
+	if err != nil {
+		return nil, s, err
+	}
+	pins = append(pins, p)
+	return p, s, nil
+}
+
+// PinSpec describes a pin created by NewPins.
+type PinSpec struct {
+	// Name is the name of the pin on the board.
+	Name string
+	// Direction is the direction of the pin. The direction isn't changed
+	// if it is empty.
+	Direction gpio.Direction
+	// Value is the initial value of an output pin. 0 sets the pin low, any
+	// other value sets it high. It is ignored for input pins.
+	Value int
+	// Edge is the edge on which Callback is called. No edge is set if it is
+	// empty.
+	Edge     gpio.Edge
+	Callback gpio.EdgeEvent
+}
+
+// NewPins creates, exports and configures the pins described by specs on the
+// board registered under boardName. The pins are returned by their name. If
+// one of the pins can't be created or configured, the pins created so far are
+// closed and an error is returned.
+func NewPins(boardName string, specs []PinSpec) (map[string]gpio.GPIO, error) {
+	created := make(map[string]gpio.GPIO, len(specs))
+	for _, spec := range specs {
+		p, err := newPinFromSpec(boardName, spec, created)
+		if err != nil {
+			for _, p := range created {
+				ClosePin(p)
+			}
+			return nil, fmt.Errorf("failed to create pin %v: %w", spec.Name, err)
+		}
+		created[spec.Name] = p
+	}
+	return created, nil
+}
+
+// newPinFromSpec creates the pin described by spec. The pin is closed if it
+// can't be configured.
+func newPinFromSpec(boardName string, spec PinSpec, created map[string]gpio.GPIO) (gpio.GPIO, error) {
+	if _, ok := created[spec.Name]; ok {
+		return nil, fmt.Errorf("pin is specified more than once")
+	}
+
+	p, err := NewPin(boardName, spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := configure(p, spec); err != nil {
+		ClosePin(p)
+		return nil, err
+	}
+	return p, nil
+}
+
+// configure sets the direction, initial value and edge of p.
+func configure(p gpio.GPIO, spec PinSpec) error {
+	if spec.Direction != "" {
+		if err := p.SetDirection(spec.Direction); err != nil {
+			return err
+		}
+	}
+
+	if spec.Direction == gpio.OutDirection {
+		set := p.SetHigh
+		if spec.Value == 0 {
+			set = p.SetLow
+		}
+		if err := set(); err != nil {
+			return err
+		}
+	}
+
+	if spec.Edge != "" {
+		return p.SetEdge(spec.Edge, spec.Callback)
+	}
+	return nil
+}
+
+// ClosePin unexports a pin created by NewPin.
+func ClosePin(p gpio.GPIO) error {
+	if !forget(p) {
+		return fmt.Errorf("pin has not been created by this package or has been closed already")
+	}
+
+	// Unexporting removes the edge event of the pin from the shared
+	// Watcher, which takes m, so it's called without holding m.
+	return p.Unexport()
+}
+
+// forget removes p from pins. It returns false when p isn't in pins.
+func forget(p gpio.GPIO) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	for i, pin := range pins {
+		if pin == p {
+			pins = append(pins[:i], pins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Shutdown unexports all pins created by NewPin, stops the Watcher and closes
+// it. Calling Shutdown more than once is safe. Edge events that are being
+// handled while Shutdown is called are allowed to finish, but pins can't be
+// used anymore once they've been unexported. Shutdown returns once the
+// goroutine running the Watcher has returned.
+//
+// After Shutdown new pins can be created using NewPin, a new Watcher is
+// started in that case. This is also the way to recover from a Watcher that
+// stopped because of an error.
+func Shutdown() error {
+	// The pins are unexported first, this removes their edge events while
+	// the Watcher is still running.
+	err := UnexportAll()
+
+	m.Lock()
+	watcher, done := w, watchDone
+	w, watchDone = nil, nil
+	files = nil
+	watchErr = nil
+	m.Unlock()
+
+	if watcher != nil {
+		// The goroutine running the Watcher takes m once Watch returns,
+		// so it's waited for without holding m.
+		watcher.StopWatch()
+		<-done
+		if cErr := watcher.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+
+	return err
+}
+
+// UnexportAll unexports all pins created by NewPin, but leaves the Watcher
+// running. All pins are unexported, even if unexporting one of them fails. The
+// first error is returned.
+func UnexportAll() error {
+	// Like ClosePin, the pins are unexported without holding m.
+	m.Lock()
+	ps := pins
+	pins = nil
+	m.Unlock()
+
+	var err error
+	for _, p := range ps {
+		if uErr := p.Unexport(); uErr != nil && err == nil {
+			err = uErr
+		}
+	}
+	return err
+}
+
+// KernelID returns the kernel ID of the pin with the given name on the board
+// registered under boardName.
+func KernelID(boardName, pinName string) (int, error) {
+	kernelID, _, err := lookup(boardName, pinName)
+	return kernelID, err
+}
+
+func register(name string, mapping map[string]int, pinBase func(int) string) error {
+	if mapping == nil {
+		return fmt.Errorf("mapping of board %v is nil", name)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := boards[name]; ok {
+		return fmt.Errorf("board %v has been registered already", name)
+	}
+
+	// Copy the mapping, so it can't be modified after registration.
+	pins := make(map[string]int, len(mapping))
+	for k, v := range mapping {
+		pins[k] = v
+	}
+
+	boards[name] = board{
+		pins:    pins,
+		pinBase: pinBase,
+	}
+	return nil
+}
+
+// lookup returns the kernel ID and the pin base of a pin.
+func lookup(boardName, pinName string) (int, string, error) {
+	m.Lock()
+	b, ok := boards[boardName]
+	m.Unlock()
+
+	if !ok {
+		return 0, "", fmt.Errorf("board %v not known", boardName)
+	}
+
+	kernelID, ok := b.pins[pinName]
+	if !ok {
+		return 0, "", errs.New(gpio.ErrInvalidPin, "id %v not known", pinName)
+	}
+
+	return kernelID, b.pinBase(kernelID), nil
+}
+
+// defaultPinBase returns the name of the folder that sysfs creates for an
+// exported pin on most boards.
+func defaultPinBase(kernelID int) string {
+	return fmt.Sprintf("gpio%d", kernelID)
+}
+
+// setupWatcher creates a new watcher and starts it, if its not already running.
+// The caller must hold m.
+func setupWatcher() error {
+	// A Watcher only needs to be setup once, but an error can't be handled in an
+	// init function.
+	if w != nil {
+		return nil
+	}
+
+	watcher, err := newWatcher()
+	if err != nil {
+		return err
+	}
+	w = watcher
+
+	done := make(chan struct{})
+	watchDone = done
+	go func() {
+		defer close(done)
+		err := watcher.Watch()
+
+		// The watcher stopped on its own, so it has not been closed by
+		// Shutdown yet.
+		m.Lock()
+		defer m.Unlock()
+		if w == watcher {
+			if err != nil {
+				watchErr = fmt.Errorf("watcher of GPIO edge events failed: %w", err)
+			}
+			watcher.Close()
+			w, watchDone = nil, nil
+		}
+	}()
+	return nil
+}
+
+// lazyWatcher is the gpio.Watcher given to the pins created by NewPin. It
+// forwards the files and events of the pins to the shared Watcher, which is
+// created and started by the first call to AddEvent.
+type lazyWatcher struct{}
+
+// Watch returns an error, the shared Watcher is started by AddEvent.
+func (lazyWatcher) Watch() error {
+	return fmt.Errorf("watcher of board pins is started by AddEvent")
+}
+
+// StopWatch does nothing, the shared Watcher is stopped by Shutdown.
+func (lazyWatcher) StopWatch() {}
+
+// Close does nothing, the shared Watcher is closed by Shutdown.
+func (lazyWatcher) Close() error { return nil }
+
+func (lazyWatcher) AddFile(file *os.File) {
+	m.Lock()
+	defer m.Unlock()
+
+	if w == nil {
+		files = append(files, file)
+		return
+	}
+	w.AddFile(file)
+}
+
+func (lazyWatcher) AddEvent(fpntr int, callback func()) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if watchErr != nil {
+		return watchErr
+	}
+
+	if err := setupWatcher(); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		w.AddFile(f)
+	}
+	files = nil
+
+	return w.AddEvent(fpntr, callback)
+}
+
+// RemoveEvent removes the event from the shared Watcher. Without a shared
+// Watcher no event has been added, but the file might be waiting to be added
+// to the Watcher, so it's forgotten.
+func (lazyWatcher) RemoveEvent(fpntr int) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if w == nil {
+		for i, f := range files {
+			if f != nil && int(f.Fd()) == fpntr {
+				files = append(files[:i], files[i+1:]...)
+				break
+			}
+		}
+		return nil
+	}
+	return w.RemoveEvent(fpntr)
+}
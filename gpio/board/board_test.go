@@ -0,0 +1,657 @@
+package board
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	mapping := map[string]int{"P1": 17, "P2": 18}
+	Register("test-register", mapping)
+
+	// Changing the mapping after registration doesn't affect the board.
+	mapping["P1"] = 4
+
+	kid, err := KernelID("test-register", "P1")
+	assert.Nil(t, err)
+	assert.Equal(t, 17, kid)
+
+	assert.Panics(t, func() { Register("test-register", mapping) })
+	assert.Panics(t, func() { Register("test-register-nil", nil) })
+}
+
+func TestLookup(t *testing.T) {
+	Register("test-lookup", map[string]int{"P1": 17})
+	RegisterWithBase("test-lookup-base", map[string]int{"PA3": 3}, func(id int) string {
+		return "pioA3"
+	})
+
+	tests := []struct {
+		board   string
+		pin     string
+		kid     int
+		pinBase string
+		err     error
+	}{
+		{"test-lookup", "P1", 17, "gpio17", nil},
+		{"test-lookup-base", "PA3", 3, "pioA3", nil},
+		{"test-lookup", "P2", 0, "", errors.New("id P2 not known")},
+		{"unknown", "P1", 0, "", errors.New("board unknown not known")},
+	}
+
+	for _, test := range tests {
+		kid, pinBase, err := lookup(test.board, test.pin)
+		assert.Equal(t, test.kid, kid)
+		assert.Equal(t, test.pinBase, pinBase)
+		if test.err == nil {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.err.Error())
+	}
+}
+
+func TestLoad(t *testing.T) {
+	assert.Nil(t, Load("test-load", strings.NewReader(`{"J4.7": 23, "J4.8": 22}`)))
+
+	kid, err := KernelID("test-load", "J4.8")
+	assert.Nil(t, err)
+	assert.Equal(t, 22, kid)
+
+	assert.NotNil(t, Load("test-load", strings.NewReader(`{"J4.7": 23}`)))
+	assert.NotNil(t, Load("test-load-invalid", strings.NewReader(`{"J4.7": "PA23"}`)))
+	assert.NotNil(t, Load("test-load-null", strings.NewReader(`null`)))
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "board")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "board.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"GPIO17": 17}`), 0644))
+	assert.Nil(t, LoadFile("test-load-file", path))
+
+	kid, err := KernelID("test-load-file", "GPIO17")
+	assert.Nil(t, err)
+	assert.Equal(t, 17, kid)
+
+	assert.NotNil(t, LoadFile("test-load-file-missing", filepath.Join(dir, "missing.json")))
+}
+
+// fakeWatcher is a gpio.Watcher that doesn't use epoll.
+type fakeWatcher struct {
+	stop chan struct{}
+	// returned is closed when Watch returns.
+	returned chan struct{}
+	// watchErr is returned by Watch. If set, Watch returns immediately.
+	watchErr error
+
+	m       sync.Mutex
+	stopped int
+	closed  int
+	events  int
+	files   int
+}
+
+func newFakeWatcher() *fakeWatcher {
+	return &fakeWatcher{stop: make(chan struct{}), returned: make(chan struct{})}
+}
+
+func (f *fakeWatcher) Watch() error {
+	defer close(f.returned)
+	if f.watchErr != nil {
+		return f.watchErr
+	}
+	<-f.stop
+	return nil
+}
+
+func (f *fakeWatcher) StopWatch() {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.stopped == 0 {
+		close(f.stop)
+	}
+	f.stopped++
+}
+
+func (f *fakeWatcher) AddEvent(fpntr int, callback func()) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.events++
+	return nil
+}
+
+func (f *fakeWatcher) RemoveEvent(fpntr int) error {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.events--
+	return nil
+}
+
+func (f *fakeWatcher) AddFile(file *os.File) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.files++
+}
+
+func (f *fakeWatcher) Close() error {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.closed++
+	return nil
+}
+
+// activeEvents returns the number of events that have been added, but not
+// removed.
+func (f *fakeWatcher) activeEvents() int {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.events
+}
+
+// assertReturned asserts that Watch has returned.
+func (f *fakeWatcher) assertReturned(t *testing.T) {
+	select {
+	case <-f.returned:
+	default:
+		t.Error("Watch hasn't returned")
+	}
+}
+
+func (f *fakeWatcher) calls() (int, int) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.stopped, f.closed
+}
+
+// fakePin is a gpio.GPIO that doesn't touch sysfs.
+type fakePin struct {
+	gpio.GPIO
+
+	kernelID    int
+	w           gpio.Watcher
+	exported    bool
+	unexportErr error
+
+	direction    gpio.Direction
+	directionErr error
+	value        int
+	edge         gpio.Edge
+	edgeErr      error
+}
+
+func (p *fakePin) SetDirection(d gpio.Direction) error {
+	if p.directionErr != nil {
+		return p.directionErr
+	}
+	p.direction = d
+	return nil
+}
+
+func (p *fakePin) SetHigh() error {
+	p.value = 1
+	return nil
+}
+
+func (p *fakePin) SetLow() error {
+	p.value = 0
+	return nil
+}
+
+func (p *fakePin) SetEdge(e gpio.Edge, f gpio.EdgeEvent) error {
+	if p.edgeErr != nil {
+		return p.edgeErr
+	}
+	p.edge = e
+	return p.w.AddEvent(p.kernelID, func() {})
+}
+
+func (p *fakePin) Export() error {
+	p.exported = true
+	return nil
+}
+
+func (p *fakePin) Unexport() error {
+	// Like a gpio.Pin, the edge event is removed before unexporting.
+	if p.edge != "" && p.edge != gpio.NoneEdge {
+		p.edge = gpio.NoneEdge
+		if err := p.w.RemoveEvent(p.kernelID); err != nil {
+			return err
+		}
+	}
+	p.exported = false
+	return p.unexportErr
+}
+
+// useFakes replaces the Watcher and the pins created by NewPin with fakes. The
+// returned function restores the original behavior.
+func useFakes(t *testing.T, watchers ...*fakeWatcher) func() {
+	origWatcher, origPin, origAdopt := newWatcher, newPin, adoptPin
+
+	newWatcher = func() (gpio.Watcher, error) {
+		if len(watchers) == 0 {
+			t.Error("unexpected creation of watcher")
+			return nil, errors.New("unexpected creation of watcher")
+		}
+		fw := watchers[0]
+		watchers = watchers[1:]
+		return fw, nil
+	}
+	newPin = func(kernelID int, _ string, w gpio.Watcher) gpio.GPIO {
+		return &fakePin{kernelID: kernelID, w: w}
+	}
+	adoptPin = func(kernelID int, _ string, w gpio.Watcher) (gpio.GPIO, gpio.AdoptedState, error) {
+		p := &fakePin{kernelID: kernelID, w: w, exported: true, direction: gpio.InDirection}
+		return p, gpio.AdoptedState{Direction: gpio.InDirection, Edge: gpio.NoneEdge}, nil
+	}
+
+	return func() {
+		assert.Nil(t, Shutdown())
+		newWatcher, newPin, adoptPin = origWatcher, origPin, origAdopt
+	}
+}
+
+func TestClosePin(t *testing.T) {
+	fw := newFakeWatcher()
+	defer useFakes(t, fw)()
+	Register("test-close-pin", map[string]int{"P1": 1, "P2": 2})
+
+	p1, err := NewPin("test-close-pin", "P1")
+	assert.Nil(t, err)
+	p2, err := NewPin("test-close-pin", "P2")
+	assert.Nil(t, err)
+	assert.True(t, p1.(*fakePin).exported)
+
+	assert.Nil(t, ClosePin(p1))
+	assert.False(t, p1.(*fakePin).exported)
+	assert.True(t, p2.(*fakePin).exported)
+
+	// Closing a pin twice fails.
+	assert.NotNil(t, ClosePin(p1))
+
+	p2.(*fakePin).unexportErr = errors.New("error")
+	assert.EqualError(t, ClosePin(p2), "error")
+}
+
+func TestShutdown(t *testing.T) {
+	fw1, fw2 := newFakeWatcher(), newFakeWatcher()
+	defer useFakes(t, fw1, fw2)()
+	Register("test-shutdown", map[string]int{"P1": 1, "P2": 2})
+
+	p1, _ := NewPin("test-shutdown", "P1")
+	p2, _ := NewPin("test-shutdown", "P2")
+	p2.(*fakePin).unexportErr = errors.New("error")
+	assert.Nil(t, p1.SetEdge(gpio.RisingEdge, nil))
+
+	// The error of the failing pin is returned, but all pins are unexported
+	// and the watcher is stopped and closed anyway. The edge event of p1 is
+	// removed and Shutdown waits for the watcher to return.
+	assert.EqualError(t, Shutdown(), "error")
+	assert.False(t, p1.(*fakePin).exported)
+	assert.False(t, p2.(*fakePin).exported)
+	assert.Equal(t, 0, fw1.activeEvents())
+	fw1.assertReturned(t)
+
+	stopped, closed := fw1.calls()
+	assert.Equal(t, 1, stopped)
+	assert.Equal(t, 1, closed)
+
+	// Shutdown is idempotent.
+	assert.Nil(t, Shutdown())
+	stopped, closed = fw1.calls()
+	assert.Equal(t, 1, stopped)
+	assert.Equal(t, 1, closed)
+
+	// A new watcher is created for pins created after Shutdown.
+	p, err := NewPin("test-shutdown", "P1")
+	assert.Nil(t, err)
+	assert.Nil(t, p.(*fakePin).w.AddEvent(1, func() {}))
+	assert.Nil(t, Shutdown())
+	fw2.assertReturned(t)
+	stopped, closed = fw2.calls()
+	assert.Equal(t, 1, stopped)
+	assert.Equal(t, 1, closed)
+}
+
+func TestUnexportAll(t *testing.T) {
+	fw := newFakeWatcher()
+	defer useFakes(t, fw)()
+	Register("test-unexport-all", map[string]int{"P1": 1, "P2": 2})
+
+	p1, _ := NewPin("test-unexport-all", "P1")
+	p2, _ := NewPin("test-unexport-all", "P2")
+	assert.Nil(t, p1.(*fakePin).w.AddEvent(1, func() {}))
+	p1.(*fakePin).unexportErr = errors.New("error")
+
+	assert.EqualError(t, UnexportAll(), "error")
+	assert.False(t, p1.(*fakePin).exported)
+	assert.False(t, p2.(*fakePin).exported)
+
+	// The pins aren't tracked anymore, but the watcher keeps running.
+	assert.NotNil(t, ClosePin(p2))
+	stopped, closed := fw.calls()
+	assert.Equal(t, 0, stopped)
+	assert.Equal(t, 0, closed)
+
+	p, err := NewPin("test-unexport-all", "P2")
+	assert.Nil(t, err)
+	assert.Nil(t, p.(*fakePin).w.AddEvent(2, func() {}))
+	assert.Nil(t, UnexportAll())
+}
+
+func TestNewPinAfterWatcherFailure(t *testing.T) {
+	fw1, fw2 := newFakeWatcher(), newFakeWatcher()
+	fw1.watchErr = errors.New("epoll failed")
+	defer useFakes(t, fw1, fw2)()
+	Register("test-watcher-failure", map[string]int{"P1": 1, "P2": 2})
+
+	p, err := NewPin("test-watcher-failure", "P1")
+	assert.Nil(t, err)
+	assert.Nil(t, p.(*fakePin).w.AddEvent(1, func() {}))
+
+	// Wait until the failure of the watcher has been recorded.
+	for i := 0; ; i++ {
+		m.Lock()
+		failed := watchErr != nil
+		m.Unlock()
+		if failed {
+			break
+		}
+		if i == 100 {
+			t.Fatal("failure of watcher has not been recorded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	_, closed := fw1.calls()
+	assert.Equal(t, 1, closed)
+
+	_, err = NewPin("test-watcher-failure", "P2")
+	assert.EqualError(t, err, "watcher of GPIO edge events failed: epoll failed")
+	assert.EqualError(t, p.(*fakePin).w.AddEvent(1, func() {}), "watcher of GPIO edge events failed: epoll failed")
+
+	// Shutdown resets the failure, a new watcher is started when an edge is
+	// set.
+	assert.Nil(t, Shutdown())
+	p, err = NewPin("test-watcher-failure", "P2")
+	assert.Nil(t, err)
+	assert.Nil(t, p.(*fakePin).w.AddEvent(1, func() {}))
+}
+
+// TestLazyWatcher tests if the watcher is only created when an edge is set on
+// a pin.
+func TestLazyWatcher(t *testing.T) {
+	fw := newFakeWatcher()
+	defer useFakes(t, fw)()
+	Register("test-lazy-watcher", map[string]int{"P1": 1, "P2": 2})
+
+	p1, err := NewPin("test-lazy-watcher", "P1")
+	assert.Nil(t, err)
+	p2, err := NewPin("test-lazy-watcher", "P2")
+	assert.Nil(t, err)
+
+	m.Lock()
+	assert.Nil(t, w)
+	m.Unlock()
+
+	// Files added before the watcher exists are handed over to it once it
+	// has been created.
+	p1.(*fakePin).w.AddFile(nil)
+	assert.Nil(t, p1.(*fakePin).w.AddEvent(1, func() {}))
+	p2.(*fakePin).w.AddFile(nil)
+	assert.Nil(t, p2.(*fakePin).w.AddEvent(2, func() {}))
+
+	m.Lock()
+	assert.Equal(t, fw, w)
+	m.Unlock()
+
+	fw.m.Lock()
+	assert.Equal(t, 2, fw.files)
+	assert.Equal(t, 2, fw.events)
+	fw.m.Unlock()
+
+	// The watcher of a pin can't stop or close the shared watcher.
+	p1.(*fakePin).w.StopWatch()
+	assert.Nil(t, p1.(*fakePin).w.Close())
+	assert.NotNil(t, p1.(*fakePin).w.Watch())
+	stopped, closed := fw.calls()
+	assert.Equal(t, 0, stopped)
+	assert.Equal(t, 0, closed)
+}
+
+// TestLazyWatcherRemoveEvent tests if a file that's waiting to be added to
+// the watcher is forgotten when its event is removed.
+func TestLazyWatcherRemoveEvent(t *testing.T) {
+	fw := newFakeWatcher()
+	defer useFakes(t, fw)()
+	Register("test-lazy-watcher-remove", map[string]int{"P1": 1})
+
+	p, err := NewPin("test-lazy-watcher-remove", "P1")
+	assert.Nil(t, err)
+
+	f, err := ioutil.TempFile("", "value")
+	assert.Nil(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	lw := p.(*fakePin).w
+	lw.AddFile(f)
+	assert.Nil(t, lw.RemoveEvent(int(f.Fd())))
+
+	m.Lock()
+	assert.Empty(t, files)
+	m.Unlock()
+
+	assert.Nil(t, lw.AddEvent(1, func() {}))
+	fw.m.Lock()
+	assert.Equal(t, 0, fw.files)
+	fw.m.Unlock()
+}
+
+// TestLazyWatcherConcurrentUse tests if only a single watcher is created when
+// edges are set on 2 pins at the same time.
+func TestLazyWatcherConcurrentUse(t *testing.T) {
+	fw := newFakeWatcher()
+	defer useFakes(t, fw)()
+	Register("test-lazy-watcher-concurrent", map[string]int{"P1": 1, "P2": 2})
+
+	p1, _ := NewPin("test-lazy-watcher-concurrent", "P1")
+	p2, _ := NewPin("test-lazy-watcher-concurrent", "P2")
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i, p := range []gpio.GPIO{p1, p2} {
+		wg.Add(1)
+		go func(fd int, p gpio.GPIO) {
+			defer wg.Done()
+			<-start
+			p.(*fakePin).w.AddFile(nil)
+			assert.Nil(t, p.(*fakePin).w.AddEvent(fd, func() {}))
+		}(i, p)
+	}
+	close(start)
+	wg.Wait()
+
+	fw.m.Lock()
+	assert.Equal(t, 2, fw.files)
+	assert.Equal(t, 2, fw.events)
+	fw.m.Unlock()
+}
+
+// TestNewPinConcurrentUse tests if pins can be created and their edges set
+// from many goroutines at once, while only a single watcher is created.
+func TestNewPinConcurrentUse(t *testing.T) {
+	fw := newFakeWatcher()
+	defer useFakes(t, fw)()
+
+	ids := make(map[string]int)
+	for i := 0; i < 8; i++ {
+		ids[fmt.Sprintf("P%d", i)] = i
+	}
+	Register("test-new-pin-concurrent", ids)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+
+			p, err := NewPin("test-new-pin-concurrent", fmt.Sprintf("P%d", i))
+			if !assert.Nil(t, err) {
+				return
+			}
+			p.(*fakePin).w.AddFile(nil)
+			assert.Nil(t, p.(*fakePin).w.AddEvent(i, func() {}))
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	fw.m.Lock()
+	assert.Equal(t, 8, fw.files)
+	assert.Equal(t, 8, fw.events)
+	fw.m.Unlock()
+}
+
+func TestNewPinWithWatcher(t *testing.T) {
+	fw := newFakeWatcher()
+	// No watcher is expected to be created.
+	defer useFakes(t)()
+	Register("test-new-pin-with-watcher", map[string]int{"P1": 1})
+
+	p, err := NewPinWithWatcher("test-new-pin-with-watcher", "P1", fw)
+	assert.Nil(t, err)
+	assert.Equal(t, fw, p.(*fakePin).w)
+	assert.Nil(t, p.(*fakePin).w.AddEvent(1, func() {}))
+
+	_, err = NewPinWithWatcher("test-new-pin-with-watcher", "P2", fw)
+	assert.EqualError(t, err, "id P2 not known")
+
+	// Shutdown unexports the pin, but leaves the watcher alone.
+	assert.Nil(t, Shutdown())
+	assert.False(t, p.(*fakePin).exported)
+	stopped, closed := fw.calls()
+	assert.Equal(t, 0, stopped)
+	assert.Equal(t, 0, closed)
+}
+
+func TestNewPins(t *testing.T) {
+	fw := newFakeWatcher()
+	defer useFakes(t, fw)()
+	Register("test-new-pins", map[string]int{"P1": 1, "P2": 2, "P3": 3})
+
+	pins, err := NewPins("test-new-pins", []PinSpec{
+		{Name: "P1", Direction: gpio.OutDirection, Value: 1},
+		{Name: "P2", Direction: gpio.OutDirection},
+		{Name: "P3", Direction: gpio.InDirection, Edge: gpio.RisingEdge, Callback: func(*gpio.Pin) {}},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, pins, 3)
+
+	p1, p2, p3 := pins["P1"].(*fakePin), pins["P2"].(*fakePin), pins["P3"].(*fakePin)
+	assert.Equal(t, gpio.OutDirection, p1.direction)
+	assert.Equal(t, 1, p1.value)
+	assert.Equal(t, gpio.OutDirection, p2.direction)
+	assert.Equal(t, 0, p2.value)
+	assert.Equal(t, gpio.InDirection, p3.direction)
+	assert.Equal(t, gpio.RisingEdge, p3.edge)
+	assert.True(t, p3.exported)
+
+	fw.m.Lock()
+	assert.Equal(t, 1, fw.events)
+	fw.m.Unlock()
+}
+
+// TestNewPinsRollback tests if the pins created by NewPins are closed when one
+// of the pins fails.
+func TestNewPinsRollback(t *testing.T) {
+	defer useFakes(t)()
+	Register("test-new-pins-rollback", map[string]int{"P1": 1, "P2": 2, "P3": 3})
+
+	var created []*fakePin
+	newPin = func(kernelID int, _ string, w gpio.Watcher) gpio.GPIO {
+		p := &fakePin{kernelID: kernelID, w: w}
+		if kernelID == 2 {
+			p.directionErr = errors.New("permission denied")
+		}
+		created = append(created, p)
+		return p
+	}
+
+	got, err := NewPins("test-new-pins-rollback", []PinSpec{
+		{Name: "P1", Direction: gpio.OutDirection},
+		{Name: "P2", Direction: gpio.OutDirection},
+		{Name: "P3", Direction: gpio.OutDirection},
+	})
+	assert.Nil(t, got)
+	assert.EqualError(t, err, "failed to create pin P2: permission denied")
+
+	// P3 is never created, P1 and P2 are unexported again.
+	assert.Len(t, created, 2)
+	for _, p := range created {
+		assert.False(t, p.exported)
+	}
+
+	// The closed pins aren't tracked anymore.
+	m.Lock()
+	assert.Len(t, pins, 0)
+	m.Unlock()
+
+	tests := []struct {
+		specs []PinSpec
+		err   string
+	}{
+		{[]PinSpec{{Name: "P1"}, {Name: "P4"}}, "failed to create pin P4: id P4 not known"},
+		{[]PinSpec{{Name: "P1"}, {Name: "P1"}}, "failed to create pin P1: pin is specified more than once"},
+	}
+
+	for _, test := range tests {
+		created = nil
+		_, err := NewPins("test-new-pins-rollback", test.specs)
+		assert.EqualError(t, err, test.err)
+		for _, p := range created {
+			assert.False(t, p.exported)
+		}
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	defer useFakes(t)()
+	Register("test-adopt", map[string]int{"P1": 1})
+
+	p, s, err := Adopt("test-adopt", "P1")
+	assert.Nil(t, err)
+	assert.Equal(t, gpio.AdoptedState{Direction: gpio.InDirection, Edge: gpio.NoneEdge}, s)
+	assert.True(t, p.(*fakePin).exported)
+
+	// Adopted pins are unexported by ClosePin.
+	assert.Nil(t, ClosePin(p))
+	assert.False(t, p.(*fakePin).exported)
+
+	_, _, err = Adopt("test-adopt", "P2")
+	assert.EqualError(t, err, "id P2 not known")
+
+	adoptPin = func(int, string, gpio.Watcher) (gpio.GPIO, gpio.AdoptedState, error) {
+		return nil, gpio.AdoptedState{}, errors.New("pin 1 has not been exported")
+	}
+	_, _, err = Adopt("test-adopt", "P1")
+	assert.EqualError(t, err, "pin 1 has not been exported")
+
+	m.Lock()
+	assert.Len(t, pins, 0)
+	m.Unlock()
+}
@@ -0,0 +1,122 @@
+// +build linux
+
+package gpio
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultDebounce is a debounce period that suits most momentary switches.
+const DefaultDebounce = 20 * time.Millisecond
+
+// Button is a momentary switch connected to an input pin. The functions
+// registered with OnPress and OnRelease are called when the switch is pressed
+// or released.
+//
+// The contacts of a switch bounce, so a single press causes a burst of edges.
+// Button waits until the value of the pin has been stable for the debounce
+// period before it reads the value, a burst of edges results in at most one
+// call of a function.
+//
+// The button is pressed when the value of the pin is 1. Use SetActiveLow on the
+// pin if the switch pulls the pin low when pressed.
+type Button struct {
+	pin      *Pin
+	debounce time.Duration
+
+	m         sync.Mutex
+	timer     *time.Timer
+	pressed   bool
+	onPress   func()
+	onRelease func()
+}
+
+// NewButton configures p as an input and watches it for rising and falling
+// edges. Edges within the debounce period of each other are treated as a
+// single change of the value.
+func NewButton(p *Pin, debounce time.Duration) (*Button, error) {
+	b := &Button{
+		pin:      p,
+		debounce: debounce,
+	}
+
+	if err := p.SetDirection(InDirection); err != nil {
+		return nil, err
+	}
+
+	v, err := p.Value()
+	if err != nil {
+		return nil, err
+	}
+	b.pressed = v == 1
+
+	if err := p.SetEdge(BothEdge, b.handleEdge); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// OnPress registers f to be called when the button is pressed. It replaces a
+// function registered before.
+func (b *Button) OnPress(f func()) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.onPress = f
+}
+
+// OnRelease registers f to be called when the button is released. It replaces
+// a function registered before.
+func (b *Button) OnRelease(f func()) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.onRelease = f
+}
+
+// Pressed returns true if the button was pressed when its value was read last.
+func (b *Button) Pressed() bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.pressed
+}
+
+// handleEdge is called by the Watcher on every edge. It (re)starts the timer
+// that reads the value of the pin once it has settled.
+func (b *Button) handleEdge(*Pin) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.debounce, b.settle)
+		return
+	}
+	b.timer.Reset(b.debounce)
+}
+
+// settle reads the value of the pin and calls the function registered for the
+// change, if the value has changed. A failed read is ignored, the next edge
+// causes another read.
+func (b *Button) settle() {
+	v, err := b.pin.Value()
+	if err != nil {
+		return
+	}
+
+	b.m.Lock()
+	pressed := v == 1
+	if pressed == b.pressed {
+		b.m.Unlock()
+		return
+	}
+	b.pressed = pressed
+
+	f := b.onRelease
+	if pressed {
+		f = b.onPress
+	}
+	b.m.Unlock()
+
+	if f != nil {
+		f()
+	}
+}
@@ -0,0 +1,118 @@
+// +build linux
+
+package gpio
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// valueReaderWriter is a rwHelper that can be safely used by multiple
+// goroutines. It only knows about the value and edge files.
+type valueReaderWriter struct {
+	m     sync.Mutex
+	value string
+	// writes contains the values written to the value file.
+	writes []string
+	// edges contains the values written to the edge file.
+	edges []string
+}
+
+func (v *valueReaderWriter) set(value string) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	v.value = value
+}
+
+func (v *valueReaderWriter) readAllFromBase(pathFromBase string) ([]byte, error) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	return []byte(v.value), nil
+}
+
+func (v *valueReaderWriter) writeFromBase(b []byte, pathFromBase string) error {
+	v.m.Lock()
+	defer v.m.Unlock()
+	if pathFromBase == "gpio1/value" {
+		v.value = string(b)
+		v.writes = append(v.writes, string(b))
+	}
+	if pathFromBase == "gpio1/edge" {
+		v.edges = append(v.edges, string(b))
+	}
+	return nil
+}
+
+func (v *valueReaderWriter) get() (string, []string) {
+	v.m.Lock()
+	defer v.m.Unlock()
+	return v.value, append([]string(nil), v.writes...)
+}
+
+func (v *valueReaderWriter) getEdges() []string {
+	v.m.Lock()
+	defer v.m.Unlock()
+	return append([]string(nil), v.edges...)
+}
+
+func (v *valueReaderWriter) exists(pathFromBase string) (bool, error) {
+	return true, nil
+}
+
+func (v *valueReaderWriter) openFromBase(pathFromBase string) (*os.File, error) {
+	return openTemp()
+}
+
+func TestButton(t *testing.T) {
+	rw := &valueReaderWriter{value: "0"}
+	p := NewPin(1, "gpio1", new(watch))
+	p.rwHelper = rw
+
+	b := &Button{pin: p, debounce: 5 * time.Millisecond}
+	presses, releases := make(chan struct{}, 2), make(chan struct{}, 2)
+	b.OnPress(func() { presses <- struct{}{} })
+	b.OnRelease(func() { releases <- struct{}{} })
+
+	// A burst of edges causes a single press.
+	rw.set("1")
+	for i := 0; i < 3; i++ {
+		b.handleEdge(p)
+	}
+
+	select {
+	case <-presses:
+	case <-time.After(time.Second):
+		t.Fatal("press has not been detected")
+	}
+	assert.True(t, b.Pressed())
+
+	rw.set("0")
+	b.handleEdge(p)
+
+	select {
+	case <-releases:
+	case <-time.After(time.Second):
+		t.Fatal("release has not been detected")
+	}
+	assert.False(t, b.Pressed())
+
+	// Bouncing that ends in the original state isn't a press.
+	b.handleEdge(p)
+	time.Sleep(20 * time.Millisecond)
+
+	assert.Len(t, presses, 0)
+	assert.Len(t, releases, 0)
+}
+
+func TestNewButtonWithFailingPin(t *testing.T) {
+	p := NewPin(1, "gpio1", new(watch))
+	p.rwHelper = mockReaderWriter{&testValues{mockErr: errors.New("error")}}
+
+	_, err := NewButton(p, DefaultDebounce)
+	assert.EqualError(t, err, "error")
+}
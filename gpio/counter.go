@@ -0,0 +1,55 @@
+// +build linux
+
+package gpio
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Counter counts the edges of an input pin, for example the pulses of a flow
+// meter or tachometer.
+type Counter struct {
+	// count must be the first field, so it's 64 bit aligned on 32 bit
+	// platforms.
+	count uint64
+
+	pin *Pin
+}
+
+// NewCounter returns a Counter for p. Counting starts when Start is called.
+func NewCounter(p *Pin) *Counter {
+	return &Counter{pin: p}
+}
+
+// Start configures the pin as an input and counts every edge of the given
+// type from now on.
+func (c *Counter) Start(e Edge) error {
+	if err := c.pin.SetDirection(InDirection); err != nil {
+		return err
+	}
+	return c.pin.SetEdge(e, c.handleEdge)
+}
+
+// Count returns the number of edges counted.
+func (c *Counter) Count() uint64 {
+	return atomic.LoadUint64(&c.count)
+}
+
+// Reset sets the count to 0.
+func (c *Counter) Reset() {
+	atomic.StoreUint64(&c.count, 0)
+}
+
+// Rate blocks for window and returns the number of edges per second during
+// that window.
+func (c *Counter) Rate(window time.Duration) float64 {
+	start := c.Count()
+	time.Sleep(window)
+	return float64(c.Count()-start) / window.Seconds()
+}
+
+// handleEdge is called by the watcher for every edge.
+func (c *Counter) handleEdge(p *Pin) {
+	atomic.AddUint64(&c.count, 1)
+}
@@ -0,0 +1,53 @@
+// +build linux
+
+package gpio
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounter(t *testing.T) {
+	p := NewPin(1, "gpio1", new(watch))
+	c := NewCounter(p)
+
+	// The watcher calls the callback from its own goroutine.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.handleEdge(p)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(1000), c.Count())
+
+	c.Reset()
+	assert.Equal(t, uint64(0), c.Count())
+}
+
+func TestCounterRate(t *testing.T) {
+	p := NewPin(1, "gpio1", new(watch))
+	c := NewCounter(p)
+
+	// Edges counted before the window don't affect the rate.
+	c.handleEdge(p)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		time.Sleep(10 * time.Millisecond)
+		for i := 0; i < 5; i++ {
+			c.handleEdge(p)
+		}
+	}()
+
+	assert.Equal(t, 50.0, c.Rate(100*time.Millisecond))
+	<-done
+}
@@ -0,0 +1,98 @@
+// +build linux
+
+package gpio
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// transitions maps a transition of the quadrature state to a change of the
+// position. The index is the previous state shifted left by 2 bits, plus the
+// current state. A state is the value of A shifted left by 1 bit, plus the
+// value of B. Invalid transitions, where both A and B changed because an edge
+// has been missed, don't change the position.
+var transitions = [16]int64{
+	0, -1, 1, 0,
+	1, 0, 0, -1,
+	-1, 0, 0, 1,
+	0, 1, -1, 0,
+}
+
+// Encoder decodes the position of a rotary encoder with quadrature outputs A
+// and B. Every edge of A or B changes the position by 1, so the position
+// changes 4 times per cycle of the encoder. The position increases when A
+// leads B.
+type Encoder struct {
+	// position must be the first field, so it's 64 bit aligned on 32 bit
+	// platforms.
+	position int64
+
+	a, b *Pin
+
+	m     sync.Mutex
+	state int
+}
+
+// NewEncoder configures a and b as inputs and watches both for rising and
+// falling edges.
+func NewEncoder(a, b *Pin) (*Encoder, error) {
+	e := &Encoder{a: a, b: b}
+
+	for _, p := range []*Pin{a, b} {
+		if err := p.SetDirection(InDirection); err != nil {
+			return nil, err
+		}
+	}
+
+	state, err := e.read()
+	if err != nil {
+		return nil, err
+	}
+	e.state = state
+
+	for _, p := range []*Pin{a, b} {
+		if err := p.SetEdge(BothEdge, e.handleEdge); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Position returns the position of the encoder.
+func (e *Encoder) Position() int64 {
+	return atomic.LoadInt64(&e.position)
+}
+
+// Reset sets the position to 0.
+func (e *Encoder) Reset() {
+	atomic.StoreInt64(&e.position, 0)
+}
+
+// handleEdge is called by the watcher for every edge of A or B.
+func (e *Encoder) handleEdge(p *Pin) {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	state, err := e.read()
+	if err != nil {
+		return
+	}
+
+	atomic.AddInt64(&e.position, transitions[e.state<<2|state])
+	e.state = state
+}
+
+// read returns the current quadrature state.
+func (e *Encoder) read() (int, error) {
+	a, err := e.a.Value()
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := e.b.Value()
+	if err != nil {
+		return 0, err
+	}
+	return a<<1 | b, nil
+}
@@ -0,0 +1,52 @@
+// +build linux
+
+package gpio
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	rwA, rwB := &valueReaderWriter{value: "0"}, &valueReaderWriter{value: "0"}
+	a, b := NewPin(1, "gpio1", new(watch)), NewPin(2, "gpio2", new(watch))
+	a.rwHelper, b.rwHelper = rwA, rwB
+
+	e := &Encoder{a: a, b: b}
+
+	// step sets the values of A and B and signals an edge.
+	step := func(state string) {
+		rwA.set(state[:1])
+		rwB.set(state[1:])
+		e.handleEdge(a)
+	}
+
+	// A leads B, a full cycle.
+	for _, s := range []string{"10", "11", "01", "00"} {
+		step(s)
+	}
+	assert.Equal(t, int64(4), e.Position())
+
+	// B leads A, one and a half cycle.
+	for _, s := range []string{"01", "11", "10", "00", "01", "11"} {
+		step(s)
+	}
+	assert.Equal(t, int64(-2), e.Position())
+
+	// Changing direction halfway a cycle.
+	for _, s := range []string{"01", "11"} {
+		step(s)
+	}
+	assert.Equal(t, int64(-2), e.Position())
+
+	// An edge without change of state, or a missed edge, is ignored.
+	step("11")
+	step("00")
+	assert.Equal(t, int64(-2), e.Position())
+
+	e.Reset()
+	assert.Equal(t, int64(0), e.Position())
+	step("10")
+	assert.Equal(t, int64(1), e.Position())
+}
@@ -1,18 +1,37 @@
-// +build linux
-
 // Package gpio contains an interface and implementation for controlling GPIO
 // pins via the sysfs interface.
 //
 // This packages does not contain any vendor specific implementations of GPIO
 // pins, however the Pin struct in this package can be embedded in another
 // struct which implements vendor specific functionality.
+//
+// Only the Pin and the GPIO interface build on every platform. The Watcher
+// returned by NewWatcher and the helpers like Button and LED require Linux.
+// Use the package gpio/mock to run code that uses the GPIO interface without
+// hardware.
 package gpio
 
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/advancedclimatesystems/io/bus"
+)
+
+var (
+	// ErrBusFailure matches the errors returned when reading or writing
+	// the sysfs files of a pin failed. It is the same error as
+	// bus.ErrFailure.
+	ErrBusFailure = bus.ErrFailure
+
+	// ErrInvalidPin matches the errors returned for pins that don't exist
+	// or can't be used, like an unknown name of a pin on a board.
+	ErrInvalidPin = errors.New("invalid pin")
 )
 
 // basePath is where the GPIO pins can be found.
@@ -45,6 +64,17 @@ const (
 	OutDirection Direction = "out"
 )
 
+// Watcher watches files for events and executes a callback when an event occurs.
+type Watcher interface {
+	Watch() error
+	StopWatch()
+	AddEvent(fpnt int, callback func()) error
+	// RemoveEvent stops watching the file and removes its callback.
+	RemoveEvent(fpnt int) error
+	AddFile(file *os.File)
+	Close() error
+}
+
 // GPIO is an interface for GPIO pins.
 type GPIO interface {
 	Value() (int, error)
@@ -73,6 +103,17 @@ type Pin struct {
 	pinBase      string
 	rwHelper     rwHelper
 	w            Watcher
+
+	// m guards direction, value and edgeFile. direction and value cache the
+	// last direction read or written and the last value written, so
+	// redundant writes are skipped. They are empty when unknown.
+	m         sync.Mutex
+	direction Direction
+	value     byte
+
+	// edgeFile is the value file watched for the edge set by SetEdge, nil
+	// when no event has been added to the Watcher.
+	edgeFile *os.File
 }
 
 // NewPin creates an instance of Pin.
@@ -89,80 +130,218 @@ func NewPin(kernelID int, pinBase string, w Watcher) *Pin {
 	}
 }
 
-// Direction returns the curent direction of the pin.
+// AdoptedState is the configuration of a pin that has been found by Adopt.
+type AdoptedState struct {
+	Direction Direction
+	Edge      Edge
+	ActiveLow bool
+}
+
+// Adopt creates an instance of Pin for a pin that has been exported already,
+// for example by another process or by a previous run of this process. Unlike
+// Export, Adopt doesn't modify the pin. It returns the current direction, edge
+// and active low of the pin, so the caller can decide whether to reconfigure
+// it. An error is returned if the pin hasn't been exported.
+func Adopt(kernelID int, pinBase string, w Watcher) (*Pin, AdoptedState, error) {
+	p := NewPin(kernelID, pinBase, w)
+	s, err := p.adopt()
+	if err != nil {
+		return nil, AdoptedState{}, err
+	}
+	return p, s, nil
+}
+
+// adopt checks if the pin has been exported and reads its configuration.
+func (p *Pin) adopt() (AdoptedState, error) {
+	var s AdoptedState
+
+	exported, err := p.IsExported()
+	if err != nil {
+		return s, err
+	}
+	if !exported {
+		return s, fmt.Errorf("pin %d has not been exported", p.KernelID)
+	}
+
+	if s.Direction, err = p.Direction(); err != nil {
+		return s, err
+	}
+	if s.Edge, err = p.Edge(); err != nil {
+		return s, err
+	}
+	if s.ActiveLow, err = p.ActiveLow(); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// IsExported returns true if the pin has been exported, by this process or by
+// another one. Unlike Export it doesn't modify the pin, so it can be used to
+// find out if the pin might have a stale configuration, left behind by another
+// process.
+func (p *Pin) IsExported() (bool, error) {
+	exported, err := p.rwHelper.exists(p.pinBase)
+	if err != nil {
+		return false, bus.Error{Err: err}
+	}
+	return exported, nil
+}
+
+// Direction returns the curent direction of the pin. The direction is always
+// read from the pin.
 func (p *Pin) Direction() (Direction, error) {
-	b := make([]byte, 3)
-	n, err := p.read(b, "direction")
+	d, err := p.readDirection()
+
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.direction = ""
+	if err == nil {
+		p.direction = d
+	}
+	return d, err
+}
+
+// readDirection reads the direction of the pin.
+func (p *Pin) readDirection() (Direction, error) {
+	s, err := p.readString("direction")
 	if err != nil {
 		return OutDirection, err
 	}
-	if n == 0 {
-		return OutDirection, errors.New("not enough bytes to read")
-	}
-	if string(b[:n]) == "out" {
+
+	switch s {
+	case "out":
 		return OutDirection, nil
-	}
-	if string(b[:n-1]) == "in" {
+	case "in":
 		return InDirection, nil
+	default:
+		return OutDirection, fmt.Errorf("not a known direction: '%v'", s)
 	}
-	return OutDirection, fmt.Errorf("not a known direction: '%v'", string(b[:n]))
 }
 
-// SetDirection configures the pin as an input or output.
+// SetDirection configures the pin as an input or output. Nothing is written
+// when the pin already has direction d according to the last direction read
+// or written. Use ForceDirection if the direction might have been changed by
+// another process.
 func (p *Pin) SetDirection(d Direction) error {
-	data := []byte(d)
-	return p.write(data, "direction")
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.direction == d {
+		return nil
+	}
+	return p.setDirection(d)
+}
+
+// ForceDirection is like SetDirection, but it always writes the direction.
+func (p *Pin) ForceDirection(d Direction) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.setDirection(d)
+}
+
+// setDirection writes the direction. The caller must hold p.m.
+func (p *Pin) setDirection(d Direction) error {
+	// Changing the direction to out also changes the value.
+	p.direction, p.value = "", 0
+	if err := p.write([]byte(d), "direction"); err != nil {
+		return err
+	}
+	p.direction = d
+	return nil
+}
+
+// SetOutputValue configures the pin as an output with an initial value of v,
+// which must be 0 or 1. Unlike SetDirection followed by SetLow or SetHigh, the
+// pin never drives the wrong level in between. The value is the physical
+// level of the pin, it isn't inverted by SetActiveLow.
+func (p *Pin) SetOutputValue(v int) error {
+	var data []byte
+	switch v {
+	case 0:
+		data = []byte("low")
+	case 1:
+		data = []byte("high")
+	default:
+		return fmt.Errorf("value %d is invalid, use 0 or 1", v)
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	// The cached value is the logical value, which isn't known when the
+	// pin is active low.
+	p.direction, p.value = "", 0
+	if err := p.write(data, "direction"); err != nil {
+		return err
+	}
+	p.direction = OutDirection
+	return nil
 }
 
 // Value returns the value of the pin. The pin must be in the 'in' direction.
 func (p *Pin) Value() (int, error) {
-	b := make([]byte, 1)
-	n, err := p.read(b, "value")
+	s, err := p.readString("value")
 	if err != nil {
 		return 0, err
 	}
-	if n != 1 {
-		return 0, fmt.Errorf("expected 1 byte, got %v", n)
-	}
-	if string(b[:n]) == "1" {
+
+	switch s {
+	case "1":
 		return 1, nil
-	}
-	if string(b[:n]) == "0" {
+	case "0":
 		return 0, nil
+	default:
+		return 0, fmt.Errorf("not a known value: '%v'", s)
 	}
-	return 0, fmt.Errorf("not a known value: '%v'", string(b[:n]))
 }
 
-// SetLow writes a 0 to the Pin
+// SetLow writes a 0 to the Pin. Nothing is written if the last value written
+// was a 0 already.
 func (p *Pin) SetLow() error {
-	data := []byte("0")
-	return p.write(data, "value")
+	return p.setValue('0')
 }
 
-// SetHigh writes a 1 to the Pin. It also sets the pins direction to output.
+// SetHigh writes a 1 to the Pin. Nothing is written if the last value written
+// was a 1 already.
 func (p *Pin) SetHigh() error {
-	data := []byte("1")
-	return p.write(data, "value")
+	return p.setValue('1')
+}
+
+// setValue writes v to the value file of the pin, unless it was the last value
+// written.
+func (p *Pin) setValue(v byte) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.value == v {
+		return nil
+	}
+
+	p.value = 0
+	if err := p.write([]byte{v}, "value"); err != nil {
+		return err
+	}
+	p.value = v
+	return nil
 }
 
 // ActiveLow returns true if the the pin is inverted, i.e. it is true when
 // the value is low
 func (p *Pin) ActiveLow() (bool, error) {
-	b := make([]byte, 1)
-	n, err := p.read(b, "active_low")
+	s, err := p.readString("active_low")
 	if err != nil {
 		return false, err
 	}
-	if n != 1 {
-		return false, fmt.Errorf("expected 1 byte, got %v", n)
-	}
-	if string(b[:n]) == "1" {
+
+	switch s {
+	case "1":
 		return true, nil
-	}
-	if string(b[:n]) == "0" {
+	case "0":
 		return false, nil
+	default:
+		return false, fmt.Errorf("not a known value: '%v'", s)
 	}
-	return false, fmt.Errorf("not a known value: '%v'", string(b[:n]))
 }
 
 // SetActiveLow inverts the pins value, i.e. it is true when
@@ -175,21 +354,22 @@ func (p *Pin) SetActiveLow(invert bool) error {
 		data = []byte("1")
 	}
 
+	// Inverting the pin inverts its value as well.
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.value = 0
+
 	return p.write(data, "active_low")
 }
 
 // Edge returns the current edge of the pin.
 func (p *Pin) Edge() (Edge, error) {
-	b := make([]byte, 8)
-	n, err := p.read(b, "edge")
+	s, err := p.readString("edge")
 	if err != nil {
 		return NoneEdge, err
 	}
-	if n == 0 {
-		return NoneEdge, errors.New("not enough bytes to read")
-	}
 
-	switch string(b[:n-1]) {
+	switch s {
 	case "rising":
 		return RisingEdge, nil
 	case "falling":
@@ -199,31 +379,108 @@ func (p *Pin) Edge() (Edge, error) {
 	case "none":
 		return NoneEdge, nil
 	default:
-		return NoneEdge, fmt.Errorf("not a known value: '%v'", string(b[:n]))
+		return NoneEdge, fmt.Errorf("not a known value: '%v'", s)
 	}
 }
 
 // SetEdge sets an edge and sets up event handing for given edge. An edge can
-// only be set on a pin with the 'in' direction.
+// only be set on a pin with the 'in' direction. The event of a previous call
+// is removed from the Watcher, so NoneEdge disables the event handling and f
+// may be nil.
 func (p *Pin) SetEdge(e Edge, f EdgeEvent) error {
-	b := []byte(e)
-	valF, err := os.OpenFile(fmt.Sprintf("%v/%v/value", basePath, p.pinBase), os.O_RDWR, 0777)
+	if e == NoneEdge {
+		return p.disableEdge(nil)
+	}
+
+	_, err := p.setEdge(e, f)
+	return err
+}
+
+// setEdge sets the edge e and adds an event calling f to the Watcher. It
+// returns the value file of the event, which identifies the event until it's
+// replaced by another call to SetEdge.
+func (p *Pin) setEdge(e Edge, f EdgeEvent) (*os.File, error) {
+	valF, err := p.rwHelper.openFromBase(fmt.Sprintf("%v/value", p.pinBase))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Wrap the callback function, so that the pin can be used as a parameter.
 	callback := func() {
 		f(p)
 	}
-	p.w.AddFile(valF)
 	if err = p.w.AddEvent(int(valF.Fd()), callback); err != nil {
+		valF.Close()
+		return nil, err
+	}
+	p.w.AddFile(valF)
+
+	p.m.Lock()
+	old := p.edgeFile
+	p.edgeFile = valF
+	err = p.write([]byte(e), "edge")
+	p.m.Unlock()
+
+	if old != nil {
+		if cErr := p.closeEvent(old); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return valF, err
+}
+
+// disableEdge sets the edge to none, removes the event added by SetEdge from
+// the Watcher and closes the value file of the event. When owner isn't nil,
+// the edge is only disabled while owner is the value file of the event, so an
+// event added by a later call to SetEdge is left alone.
+func (p *Pin) disableEdge(owner *os.File) error {
+	p.m.Lock()
+	if owner != nil && p.edgeFile != owner {
+		p.m.Unlock()
+		return nil
+	}
+	if err := p.write([]byte(NoneEdge), "edge"); err != nil {
+		p.m.Unlock()
 		return err
 	}
-	return p.write(b, "edge")
+	f := p.edgeFile
+	p.edgeFile = nil
+	p.m.Unlock()
+
+	// The Watcher might take locks of its own, like the Watcher of the
+	// board package, so it's called without holding p.m.
+	if f == nil {
+		return nil
+	}
+	return p.closeEvent(f)
+}
+
+// removeEvent removes the event added by SetEdge from the Watcher and closes
+// its value file, without changing the edge.
+func (p *Pin) removeEvent() error {
+	p.m.Lock()
+	f := p.edgeFile
+	p.edgeFile = nil
+	p.m.Unlock()
+
+	if f == nil {
+		return nil
+	}
+	return p.closeEvent(f)
+}
+
+// closeEvent removes the event of the value file f from the Watcher and closes
+// f. f is closed even if the event can't be removed, closing it removes it
+// from the epoll set of the Watcher as well.
+func (p *Pin) closeEvent(f *os.File) error {
+	err := p.w.RemoveEvent(int(f.Fd()))
+	f.Close()
+	return err
 }
 
 // Export exports the pin, if it wasn't exported already.
 func (p *Pin) Export() error {
+	p.resetCache()
+
 	err := p.rwHelper.writeFromBase(p.kernelIDByte, "export")
 	// The 'device or resource busy' error indicates the pin has already been
 	// exported. Checking for specific error is a bit weird in Go. Maybe proper
@@ -234,38 +491,61 @@ func (p *Pin) Export() error {
 	return err
 }
 
-// Unexport unexports the pin.
+// Unexport unexports the pin. The event added by SetEdge is removed from the
+// Watcher first, so the Watcher doesn't watch a file that's about to vanish.
+// The pin is unexported even if the event can't be removed.
 func (p *Pin) Unexport() error {
-	return p.rwHelper.writeFromBase(p.kernelIDByte, "unexport")
+	rErr := p.removeEvent()
+	p.resetCache()
+
+	if err := p.rwHelper.writeFromBase(p.kernelIDByte, "unexport"); err != nil {
+		return err
+	}
+	return rErr
+}
+
+// resetCache forgets the cached direction and value of the pin.
+func (p *Pin) resetCache() {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.direction, p.value = "", 0
 }
 
-func (p *Pin) read(b []byte, file string) (int, error) {
-	return p.rwHelper.readFromBase(b, fmt.Sprintf("%v/%v", p.pinBase, file))
+// readString reads a sysfs file of the pin and returns its content without the
+// trailing newline. Errors are wrapped in a bus.Error.
+func (p *Pin) readString(file string) (string, error) {
+	b, err := p.rwHelper.readAllFromBase(fmt.Sprintf("%v/%v", p.pinBase, file))
+	if err != nil {
+		return "", bus.Error{Err: err}
+	}
+	return strings.TrimSpace(string(b)), nil
 }
 
+// write writes a sysfs file of the pin. Errors are wrapped in a bus.Error.
 func (p *Pin) write(b []byte, file string) error {
-	return p.rwHelper.writeFromBase(b, fmt.Sprintf("%v/%v", p.pinBase, file))
+	if err := p.rwHelper.writeFromBase(b, fmt.Sprintf("%v/%v", p.pinBase, file)); err != nil {
+		return bus.Error{Err: err}
+	}
+	return nil
 }
 
 // rwHelper is a seperate interface for interacting with files. This makes it
 // possible to create mocks for reading and writing files, which is needed to
 // write proper tests.
 type rwHelper interface {
-	readFromBase(b []byte, pathFromBase string) (int, error)
+	readAllFromBase(pathFromBase string) ([]byte, error)
 	writeFromBase(b []byte, pathFromBase string) error
+	exists(pathFromBase string) (bool, error)
+	openFromBase(pathFromBase string) (*os.File, error)
 }
 
 // baseReaderWriter has methods to read/write gpio-related files.
 type baseReaderWriter struct{}
 
-// readFromBase reads data from a file into b.
-func (baseReaderWriter) readFromBase(b []byte, pathFromBase string) (int, error) {
-	f, err := os.OpenFile(fmt.Sprintf("%v/%v", basePath, pathFromBase), os.O_RDONLY, 0777)
-	if err != nil {
-		return 0, err
-	}
-	defer f.Close()
-	return f.Read(b)
+// readAllFromBase reads the whole content of a file.
+func (baseReaderWriter) readAllFromBase(pathFromBase string) ([]byte, error) {
+	return ioutil.ReadFile(fmt.Sprintf("%v/%v", basePath, pathFromBase))
 }
 
 // readFromBase writeFromBase writes data to a file.
@@ -279,3 +559,17 @@ func (baseReaderWriter) writeFromBase(b []byte, pathFromBase string) error {
 	_, err = f.Write(b)
 	return err
 }
+
+// openFromBase opens a file for reading and writing.
+func (baseReaderWriter) openFromBase(pathFromBase string) (*os.File, error) {
+	return os.OpenFile(fmt.Sprintf("%v/%v", basePath, pathFromBase), os.O_RDWR, 0777)
+}
+
+// exists returns true if the file or folder exists.
+func (baseReaderWriter) exists(pathFromBase string) (bool, error) {
+	_, err := os.Stat(fmt.Sprintf("%v/%v", basePath, pathFromBase))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
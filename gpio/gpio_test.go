@@ -1,9 +1,15 @@
+// +build linux
+
 package gpio
 
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,6 +18,16 @@ type testValues struct {
 	readVal  []byte
 	mockErr  error
 	prevPath string
+
+	// files contains the content of files by path. If set, it is used
+	// instead of readVal.
+	files map[string]string
+	// missing is true if the folder of the pin doesn't exist.
+	missing bool
+	// writes counts the writes per path.
+	writes map[string]int
+	// opened are the files opened by openFromBase.
+	opened []*os.File
 }
 
 type mockReaderWriter struct {
@@ -21,33 +37,62 @@ type mockReaderWriter struct {
 	v *testValues
 }
 
-// readFromBase reads data from a file into b.
-func (m mockReaderWriter) readFromBase(b []byte, pathFromBase string) (int, error) {
+// readAllFromBase reads the whole content of a file.
+func (m mockReaderWriter) readAllFromBase(pathFromBase string) ([]byte, error) {
 	if m.v.mockErr != nil {
-		return 0, m.v.mockErr
+		return nil, m.v.mockErr
 	}
 
-	n := 0
-	for i, v := range m.v.readVal {
-		if i < len(b) {
-			b[i] = v
-			n++
-		}
-	}
 	m.v.prevPath = pathFromBase
-	return n, nil
+	if m.v.files != nil {
+		return []byte(m.v.files[pathFromBase]), nil
+	}
+	return m.v.readVal, nil
 }
 
 // readFromBase writeFromBase writes data to a file.
 func (m mockReaderWriter) writeFromBase(b []byte, pathFromBase string) error {
 	m.v.prevPath = pathFromBase
 	m.v.readVal = b
+	if m.v.writes == nil {
+		m.v.writes = make(map[string]int)
+	}
+	m.v.writes[pathFromBase]++
 	if m.v.mockErr != nil {
 		return m.v.mockErr
 	}
 	return nil
 }
 
+func (m mockReaderWriter) exists(pathFromBase string) (bool, error) {
+	m.v.prevPath = pathFromBase
+	return !m.v.missing, m.v.mockErr
+}
+
+// openFromBase opens a temporary file instead of the file at the path.
+func (m mockReaderWriter) openFromBase(pathFromBase string) (*os.File, error) {
+	if m.v.mockErr != nil {
+		return nil, m.v.mockErr
+	}
+
+	m.v.prevPath = pathFromBase
+	f, err := openTemp()
+	if err != nil {
+		return nil, err
+	}
+	m.v.opened = append(m.v.opened, f)
+	return f, nil
+}
+
+// openTemp opens a temporary file, which is removed when it's closed.
+func openTemp() (*os.File, error) {
+	f, err := ioutil.TempFile("", "value")
+	if err != nil {
+		return nil, err
+	}
+	return f, os.Remove(f.Name())
+}
+
 func TestPinImplements(t *testing.T) {
 	assert.Implements(t, (*GPIO)(nil), new(Pin))
 }
@@ -67,10 +112,13 @@ func TestDirection(t *testing.T) {
 		expected Direction
 		err      error
 	}{
-		{"out", OutDirection, nil},
+		{"out\n", OutDirection, nil},
 		{"in\n", InDirection, nil},
-		{"not-a-valid-value", OutDirection, errors.New("not a known direction: 'not'")},
-		{"", OutDirection, errors.New("not enough bytes to read")},
+		{"out", OutDirection, nil},
+		{"in", InDirection, nil},
+		{"not-a-valid-value", OutDirection, errors.New("not a known direction: 'not-a-valid-value'")},
+		{"inout\n", OutDirection, errors.New("not a known direction: 'inout'")},
+		{"", OutDirection, errors.New("not a known direction: ''")},
 	}
 	for _, test := range tests {
 		mrw := mockReaderWriter{&testValues{readVal: []byte(test.val)}}
@@ -97,6 +145,110 @@ func TestSetDirection(t *testing.T) {
 	assert.Equal(t, "gpio1/direction", mrw.v.prevPath)
 }
 
+func TestSetDirectionSkipsRedundantWrites(t *testing.T) {
+	p := NewPin(1, "gpio1", new(watch))
+	v := &testValues{}
+	p.rwHelper = mockReaderWriter{v}
+
+	assert.Nil(t, p.SetDirection(OutDirection))
+	assert.Nil(t, p.SetDirection(OutDirection))
+	assert.Equal(t, 1, v.writes["gpio1/direction"])
+
+	// A forced direction is always written.
+	assert.Nil(t, p.ForceDirection(OutDirection))
+	assert.Equal(t, 2, v.writes["gpio1/direction"])
+
+	assert.Nil(t, p.SetDirection(InDirection))
+	assert.Equal(t, 3, v.writes["gpio1/direction"])
+
+	// The direction read from the pin is cached too.
+	v.readVal = []byte("out")
+	dir, err := p.Direction()
+	assert.Nil(t, err)
+	assert.Equal(t, OutDirection, dir)
+	assert.Nil(t, p.SetDirection(OutDirection))
+	assert.Equal(t, 3, v.writes["gpio1/direction"])
+
+	// Exporting and unexporting the pin resets the cache.
+	assert.Nil(t, p.Unexport())
+	assert.Nil(t, p.SetDirection(OutDirection))
+	assert.Equal(t, 4, v.writes["gpio1/direction"])
+	assert.Nil(t, p.Export())
+	assert.Nil(t, p.SetDirection(OutDirection))
+	assert.Equal(t, 5, v.writes["gpio1/direction"])
+
+	// A failed write resets the cache.
+	v.mockErr = errors.New("error")
+	assert.NotNil(t, p.SetDirection(InDirection))
+	v.mockErr = nil
+	assert.Nil(t, p.SetDirection(InDirection))
+	assert.Equal(t, 7, v.writes["gpio1/direction"])
+}
+
+func TestSetOutputValue(t *testing.T) {
+	p := NewPin(1, "gpio1", new(watch))
+	v := &testValues{}
+	p.rwHelper = mockReaderWriter{v}
+
+	assert.Nil(t, p.SetOutputValue(1))
+	assert.Equal(t, "gpio1/direction", v.prevPath)
+	assert.Equal(t, []byte("high"), v.readVal)
+
+	assert.Nil(t, p.SetOutputValue(0))
+	assert.Equal(t, []byte("low"), v.readVal)
+	assert.Equal(t, 2, v.writes["gpio1/direction"])
+
+	// The direction is cached, so it isn't written again.
+	assert.Nil(t, p.SetDirection(OutDirection))
+	assert.Equal(t, 2, v.writes["gpio1/direction"])
+
+	assert.EqualError(t, p.SetOutputValue(2), "value 2 is invalid, use 0 or 1")
+	assert.Equal(t, 2, v.writes["gpio1/direction"])
+
+	v.mockErr = errors.New("error")
+	assert.NotNil(t, p.SetOutputValue(1))
+	v.mockErr = nil
+	assert.Nil(t, p.SetDirection(OutDirection))
+	assert.Equal(t, 4, v.writes["gpio1/direction"])
+}
+
+func TestSetValueSkipsRedundantWrites(t *testing.T) {
+	p := NewPin(1, "gpio1", new(watch))
+	v := &testValues{}
+	p.rwHelper = mockReaderWriter{v}
+
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, p.SetHigh())
+	}
+	assert.Equal(t, 1, v.writes["gpio1/value"])
+
+	assert.Nil(t, p.SetLow())
+	assert.Nil(t, p.SetLow())
+	assert.Nil(t, p.SetHigh())
+	assert.Equal(t, 3, v.writes["gpio1/value"])
+	assert.Equal(t, []byte("1"), v.readVal)
+
+	// Changing the direction or inverting the pin changes the value.
+	assert.Nil(t, p.SetDirection(OutDirection))
+	assert.Nil(t, p.SetHigh())
+	assert.Equal(t, 4, v.writes["gpio1/value"])
+
+	assert.Nil(t, p.SetActiveLow(true))
+	assert.Nil(t, p.SetHigh())
+	assert.Equal(t, 5, v.writes["gpio1/value"])
+
+	assert.Nil(t, p.Export())
+	assert.Nil(t, p.SetHigh())
+	assert.Equal(t, 6, v.writes["gpio1/value"])
+
+	// A failed write resets the cache.
+	v.mockErr = errors.New("error")
+	assert.NotNil(t, p.SetLow())
+	v.mockErr = nil
+	assert.Nil(t, p.SetHigh())
+	assert.Equal(t, 8, v.writes["gpio1/value"])
+}
+
 func TestValue(t *testing.T) {
 	p := NewPin(1, "gpio1", new(watch))
 
@@ -105,10 +257,12 @@ func TestValue(t *testing.T) {
 		expected int
 		err      error
 	}{
+		{"1\n", 1, nil},
+		{"0\n", 0, nil},
 		{"1", 1, nil},
-		{"0", 0, nil},
-		{"not-a-valid-value", 0, errors.New("not a known value: 'n'")},
-		{"", 0, errors.New("expected 1 byte, got 0")},
+		{"not-a-valid-value", 0, errors.New("not a known value: 'not-a-valid-value'")},
+		{"10\n", 0, errors.New("not a known value: '10'")},
+		{"", 0, errors.New("not a known value: ''")},
 	}
 	for _, test := range tests {
 		mrw := mockReaderWriter{&testValues{readVal: []byte(test.val)}}
@@ -159,10 +313,11 @@ func TestActiveLow(t *testing.T) {
 		expected bool
 		err      error
 	}{
-		{"1", true, nil},
+		{"1\n", true, nil},
+		{"0\n", false, nil},
 		{"0", false, nil},
-		{"not-a-valid-value", false, errors.New("not a known value: 'n'")},
-		{"", false, errors.New("expected 1 byte, got 0")},
+		{"not-a-valid-value", false, errors.New("not a known value: 'not-a-valid-value'")},
+		{"", false, errors.New("not a known value: ''")},
 	}
 	for _, test := range tests {
 		mrw := mockReaderWriter{&testValues{readVal: []byte(test.val)}}
@@ -207,8 +362,9 @@ func TestEdge(t *testing.T) {
 		{"falling\n", FallingEdge, nil},
 		{"none\n", NoneEdge, nil},
 		{"both\n", BothEdge, nil},
-		{"not-a-valid-value", NoneEdge, errors.New("not a known value: 'not-a-va'")},
-		{"", NoneEdge, errors.New("not enough bytes to read")},
+		{"rising", RisingEdge, nil},
+		{"not-a-valid-value", NoneEdge, errors.New("not a known value: 'not-a-valid-value'")},
+		{"", NoneEdge, errors.New("not a known value: ''")},
 	}
 	for _, test := range tests {
 		mrw := mockReaderWriter{&testValues{readVal: []byte(test.val)}}
@@ -226,7 +382,137 @@ func TestEdge(t *testing.T) {
 }
 
 func TestSetEdge(t *testing.T) {
-	// TODO: Find a way to mock opening files
+	w, _ := newWatch(&mockSys{})
+	p := NewPin(1, "gpio1", w)
+	mrw := mockReaderWriter{&testValues{}}
+	p.rwHelper = mrw
+
+	called := 0
+	assert.Nil(t, p.SetEdge(RisingEdge, func(*Pin) { called++ }))
+	assert.Equal(t, "gpio1/edge", mrw.v.prevPath)
+	assert.Equal(t, []byte("rising"), mrw.v.readVal)
+
+	assert.Len(t, mrw.v.opened, 1)
+	fd := int(mrw.v.opened[0].Fd())
+	assert.Contains(t, w.callbacks, fd)
+	assert.Equal(t, []*os.File{mrw.v.opened[0]}, w.files)
+
+	// The first event is the event fired when the file is added.
+	w.handleEvent(fd)
+	w.handleEvent(fd)
+	assert.Equal(t, 1, called)
+
+	// Setting another edge replaces the event.
+	assert.Nil(t, p.SetEdge(FallingEdge, func(*Pin) {}))
+	assert.Len(t, mrw.v.opened, 2)
+	assert.Len(t, w.callbacks, 1)
+	assert.Contains(t, w.callbacks, int(mrw.v.opened[1].Fd()))
+	assert.Equal(t, []*os.File{mrw.v.opened[1]}, w.files)
+	assert.NotNil(t, mrw.v.opened[0].Close(), "file of the first event is still open")
+
+	// The file is closed when the event can't be added.
+	w.sysH = &mockSys{ectlbErr: errors.New("error")}
+	assert.EqualError(t, p.SetEdge(BothEdge, func(*Pin) {}), "error")
+	assert.Len(t, mrw.v.opened, 3)
+	assert.NotNil(t, mrw.v.opened[2].Close(), "file of the failed event is still open")
+	assert.Equal(t, []*os.File{mrw.v.opened[1]}, w.files)
+	assert.Equal(t, []byte("falling"), mrw.v.readVal)
+
+	mrw.v.mockErr = errors.New("error")
+	assert.EqualError(t, p.SetEdge(BothEdge, func(*Pin) {}), "error")
+}
+
+func TestSetEdgeNone(t *testing.T) {
+	w, _ := newWatch(&mockSys{})
+	p := NewPin(1, "gpio1", w)
+	mrw := mockReaderWriter{&testValues{}}
+	p.rwHelper = mrw
+
+	// Without an event there is nothing to remove.
+	assert.Nil(t, p.SetEdge(NoneEdge, nil))
+	assert.Equal(t, "gpio1/edge", mrw.v.prevPath)
+	assert.Equal(t, []byte("none"), mrw.v.readVal)
+
+	assert.Nil(t, p.SetEdge(RisingEdge, func(*Pin) {}))
+	fd := int(mrw.v.opened[0].Fd())
+
+	assert.Nil(t, p.SetEdge(NoneEdge, nil))
+	assert.NotContains(t, w.callbacks, fd)
+	assert.Empty(t, w.files)
+	assert.Equal(t, []byte("none"), mrw.v.readVal)
+	assert.NotNil(t, mrw.v.opened[0].Close(), "file of the event is still open")
+
+	// The file is closed, even if the event can't be removed.
+	assert.Nil(t, p.SetEdge(RisingEdge, func(*Pin) {}))
+	w.sysH = &mockSys{ectlbErr: errors.New("error")}
+	assert.EqualError(t, p.SetEdge(NoneEdge, nil), "error")
+	assert.NotNil(t, mrw.v.opened[1].Close(), "file of the event is still open")
+
+	// Nothing is left to remove.
+	assert.Nil(t, p.SetEdge(NoneEdge, nil))
+}
+
+// lockingWatcher is a Watcher whose RemoveEvent takes l, like the Watcher of
+// the board package takes the lock of that package. RemoveEvent closes
+// removing and waits until locked is closed before it takes l.
+type lockingWatcher struct {
+	*watch
+	l        *sync.Mutex
+	removing chan struct{}
+	locked   chan struct{}
+}
+
+func (w *lockingWatcher) RemoveEvent(fpntr int) error {
+	close(w.removing)
+	<-w.locked
+
+	w.l.Lock()
+	defer w.l.Unlock()
+	return w.watch.RemoveEvent(fpntr)
+}
+
+// TestSetEdgeNoneConcurrentShutdown tests if disabling the edge, for example
+// from an edge callback, doesn't deadlock with a Shutdown of the board package
+// that unexports the pin at the same time.
+func TestSetEdgeNoneConcurrentShutdown(t *testing.T) {
+	watch, _ := newWatch(&mockSys{})
+	w := &lockingWatcher{watch: watch, l: new(sync.Mutex)}
+	p := NewPin(1, "gpio1", w)
+	p.rwHelper = &valueReaderWriter{value: "0"}
+	assert.Nil(t, p.SetEdge(RisingEdge, func(*Pin) {}))
+
+	w.removing = make(chan struct{})
+	w.locked = make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		assert.Nil(t, p.SetEdge(NoneEdge, nil))
+	}()
+	go func() {
+		defer wg.Done()
+
+		// The lock of the Watcher is taken by code that unexports
+		// the pin while SetEdge removes the event.
+		<-w.removing
+		w.l.Lock()
+		defer w.l.Unlock()
+		close(w.locked)
+		assert.Nil(t, p.Unexport())
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SetEdge and Unexport deadlocked")
+	}
 }
 
 func TestExport(t *testing.T) {
@@ -274,3 +560,79 @@ func TestUnexport(t *testing.T) {
 	}
 
 }
+
+func TestUnexportRemovesEvent(t *testing.T) {
+	w, _ := newWatch(&mockSys{})
+	p := NewPin(1, "gpio1", w)
+	mrw := mockReaderWriter{&testValues{}}
+	p.rwHelper = mrw
+
+	assert.Nil(t, p.SetEdge(RisingEdge, func(*Pin) {}))
+	fd := int(mrw.v.opened[0].Fd())
+
+	assert.Nil(t, p.Unexport())
+	assert.Equal(t, "unexport", mrw.v.prevPath)
+	assert.NotContains(t, w.callbacks, fd)
+	assert.Empty(t, w.files)
+	assert.NotNil(t, mrw.v.opened[0].Close(), "file of the event is still open")
+
+	// The pin is unexported, even if the event can't be removed.
+	assert.Nil(t, p.SetEdge(RisingEdge, func(*Pin) {}))
+	w.sysH = &mockSys{ectlbErr: errors.New("error")}
+	mrw.v.prevPath = ""
+	assert.EqualError(t, p.Unexport(), "error")
+	assert.Equal(t, "unexport", mrw.v.prevPath)
+}
+
+func TestAdopt(t *testing.T) {
+	p := NewPin(1, "gpio1", new(watch))
+	p.rwHelper = mockReaderWriter{&testValues{files: map[string]string{
+		"gpio1/direction":  "in\n",
+		"gpio1/edge":       "falling\n",
+		"gpio1/active_low": "1\n",
+	}}}
+
+	s, err := p.adopt()
+	assert.Nil(t, err)
+	assert.Equal(t, AdoptedState{InDirection, FallingEdge, true}, s)
+
+	tests := []struct {
+		v   *testValues
+		err error
+	}{
+		{&testValues{missing: true}, errors.New("pin 1 has not been exported")},
+		{&testValues{mockErr: errors.New("error")}, errors.New("error")},
+		{&testValues{files: map[string]string{
+			"gpio1/direction": "in\n",
+			"gpio1/edge":      "up\n",
+		}}, errors.New("not a known value: 'up'")},
+	}
+
+	for _, test := range tests {
+		p.rwHelper = mockReaderWriter{test.v}
+		_, err := p.adopt()
+		assert.EqualError(t, err, test.err.Error())
+	}
+}
+
+func TestIsExported(t *testing.T) {
+	p := NewPin(1, "gpio1", new(watch))
+	v := &testValues{}
+	p.rwHelper = mockReaderWriter{v}
+
+	exported, err := p.IsExported()
+	assert.Nil(t, err)
+	assert.True(t, exported)
+	assert.Equal(t, "gpio1", v.prevPath)
+	assert.Equal(t, 0, len(v.writes))
+
+	v.missing = true
+	exported, err = p.IsExported()
+	assert.Nil(t, err)
+	assert.False(t, exported)
+
+	v.mockErr = errors.New("permission denied")
+	_, err = p.IsExported()
+	assert.EqualError(t, err, "permission denied")
+	assert.True(t, errors.Is(err, ErrBusFailure))
+}
@@ -0,0 +1,42 @@
+// +build linux
+
+package gpio
+
+import "fmt"
+
+// PinGroup drives several output pins with a single call, like the data lines
+// of a parallel bus to an LCD or a stepper driver.
+type PinGroup struct {
+	pins []*Pin
+}
+
+// NewPinGroup returns a PinGroup of the pins. The pins must be outputs.
+func NewPinGroup(pins ...*Pin) *PinGroup {
+	return &PinGroup{pins: pins}
+}
+
+// SetValues writes v[i] to pin i of the group. Every value must be 0 or 1,
+// nothing is written when a value is invalid.
+//
+// The sysfs interface can't write several pins at once, so the pins are
+// written one by one in the order of the group, and the pins change with a
+// small skew. A pin isn't written when its value is the last value written,
+// see SetHigh.
+func (g *PinGroup) SetValues(v []int) error {
+	if len(v) != len(g.pins) {
+		return fmt.Errorf("got %d values for a group of %d pins", len(v), len(g.pins))
+	}
+
+	for _, value := range v {
+		if value != 0 && value != 1 {
+			return fmt.Errorf("value %d is invalid, use 0 or 1", value)
+		}
+	}
+
+	for i, p := range g.pins {
+		if err := p.setValue(byte('0' + v[i])); err != nil {
+			return fmt.Errorf("failed to set value of pin %d: %w", p.KernelID, err)
+		}
+	}
+	return nil
+}
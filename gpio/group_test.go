@@ -0,0 +1,47 @@
+// +build linux
+
+package gpio
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinGroup(t *testing.T) {
+	var pins []*Pin
+	var values []*testValues
+	for i := 1; i <= 3; i++ {
+		p := NewPin(i, fmt.Sprintf("gpio%d", i), new(watch))
+		v := &testValues{}
+		p.rwHelper = mockReaderWriter{v}
+		pins = append(pins, p)
+		values = append(values, v)
+	}
+	g := NewPinGroup(pins...)
+
+	assert.Nil(t, g.SetValues([]int{1, 0, 1}))
+	for i, want := range []string{"1", "0", "1"} {
+		assert.Equal(t, []byte(want), values[i].readVal)
+		assert.Equal(t, fmt.Sprintf("gpio%d/value", i+1), values[i].prevPath)
+	}
+
+	// Only the pins that change are written.
+	assert.Nil(t, g.SetValues([]int{1, 1, 1}))
+	assert.Equal(t, []int{1, 2, 1}, []int{
+		values[0].writes["gpio1/value"],
+		values[1].writes["gpio2/value"],
+		values[2].writes["gpio3/value"],
+	})
+
+	assert.EqualError(t, g.SetValues([]int{1, 0}), "got 2 values for a group of 3 pins")
+	assert.EqualError(t, g.SetValues([]int{0, 2, 0}), "value 2 is invalid, use 0 or 1")
+	assert.Equal(t, []byte("1"), values[0].readVal)
+
+	values[1].mockErr = errors.New("error")
+	assert.EqualError(t, g.SetValues([]int{0, 0, 0}), "failed to set value of pin 2: error")
+	assert.Equal(t, []byte("0"), values[0].readVal)
+	assert.Equal(t, []byte("1"), values[2].readVal)
+}
@@ -0,0 +1,111 @@
+// +build linux
+
+package gpio
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LED is a LED connected to an output pin. The LED is on when the value of the
+// pin is 1. Use SetActiveLow on the pin if the LED is on when the pin is low.
+type LED struct {
+	pin *Pin
+
+	m sync.Mutex
+	// stop is closed to stop blinking. It is nil when the LED isn't
+	// blinking.
+	stop chan struct{}
+	// done is closed when the blinking goroutine has returned.
+	done chan struct{}
+}
+
+// NewLED configures p as an output and returns a LED for it.
+func NewLED(p *Pin) (*LED, error) {
+	if err := p.SetDirection(OutDirection); err != nil {
+		return nil, err
+	}
+	return &LED{pin: p}, nil
+}
+
+// On stops blinking and turns the LED on.
+func (l *LED) On() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.stopBlink()
+	return l.pin.SetHigh()
+}
+
+// Off stops blinking and turns the LED off.
+func (l *LED) Off() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.stopBlink()
+	return l.pin.SetLow()
+}
+
+// Blink turns the LED on and off until On, Off or Stop is called. The LED is
+// on for half of the period and off for the other half. Calling Blink while
+// the LED is blinking changes the period. Errors that occur while toggling
+// the LED are ignored, only the error of turning the LED on initially is
+// returned. The period must be at least 2ns, else an error is returned and the
+// LED is left as it is.
+func (l *LED) Blink(period time.Duration) error {
+	if period < 2 {
+		return fmt.Errorf("period of %v is too short, it must be at least 2ns", period)
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.stopBlink()
+	if err := l.pin.SetHigh(); err != nil {
+		return err
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	l.stop, l.done = stop, done
+
+	go func() {
+		defer close(done)
+
+		t := time.NewTicker(period / 2)
+		defer t.Stop()
+
+		on := true
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				on = !on
+				if on {
+					l.pin.SetHigh()
+				} else {
+					l.pin.SetLow()
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops blinking and turns the LED off. It is the same as Off.
+func (l *LED) Stop() error {
+	return l.Off()
+}
+
+// stopBlink stops the blinking goroutine and waits until it has returned, so
+// it can't change the value of the pin anymore. The caller must hold m.
+func (l *LED) stopBlink() {
+	if l.stop == nil {
+		return
+	}
+
+	close(l.stop)
+	<-l.done
+	l.stop, l.done = nil, nil
+}
@@ -0,0 +1,85 @@
+// +build linux
+
+package gpio
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLED(t *testing.T) {
+	rw := &valueReaderWriter{value: "0"}
+	p := NewPin(1, "gpio1", new(watch))
+	p.rwHelper = rw
+
+	l, err := NewLED(p)
+	assert.Nil(t, err)
+
+	assert.Nil(t, l.On())
+	v, _ := rw.get()
+	assert.Equal(t, "1", v)
+
+	assert.Nil(t, l.Off())
+	v, _ = rw.get()
+	assert.Equal(t, "0", v)
+}
+
+func TestLEDBlink(t *testing.T) {
+	rw := &valueReaderWriter{value: "0"}
+	p := NewPin(1, "gpio1", new(watch))
+	p.rwHelper = rw
+
+	l, _ := NewLED(p)
+	assert.Nil(t, l.Blink(2*time.Millisecond))
+
+	// Wait until the LED has been toggled a few times.
+	for i := 0; ; i++ {
+		if _, writes := rw.get(); len(writes) >= 4 {
+			break
+		}
+		if i == 1000 {
+			t.Fatal("LED doesn't blink")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Nil(t, l.Stop())
+	v, writes := rw.get()
+	assert.Equal(t, "0", v)
+	assert.Equal(t, []string{"1", "0", "1", "0"}, writes[:4])
+
+	// The LED stays off once it has been stopped.
+	time.Sleep(5 * time.Millisecond)
+	v, after := rw.get()
+	assert.Equal(t, "0", v)
+	assert.Equal(t, len(writes), len(after))
+
+	// Stopping a LED that isn't blinking is fine.
+	assert.Nil(t, l.Stop())
+}
+
+func TestLEDBlinkInvalidPeriod(t *testing.T) {
+	rw := &valueReaderWriter{value: "0"}
+	p := NewPin(1, "gpio1", new(watch))
+	p.rwHelper = rw
+
+	l, _ := NewLED(p)
+	assert.EqualError(t, l.Blink(0), "period of 0s is too short, it must be at least 2ns")
+	assert.EqualError(t, l.Blink(-time.Second), "period of -1s is too short, it must be at least 2ns")
+
+	// The LED is left as it is.
+	v, writes := rw.get()
+	assert.Equal(t, "0", v)
+	assert.Empty(t, writes)
+}
+
+func TestNewLEDWithFailingPin(t *testing.T) {
+	p := NewPin(1, "gpio1", new(watch))
+	p.rwHelper = mockReaderWriter{&testValues{mockErr: errors.New("error")}}
+
+	_, err := NewLED(p)
+	assert.EqualError(t, err, "error")
+}
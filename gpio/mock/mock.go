@@ -0,0 +1,270 @@
+// Package mock contains an in-memory implementation of the gpio.GPIO
+// interface. It allows to develop and test code that uses GPIO pins without
+// hardware and on platforms other than Linux, like iotest does for I2C.
+//
+//	func TestAlarm(t *testing.T) {
+//		p := mock.NewMockPin()
+//		a := NewAlarm(p)
+//
+//		// Drive the input high, this calls the callback registered
+//		// with SetEdge when it listens to rising edges.
+//		p.SetValue(1)
+//
+//		assert.True(t, a.Triggered())
+//	}
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/advancedclimatesystems/io/gpio"
+)
+
+var _ gpio.GPIO = (*MockPin)(nil)
+
+// ErrNotOutput is returned when a value is written to a pin that isn't an
+// output.
+var ErrNotOutput = errors.New("pin isn't an output")
+
+// MockPin is a GPIO pin that keeps its state in memory. Like a pin in sysfs a
+// new MockPin is an input with value 0 and no edge. MockPin can be used
+// concurrently.
+//
+// The callbacks registered with SetEdge are called with a nil *gpio.Pin,
+// because a MockPin isn't backed by a gpio.Pin.
+type MockPin struct {
+	// Err, when set, is returned by every method of the GPIO interface
+	// and nothing is changed. It simulates a failing pin. It isn't
+	// guarded, so don't change it while the pin is used concurrently.
+	Err error
+
+	m         sync.Mutex
+	value     int
+	direction gpio.Direction
+	edge      gpio.Edge
+	callback  gpio.EdgeEvent
+	activeLow bool
+	exported  bool
+	writes    []int
+}
+
+// NewMockPin returns an unexported MockPin that is an input with value 0.
+func NewMockPin() *MockPin {
+	return &MockPin{
+		direction: gpio.InDirection,
+		edge:      gpio.NoneEdge,
+	}
+}
+
+// Value returns the value of the pin: the value injected with SetValue when
+// it's an input or the value written last when it's an output.
+func (p *MockPin) Value() (int, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Err != nil {
+		return 0, p.Err
+	}
+	return p.value, nil
+}
+
+// SetHigh sets the value of the output to 1.
+func (p *MockPin) SetHigh() error {
+	return p.write(1)
+}
+
+// SetLow sets the value of the output to 0.
+func (p *MockPin) SetLow() error {
+	return p.write(0)
+}
+
+func (p *MockPin) write(v int) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Err != nil {
+		return p.Err
+	}
+	if p.direction != gpio.OutDirection {
+		return ErrNotOutput
+	}
+
+	p.value = v
+	p.writes = append(p.writes, v)
+	return nil
+}
+
+// Direction returns the direction of the pin.
+func (p *MockPin) Direction() (gpio.Direction, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Err != nil {
+		return "", p.Err
+	}
+	return p.direction, nil
+}
+
+// SetDirection sets the direction of the pin. Making it an output sets its
+// value to 0, like the kernel does.
+func (p *MockPin) SetDirection(d gpio.Direction) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Err != nil {
+		return p.Err
+	}
+
+	switch d {
+	case gpio.InDirection:
+	case gpio.OutDirection:
+		p.value = 0
+	default:
+		return fmt.Errorf("not a known direction: '%v'", d)
+	}
+
+	p.direction = d
+	return nil
+}
+
+// Edge returns the edge of the pin.
+func (p *MockPin) Edge() (gpio.Edge, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Err != nil {
+		return gpio.NoneEdge, p.Err
+	}
+	return p.edge, nil
+}
+
+// SetEdge sets the edge and the callback that is called when SetValue
+// changes the value of the input on that edge. An edge can only be set on a
+// pin with the 'in' direction.
+func (p *MockPin) SetEdge(e gpio.Edge, f gpio.EdgeEvent) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Err != nil {
+		return p.Err
+	}
+
+	switch e {
+	case gpio.RisingEdge, gpio.FallingEdge, gpio.BothEdge, gpio.NoneEdge:
+	default:
+		return fmt.Errorf("not a known value: '%v'", e)
+	}
+
+	if p.direction != gpio.InDirection && e != gpio.NoneEdge {
+		return fmt.Errorf("failed to set edge %v: pin isn't an input", e)
+	}
+
+	p.edge = e
+	p.callback = f
+	return nil
+}
+
+// ActiveLow returns whether the pin is active low.
+func (p *MockPin) ActiveLow() (bool, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Err != nil {
+		return false, p.Err
+	}
+	return p.activeLow, nil
+}
+
+// SetActiveLow sets whether the pin is active low. It's only recorded, the
+// values of a MockPin are always the logical values.
+func (p *MockPin) SetActiveLow(invert bool) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Err != nil {
+		return p.Err
+	}
+	p.activeLow = invert
+	return nil
+}
+
+// Export exports the pin.
+func (p *MockPin) Export() error {
+	return p.setExported(true)
+}
+
+// Unexport unexports the pin.
+func (p *MockPin) Unexport() error {
+	return p.setExported(false)
+}
+
+func (p *MockPin) setExported(exported bool) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.Err != nil {
+		return p.Err
+	}
+	p.exported = exported
+	return nil
+}
+
+// Exported returns whether the pin is exported.
+func (p *MockPin) Exported() bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return p.exported
+}
+
+// Writes returns the values written with SetHigh and SetLow, oldest first.
+func (p *MockPin) Writes() []int {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	return append([]int(nil), p.writes...)
+}
+
+// SetValue injects the value of the input, as if it was driven externally.
+// When the value changes on the edge set with SetEdge, the callback is called
+// before SetValue returns.
+func (p *MockPin) SetValue(v int) error {
+	if v != 0 && v != 1 {
+		return fmt.Errorf("value %d is invalid, use 0 or 1", v)
+	}
+
+	p.m.Lock()
+	old := p.value
+	p.value = v
+
+	var f gpio.EdgeEvent
+	switch {
+	case old == v:
+	case p.edge == gpio.BothEdge,
+		p.edge == gpio.RisingEdge && v == 1,
+		p.edge == gpio.FallingEdge && v == 0:
+		f = p.callback
+	}
+	p.m.Unlock()
+
+	// The callback is called without holding the lock, so it can use the
+	// pin.
+	if f != nil {
+		f(nil)
+	}
+	return nil
+}
+
+// Fire calls the callback set with SetEdge, regardless of the value and the
+// edge of the pin. It does nothing when no callback is set.
+func (p *MockPin) Fire() {
+	p.m.Lock()
+	f := p.callback
+	p.m.Unlock()
+
+	if f != nil {
+		f(nil)
+	}
+}
@@ -0,0 +1,104 @@
+package mock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockPinOutput(t *testing.T) {
+	p := NewMockPin()
+
+	assert.Equal(t, ErrNotOutput, p.SetHigh())
+
+	assert.Nil(t, p.SetDirection(gpio.OutDirection))
+	assert.Nil(t, p.SetHigh())
+	assert.Nil(t, p.SetLow())
+	assert.Nil(t, p.SetHigh())
+
+	v, err := p.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, []int{1, 0, 1}, p.Writes())
+
+	d, err := p.Direction()
+	assert.Nil(t, err)
+	assert.Equal(t, gpio.OutDirection, d)
+
+	assert.EqualError(t, p.SetDirection("inout"), "not a known direction: 'inout'")
+	assert.EqualError(t, p.SetEdge(gpio.RisingEdge, nil), "failed to set edge rising: pin isn't an input")
+}
+
+func TestMockPinEdge(t *testing.T) {
+	tests := []struct {
+		edge   gpio.Edge
+		values []int
+		calls  int
+	}{
+		{gpio.NoneEdge, []int{1, 0, 1}, 0},
+		{gpio.RisingEdge, []int{1, 1, 0, 1}, 2},
+		{gpio.FallingEdge, []int{1, 0, 0, 1, 0}, 2},
+		{gpio.BothEdge, []int{1, 0, 0, 1}, 3},
+	}
+
+	for _, test := range tests {
+		p := NewMockPin()
+
+		calls := 0
+		assert.Nil(t, p.SetEdge(test.edge, func(*gpio.Pin) {
+			// The callback must be able to use the pin.
+			_, _ = p.Value()
+			calls++
+		}))
+
+		for _, v := range test.values {
+			assert.Nil(t, p.SetValue(v))
+		}
+		assert.Equal(t, test.calls, calls, "edge %v", test.edge)
+
+		p.Fire()
+		assert.Equal(t, test.calls+1, calls)
+
+		e, err := p.Edge()
+		assert.Nil(t, err)
+		assert.Equal(t, test.edge, e)
+	}
+
+	p := NewMockPin()
+	p.Fire()
+	assert.EqualError(t, p.SetValue(2), "value 2 is invalid, use 0 or 1")
+	assert.EqualError(t, p.SetEdge("sideways", nil), "not a known value: 'sideways'")
+}
+
+func TestMockPinState(t *testing.T) {
+	p := NewMockPin()
+
+	assert.Nil(t, p.Export())
+	assert.True(t, p.Exported())
+	assert.Nil(t, p.Unexport())
+	assert.False(t, p.Exported())
+
+	assert.Nil(t, p.SetActiveLow(true))
+	low, err := p.ActiveLow()
+	assert.Nil(t, err)
+	assert.True(t, low)
+}
+
+func TestMockPinErr(t *testing.T) {
+	p := NewMockPin()
+	p.Err = errors.New("some error")
+
+	_, err := p.Value()
+	assert.Equal(t, p.Err, err)
+	assert.Equal(t, p.Err, p.SetDirection(gpio.OutDirection))
+	assert.Equal(t, p.Err, p.SetHigh())
+	assert.Equal(t, p.Err, p.SetEdge(gpio.BothEdge, nil))
+	assert.Equal(t, p.Err, p.Export())
+
+	d, err := p.Direction()
+	assert.Equal(t, p.Err, err)
+	assert.Equal(t, gpio.Direction(""), d)
+	assert.Nil(t, p.Writes())
+}
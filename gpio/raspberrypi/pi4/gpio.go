@@ -0,0 +1,237 @@
+// +build linux
+
+// Package pi4 contains GPIO drivers for the Raspberry Pi 4 Model B.
+//
+// The Raspberry Pi 4 exposes the GPIO pins of its SoC on the 40 pins header.
+// The pins are identified by their BCM number, which is also their kernel ID.
+// The constants of this package map the number of a pin on the header to its
+// BCM number, so Pin11 is GPIO 17. Pins reserved for power, ground and the ID
+// EEPROM of HATs can't be used.
+// https://www.raspberrypi.org/documentation/usage/gpio/
+package pi4
+
+import (
+	"fmt"
+
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/advancedclimatesystems/io/gpio/board"
+	"github.com/advancedclimatesystems/io/internal/errs"
+)
+
+// boardName is the name under which the mapping of the board is registered.
+const boardName = "raspberrypi-pi4"
+
+// The GPIO pins on the 40 pins header. The name of a constant is the number
+// of the pin on the header, its value is the BCM number of the pin.
+const (
+	Pin3  = 2
+	Pin5  = 3
+	Pin7  = 4
+	Pin8  = 14
+	Pin10 = 15
+	Pin11 = 17
+	Pin12 = 18
+	Pin13 = 27
+	Pin15 = 22
+	Pin16 = 23
+	Pin18 = 24
+	Pin19 = 10
+	Pin21 = 9
+	Pin22 = 25
+	Pin23 = 11
+	Pin24 = 8
+	Pin26 = 7
+	Pin29 = 5
+	Pin31 = 6
+	Pin32 = 12
+	Pin33 = 13
+	Pin35 = 19
+	Pin36 = 16
+	Pin37 = 26
+	Pin38 = 20
+	Pin40 = 21
+)
+
+func init() {
+	mapping := make(map[string]int, len(header))
+	for _, bcm := range header {
+		mapping[pinName(bcm)] = bcm
+	}
+	board.Register(boardName, mapping)
+}
+
+// NewPin creates and exports the pin with the given BCM number. Pins that
+// aren't on the 40 pins header and the pins of the ID EEPROM are rejected.
+func NewPin(bcm int) (gpio.GPIO, error) {
+	if err := validate(bcm); err != nil {
+		return nil, err
+	}
+	return board.NewPin(boardName, pinName(bcm))
+}
+
+// NewPinWithWatcher is like NewPin, but the edge events of the pin are handled
+// by w. The caller is responsible for starting, stopping and closing w.
+func NewPinWithWatcher(bcm int, w gpio.Watcher) (gpio.GPIO, error) {
+	if err := validate(bcm); err != nil {
+		return nil, err
+	}
+	return board.NewPinWithWatcher(boardName, pinName(bcm), w)
+}
+
+// PinSpec describes a pin created by NewPins.
+type PinSpec struct {
+	// BCM is the BCM number of the pin.
+	BCM int
+	// Direction is the direction of the pin. The direction isn't changed
+	// if it is empty.
+	Direction gpio.Direction
+	// Value is the initial value of an output pin. 0 sets the pin low, any
+	// other value sets it high. It is ignored for input pins.
+	Value int
+	// Edge is the edge on which Callback is called. No edge is set if it is
+	// empty.
+	Edge     gpio.Edge
+	Callback gpio.EdgeEvent
+}
+
+// NewPins creates, exports and configures the pins described by specs. The
+// pins are returned by their BCM number. If one of the pins fails, the pins
+// created so far are closed.
+func NewPins(specs []PinSpec) (map[int]gpio.GPIO, error) {
+	bs := make([]board.PinSpec, len(specs))
+	for i, spec := range specs {
+		if err := validate(spec.BCM); err != nil {
+			return nil, err
+		}
+
+		bs[i] = board.PinSpec{
+			Name:      pinName(spec.BCM),
+			Direction: spec.Direction,
+			Value:     spec.Value,
+			Edge:      spec.Edge,
+			Callback:  spec.Callback,
+		}
+	}
+
+	created, err := board.NewPins(boardName, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	pins := make(map[int]gpio.GPIO, len(created))
+	for _, spec := range specs {
+		pins[spec.BCM] = created[pinName(spec.BCM)]
+	}
+	return pins, nil
+}
+
+// HeaderPin returns the BCM number of the pin with the given number on the 40
+// pins header. It returns an error for pins that are reserved for power,
+// ground or the ID EEPROM.
+func HeaderPin(pin int) (int, error) {
+	if r, ok := reserved[pin]; ok {
+		return 0, errs.New(gpio.ErrInvalidPin, "pin %d of the header is reserved for %v", pin, r)
+	}
+
+	bcm, ok := header[pin]
+	if !ok {
+		return 0, errs.New(gpio.ErrInvalidPin, "pin %d is not on the header", pin)
+	}
+	return bcm, nil
+}
+
+// Adopt adopts the pin with the given BCM number that has been exported
+// already, for example by another process. The pin isn't modified, its current
+// configuration is returned.
+func Adopt(bcm int) (gpio.GPIO, gpio.AdoptedState, error) {
+	if err := validate(bcm); err != nil {
+		return nil, gpio.AdoptedState{}, err
+	}
+	return board.Adopt(boardName, pinName(bcm))
+}
+
+// ClosePin unexports a pin created by NewPin.
+func ClosePin(p gpio.GPIO) error {
+	return board.ClosePin(p)
+}
+
+// Shutdown unexports all pins created by NewPin and stops the watcher that
+// handles their edge events. The watcher is shared by all board packages, so
+// pins created by other board packages are unexported too.
+func Shutdown() error {
+	return board.Shutdown()
+}
+
+// UnexportAll unexports all pins created by NewPin. Pins created by other
+// board packages are unexported too.
+func UnexportAll() error {
+	return board.UnexportAll()
+}
+
+// validate returns an error if the pin with the given BCM number can't be
+// used.
+func validate(bcm int) error {
+	if bcm == 0 || bcm == 1 {
+		return errs.New(gpio.ErrInvalidPin, "GPIO %d is reserved for the ID EEPROM", bcm)
+	}
+
+	for _, b := range header {
+		if b == bcm {
+			return nil
+		}
+	}
+	return errs.New(gpio.ErrInvalidPin, "GPIO %d is not on the header", bcm)
+}
+
+func pinName(bcm int) string {
+	return fmt.Sprintf("GPIO%d", bcm)
+}
+
+// header maps the number of a pin on the 40 pins header to its BCM number.
+var header = map[int]int{
+	3:  Pin3,
+	5:  Pin5,
+	7:  Pin7,
+	8:  Pin8,
+	10: Pin10,
+	11: Pin11,
+	12: Pin12,
+	13: Pin13,
+	15: Pin15,
+	16: Pin16,
+	18: Pin18,
+	19: Pin19,
+	21: Pin21,
+	22: Pin22,
+	23: Pin23,
+	24: Pin24,
+	26: Pin26,
+	29: Pin29,
+	31: Pin31,
+	32: Pin32,
+	33: Pin33,
+	35: Pin35,
+	36: Pin36,
+	37: Pin37,
+	38: Pin38,
+	40: Pin40,
+}
+
+// reserved contains the pins of the 40 pins header that can't be used as
+// GPIO.
+var reserved = map[int]string{
+	1:  "3.3V power",
+	2:  "5V power",
+	4:  "5V power",
+	6:  "ground",
+	9:  "ground",
+	14: "ground",
+	17: "3.3V power",
+	20: "ground",
+	25: "ground",
+	27: "the ID EEPROM",
+	28: "the ID EEPROM",
+	30: "ground",
+	34: "ground",
+	39: "ground",
+}
@@ -0,0 +1,107 @@
+package pi4
+
+import (
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/advancedclimatesystems/io/gpio/board"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderPin(t *testing.T) {
+	tests := []struct {
+		pin int
+		bcm int
+		err error
+	}{
+		{3, 2, nil},
+		{11, 17, nil},
+		{40, 21, nil},
+
+		{1, 0, errors.New("pin 1 of the header is reserved for 3.3V power")},
+		{2, 0, errors.New("pin 2 of the header is reserved for 5V power")},
+		{39, 0, errors.New("pin 39 of the header is reserved for ground")},
+		{27, 0, errors.New("pin 27 of the header is reserved for the ID EEPROM")},
+		{41, 0, errors.New("pin 41 is not on the header")},
+	}
+
+	for _, test := range tests {
+		bcm, err := HeaderPin(test.pin)
+		assert.Equal(t, test.bcm, bcm)
+		if test.err == nil {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.err.Error())
+		assert.True(t, errors.Is(err, gpio.ErrInvalidPin))
+	}
+}
+
+// TestHeaderIsComplete tests if every pin of the header is either a GPIO or
+// reserved.
+func TestHeaderIsComplete(t *testing.T) {
+	for pin := 1; pin <= 40; pin++ {
+		_, isGPIO := header[pin]
+		_, isReserved := reserved[pin]
+		assert.True(t, isGPIO != isReserved, "pin %d", pin)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		bcm int
+		err error
+	}{
+		{2, nil},
+		{27, nil},
+		{0, errors.New("GPIO 0 is reserved for the ID EEPROM")},
+		{1, errors.New("GPIO 1 is reserved for the ID EEPROM")},
+		{28, errors.New("GPIO 28 is not on the header")},
+		{-1, errors.New("GPIO -1 is not on the header")},
+	}
+
+	for _, test := range tests {
+		err := validate(test.bcm)
+		if test.err == nil {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.err.Error())
+		assert.True(t, errors.Is(err, gpio.ErrInvalidPin))
+	}
+
+	_, err := NewPin(1)
+	assert.EqualError(t, err, "GPIO 1 is reserved for the ID EEPROM")
+
+	_, err = NewPins([]PinSpec{{BCM: Pin11}, {BCM: 0}})
+	assert.EqualError(t, err, "GPIO 0 is reserved for the ID EEPROM")
+}
+
+func TestGetKernelID(t *testing.T) {
+	for _, bcm := range header {
+		kid, err := board.KernelID(boardName, pinName(bcm))
+		assert.Nil(t, err)
+		assert.Equal(t, bcm, kid)
+	}
+}
+
+func ExampleNewPin() {
+	outPin, _ := NewPin(Pin11)
+	_ = outPin.SetDirection(gpio.OutDirection)
+
+	inPin, _ := NewPin(Pin13)
+	_ = inPin.SetDirection(gpio.InDirection)
+	_ = inPin.SetEdge(gpio.RisingEdge, func(p *gpio.Pin) {
+		log.Printf("wow")
+	})
+
+	for i := 0; i < 4; i++ {
+		_ = outPin.SetHigh()
+		time.Sleep(1000 * time.Millisecond)
+		_ = outPin.SetLow()
+		time.Sleep(1000 * time.Millisecond)
+	}
+}
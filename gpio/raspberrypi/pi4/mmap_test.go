@@ -0,0 +1,123 @@
+package pi4
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/stretchr/testify/assert"
+)
+
+// sysfsPin is a fake sysfs pin.
+type sysfsPin struct {
+	gpio.GPIO
+	activeLow bool
+}
+
+func (p *sysfsPin) ActiveLow() (bool, error) {
+	return p.activeLow, nil
+}
+
+func (p *sysfsPin) SetActiveLow(invert bool) error {
+	p.activeLow = invert
+	return nil
+}
+
+// fakeRegisters replaces the mapped registers by fake registers. The returned
+// func restores them.
+func fakeRegisters() (registers, func()) {
+	regs := make(registers, 0x100/4)
+	memMu.Lock()
+	mem = nil
+	mapRegisters = func() (registers, error) { return regs, nil }
+	memMu.Unlock()
+
+	return regs, func() {
+		memMu.Lock()
+		mem = nil
+		mapRegisters = mapGPIOMem
+		memMu.Unlock()
+	}
+}
+
+func TestFastPinDirection(t *testing.T) {
+	regs, restore := fakeRegisters()
+	defer restore()
+	// GPIO 13 is set to alternative function 0, PWM.
+	regs[gpfsel0+1] = 4 << 9
+
+	p, err := newFastPin(17, &sysfsPin{})
+	assert.Nil(t, err)
+
+	assert.Nil(t, p.SetDirection(gpio.OutDirection))
+	assert.Equal(t, uint32(4<<9|1<<21), regs[gpfsel0+1])
+	d, err := p.Direction()
+	assert.Nil(t, err)
+	assert.Equal(t, gpio.OutDirection, d)
+
+	assert.Nil(t, p.SetDirection(gpio.InDirection))
+	assert.Equal(t, uint32(4<<9), regs[gpfsel0+1])
+	d, err = p.Direction()
+	assert.Nil(t, err)
+	assert.Equal(t, gpio.InDirection, d)
+
+	p, _ = newFastPin(13, &sysfsPin{})
+	_, err = p.Direction()
+	assert.EqualError(t, err, "GPIO 13 is set to function 4 instead of input or output")
+
+	assert.EqualError(t, p.SetDirection("sideways"), "unknown direction 'sideways'")
+}
+
+func TestFastPinValue(t *testing.T) {
+	regs, restore := fakeRegisters()
+	defer restore()
+	p, err := newFastPin(17, &sysfsPin{})
+	assert.Nil(t, err)
+
+	assert.Nil(t, p.SetHigh())
+	assert.Equal(t, uint32(1<<17), regs[gpset0])
+	assert.Nil(t, p.SetLow())
+	assert.Equal(t, uint32(1<<17), regs[gpclr0])
+
+	regs[gplev0] = 1<<17 | 1<<18
+	v, err := p.Value()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+
+	regs[gplev0] = 1 << 18
+	v, _ = p.Value()
+	assert.Equal(t, 0, v)
+}
+
+func TestFastPinActiveLow(t *testing.T) {
+	regs, restore := fakeRegisters()
+	defer restore()
+	sysfs := &sysfsPin{activeLow: true}
+	p, err := newFastPin(27, sysfs)
+	assert.Nil(t, err)
+
+	activeLow, _ := p.ActiveLow()
+	assert.True(t, activeLow)
+
+	assert.Nil(t, p.SetHigh())
+	assert.Equal(t, uint32(1<<27), regs[gpclr0])
+	v, _ := p.Value()
+	assert.Equal(t, 1, v)
+
+	assert.Nil(t, p.SetActiveLow(false))
+	assert.False(t, sysfs.activeLow)
+
+	assert.Nil(t, p.SetHigh())
+	assert.Equal(t, uint32(1<<27), regs[gpset0])
+	v, _ = p.Value()
+	assert.Equal(t, 0, v)
+}
+
+func TestFastPinMapFailure(t *testing.T) {
+	_, restore := fakeRegisters()
+	defer restore()
+	mapRegisters = func() (registers, error) { return nil, errors.New("permission denied") }
+
+	_, err := newFastPin(17, &sysfsPin{})
+	assert.EqualError(t, err, "permission denied")
+}
@@ -0,0 +1,84 @@
+// +build linux
+
+package gpio
+
+import (
+	"context"
+	"sync"
+)
+
+// valuesBuffer is the number of values buffered by the channel returned by
+// Values.
+const valuesBuffer = 8
+
+// Values watches the pin for rising and falling edges and sends the new value
+// of the pin on the returned channel every time it changes. The channel is
+// closed when ctx is done, after that the edge of the pin is set to none,
+// unless the callback has been replaced by SetEdge or Values in the meantime.
+//
+// Values never blocks the watcher. When the consumer is too slow and the
+// buffer of the channel is full, the oldest value is dropped, so the newest
+// value is always delivered. Values replaces a callback registered with
+// SetEdge before.
+func (p *Pin) Values(ctx context.Context) (<-chan int, error) {
+	s := &valueStream{c: make(chan int, valuesBuffer)}
+	f, err := p.setEdge(BothEdge, s.handleEdge)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.close()
+		_ = p.disableEdge(f)
+	}()
+
+	return s.c, nil
+}
+
+// valueStream sends the values of a pin on a channel.
+type valueStream struct {
+	m      sync.Mutex
+	c      chan int
+	closed bool
+}
+
+// handleEdge reads the value of the pin and sends it on the channel. It's
+// called by the watcher, which might call it after the stream has been closed.
+func (s *valueStream) handleEdge(p *Pin) {
+	v, err := p.Value()
+	if err != nil {
+		return
+	}
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	for {
+		select {
+		case s.c <- v:
+			return
+		default:
+		}
+
+		// The buffer is full, drop the oldest value. The consumer might
+		// have received a value in the meantime, so this mustn't block.
+		select {
+		case <-s.c:
+		default:
+		}
+	}
+}
+
+// close closes the channel. No values are send after close returns.
+func (s *valueStream) close() {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.closed = true
+	close(s.c)
+}
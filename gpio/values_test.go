@@ -0,0 +1,90 @@
+// +build linux
+
+package gpio
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueStream(t *testing.T) {
+	rw := &valueReaderWriter{value: "0"}
+	p := NewPin(1, "gpio1", new(watch))
+	p.rwHelper = rw
+
+	s := &valueStream{c: make(chan int, 2)}
+
+	rw.set("1")
+	s.handleEdge(p)
+	assert.Equal(t, 1, <-s.c)
+
+	// A slow consumer only misses the oldest values.
+	for _, v := range []string{"0", "1", "0"} {
+		rw.set(v)
+		s.handleEdge(p)
+	}
+	assert.Equal(t, 1, <-s.c)
+	assert.Equal(t, 0, <-s.c)
+
+	s.close()
+	_, ok := <-s.c
+	assert.False(t, ok)
+
+	// Events after closing are ignored.
+	s.handleEdge(p)
+}
+
+// TestValuesReplaced tests if cancelling a stream that has been replaced by
+// another call to Values leaves the newer stream alone.
+func TestValuesReplaced(t *testing.T) {
+	rw := &valueReaderWriter{value: "0"}
+	w, _ := newWatch(&mockSys{})
+	p := NewPin(1, "gpio1", w)
+	p.rwHelper = rw
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	c1, err := p.Values(ctx1)
+	assert.Nil(t, err)
+
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	c2, err := p.Values(ctx2)
+	assert.Nil(t, err)
+
+	p.m.Lock()
+	f := p.edgeFile
+	p.m.Unlock()
+
+	cancel1()
+	for range c1 {
+	}
+
+	// The goroutine of the first stream might still be running, give it
+	// the chance to wrongly disable the edge.
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, []string{"both", "both"}, rw.getEdges())
+	p.m.Lock()
+	assert.Equal(t, f, p.edgeFile)
+	p.m.Unlock()
+
+	rw.set("1")
+	w.m.RLock()
+	cb := w.callbacks[int(f.Fd())]
+	w.m.RUnlock()
+	cb.callback()
+	assert.Equal(t, 1, <-c2)
+
+	cancel2()
+	for range c2 {
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(rw.getEdges()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, []string{"both", "both", "none"}, rw.getEdges())
+}
@@ -6,23 +6,19 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
 	"syscall"
 )
 
+// watchTimeout is the number of milliseconds EpollWait blocks at most, so
+// Watch notices StopWatch within this time even when no events arrive.
+const watchTimeout = 100
+
 type watchCallback struct {
 	initial  bool
 	callback func()
 }
 
-// Watcher watches files for events and executes a callback when an event occurs.
-type Watcher interface {
-	Watch() error
-	StopWatch()
-	AddEvent(fpnt int, callback func()) error
-	AddFile(file *os.File)
-	Close() error
-}
-
 // watch is the implementation of Watcher. It is used to watch gpio files for
 // changes and handle events assiociated with those files.
 type watch struct {
@@ -36,8 +32,11 @@ type watch struct {
 	// Keep a reference to the files, otherwise it might get garbage collected,
 	// which causes epoll not recieveing any events.
 	files []*os.File
-	run   bool
 	m     sync.RWMutex
+
+	// stopped is set to 1 by StopWatch. It's accessed atomically, because
+	// StopWatch is called from another goroutine than Watch.
+	stopped int32
 }
 
 // NewWatcher Creates a new Watcher.
@@ -66,9 +65,9 @@ func newWatch(sysH syscaller) (*watch, error) {
 	return w, nil
 }
 
-// Watch handles incoming epoll events.
+// Watch handles incoming epoll events until StopWatch is called. Once stopped
+// the watch can't be started again, Watch returns immediately.
 func (w *watch) Watch() error {
-	w.run = true
 	// maxEvents is the maximum of events handled at once.
 	w.m.RLock()
 	maxEvents := len(w.callbacks)
@@ -79,12 +78,14 @@ func (w *watch) Watch() error {
 		maxEvents = 1
 	}
 	events := make([]syscall.EpollEvent, maxEvents)
-	for w.run {
-		// The last argument is the timeout, the timeout specifies how long the call will block,
-		// Setting a timeout of -1 will make it block indefinitely.
-		numEvents, err := w.sysH.EpollWait(w.fd, events, -1)
+	for atomic.LoadInt32(&w.stopped) == 0 {
+		// The last argument is the timeout, the timeout specifies how long the call will block.
+		// It's finite, so the loop checks if it has been stopped regularly.
+		numEvents, err := w.sysH.EpollWait(w.fd, events, watchTimeout)
 		if err != nil {
-			if err == syscall.EAGAIN {
+			// EpollWait is interrupted when the process receives a
+			// signal, which happens often in Go programs.
+			if err == syscall.EAGAIN || err == syscall.EINTR {
 				continue
 			}
 			return fmt.Errorf("stopping watch loop: %v", err)
@@ -96,9 +97,10 @@ func (w *watch) Watch() error {
 	return nil
 }
 
-// StopWatch stops the watcher after next event.
+// StopWatch stops the watcher after the next event, or within watchTimeout
+// milliseconds when no event arrives. It may be called before Watch.
 func (w *watch) StopWatch() {
-	w.run = false
+	atomic.StoreInt32(&w.stopped, 1)
 }
 
 // handleEvent runs the callback funcion if one has been registered for a file.
@@ -154,6 +156,24 @@ func (w *watch) AddEvent(fpntr int, callback func()) error {
 	return nil
 }
 
+func (w *watch) RemoveEvent(fpntr int) error {
+	if err := w.sysH.EpollCtl(w.fd, syscall.EPOLL_CTL_DEL, fpntr, nil); err != nil {
+		return err
+	}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	delete(w.callbacks, fpntr)
+	for i, f := range w.files {
+		if int(f.Fd()) == fpntr {
+			w.files = append(w.files[:i], w.files[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
 func (w *watch) Close() error {
 	return syscall.Close(w.fd)
 }
@@ -1,3 +1,5 @@
+// +build linux
+
 package gpio
 
 import (
@@ -103,9 +105,22 @@ func TestAddEvent(t *testing.T) {
 	}
 }
 
-func TestWatch(t *testing.T) {
+func TestRemoveEvent(t *testing.T) {
 	w, _ := newWatch(&mockSys{})
+	w.addCallback(1, func() {})
+	w.addCallback(2, func() {})
+
+	w.sysH = &mockSys{ectlbErr: errors.New("err")}
+	assert.Equal(t, errors.New("err"), w.RemoveEvent(1))
+	assert.Equal(t, 2, len(w.callbacks))
+
+	w.sysH = &mockSys{}
+	assert.Nil(t, w.RemoveEvent(1))
+	assert.NotContains(t, w.callbacks, 1)
+	assert.Contains(t, w.callbacks, 2)
+}
 
+func TestWatch(t *testing.T) {
 	tests := []struct {
 		callbacks   int
 		err         error
@@ -127,6 +142,8 @@ func TestWatch(t *testing.T) {
 	}
 
 	for _, test := range tests {
+		// A stopped watch can't be started again.
+		w, _ := newWatch(&mockSys{})
 		for i := 0; i < test.callbacks; i++ {
 			w.addCallback(i, func() {})
 		}
@@ -141,3 +158,30 @@ func TestWatch(t *testing.T) {
 		assert.Equal(t, test.expectedErr, err)
 	}
 }
+
+func TestWatchInterrupted(t *testing.T) {
+	w, _ := newWatch(&mockSys{})
+
+	calls := 0
+	w.sysH = &mockSys{eWaitFn: func(epfd int, events []syscall.EpollEvent, msec int) (int, error) {
+		calls++
+		if calls == 1 {
+			return -1, syscall.EINTR
+		}
+		w.StopWatch()
+		return 0, nil
+	}}
+
+	assert.Nil(t, w.Watch())
+	assert.Equal(t, 2, calls)
+}
+
+func TestStopWatchBeforeWatch(t *testing.T) {
+	w, _ := newWatch(&mockSys{eWaitFn: func(epfd int, events []syscall.EpollEvent, msec int) (int, error) {
+		t.Error("EpollWait called after StopWatch")
+		return 0, nil
+	}})
+
+	w.StopWatch()
+	assert.Nil(t, w.Watch())
+}
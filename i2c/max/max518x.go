@@ -3,8 +3,9 @@
 // MAX581x
 //
 // The implemententation for the MAX5813, MAX5814 and MAX5815 only implement
-// the REF and CODEn_LOADn commands. The commands CODEn, LOADn, CODEn_LOAD_ALL,
-// POWER, SW_CLEAR, SW_RESET, CONFIG, CODE_ALL, LOAD_ALL and CODE_ALL,
+// the REF, CODEn, CODEn_LOAD_ALL and CODEn_LOADn commands. The LOADn command
+// byte is only used to read back the DAC registers. The commands POWER,
+// SW_CLEAR, SW_RESET, CONFIG, CODE_ALL, LOAD_ALL and CODE_ALL,
 // CODE_ALL_LOAD_ALL are not implemented.
 package max
 
@@ -12,13 +13,26 @@ import (
 	"fmt"
 	"math"
 
-	"golang.org/x/exp/io/i2c"
+	"github.com/advancedclimatesystems/io/bus"
+	"github.com/advancedclimatesystems/io/dac"
+	"github.com/advancedclimatesystems/io/internal/errs"
 )
 
 const (
 	// CODEn_LOADn simultaneously writes data to the selected CODE
 	// register(s) while updating selected DAC register(s).
 	codenLoadn = 0x30
+
+	// CODEn writes data to the selected CODE register(s).
+	coden = 0x00
+
+	// CODEn_LOAD_ALL simultaneously writes data to the selected CODE
+	// register(s) while updating all DAC registers.
+	codenLoadAll = 0x20
+
+	// LOADn is the command byte to send before reading the DAC register of
+	// channel n.
+	loadn = 0x10
 )
 
 // MAX5813 is a 4 channel DAC with a resolution of 8 bits. The datasheet is
@@ -28,7 +42,7 @@ type MAX5813 struct {
 }
 
 // NewMAX5813 returns a new instance of MAX5813.
-func NewMAX5813(conn *i2c.Device, vref float64) (*MAX5813, error) {
+func NewMAX5813(conn bus.I2C, vref float64) (*MAX5813, error) {
 	m := &MAX5813{
 		max581x{
 			conn:       conn,
@@ -50,7 +64,7 @@ type MAX5814 struct {
 }
 
 // NewMAX5814 returns a new instance of MAX5814.
-func NewMAX5814(conn *i2c.Device, vref float64) (*MAX5814, error) {
+func NewMAX5814(conn bus.I2C, vref float64) (*MAX5814, error) {
 	m := &MAX5814{
 		max581x{
 			conn:       conn,
@@ -72,7 +86,7 @@ type MAX5815 struct {
 }
 
 // NewMAX5815 returns a new instance of MAX5814.
-func NewMAX5815(conn *i2c.Device, vref float64) (*MAX5815, error) {
+func NewMAX5815(conn bus.I2C, vref float64) (*MAX5815, error) {
 	m := &MAX5815{
 		max581x{
 			conn:       conn,
@@ -88,37 +102,136 @@ func NewMAX5815(conn *i2c.Device, vref float64) (*MAX5815, error) {
 }
 
 type max581x struct {
-	conn       *i2c.Device
+	conn       bus.I2C
 	vref       float64
 	resolution int
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
 }
 
 // SetVoltage set output voltage of channel. Using the Vref the input code is
-// calculated and then SetInputCode is called.
+// calculated and then SetInputCode is called. The input code is rounded to
+// the nearest code, so the output is as close as possible to v.
 func (m max581x) SetVoltage(v float64, channel int) error {
-	code := v * (math.Pow(2, float64(m.resolution)) - 1) / m.vref
-	return m.SetInputCode(int(code), channel)
+	return m.SetInputCode(dac.VoltageToCode(v, m.resolution, m.vref), channel)
+}
+
+// SetVoltages sets the output voltages of all 4 channels. Element i of
+// voltages is the voltage of channel i. An error is returned if not exactly 4
+// voltages are given.
+//
+// The CODE registers of all channels are written first using the CODEn
+// command, the last write uses CODEn_LOAD_ALL to update the outputs of all
+// channels at once. So the outputs don't change at all when a voltage is
+// invalid or a write fails.
+func (m max581x) SetVoltages(voltages []float64) error {
+	if len(voltages) != 4 {
+		return fmt.Errorf("got %d voltages, but DAC has 4 channels", len(voltages))
+	}
+
+	frames := make([][]byte, len(voltages))
+	for ch, v := range voltages {
+		cmd := byte(coden)
+		if ch == len(voltages)-1 {
+			cmd = codenLoadAll
+		}
+
+		frame, err := m.frame(cmd, dac.VoltageToCode(v, m.resolution, m.vref), ch)
+		if err != nil {
+			return err
+		}
+		frames[ch] = frame
+	}
+
+	for ch, frame := range frames {
+		if err := bus.LogI2C(m.conn, m.Logger).Write(frame); err != nil {
+			return fmt.Errorf("failed to write channel %d: %w", ch, bus.Error{Err: err})
+		}
+	}
+	return nil
+}
+
+// Resolution returns the voltage of a single step of the input code, that is
+// the smallest difference in output voltage the DAC can produce.
+func (m max581x) Resolution() float64 {
+	return dac.CodeToVoltage(1, m.resolution, m.vref)
 }
 
 // SetInputCode writes the digital input code to the DAC using the CODEn_LOADn
 // command.
 func (m max581x) SetInputCode(code, channel int) error {
+	frame, err := m.frame(codenLoadn, code, channel)
+	if err != nil {
+		return err
+	}
+
+	if err := bus.LogI2C(m.conn, m.Logger).Write(frame); err != nil {
+		return bus.Error{Err: err}
+	}
+	return nil
+}
+
+// OutputCode reads the DAC register of a channel and returns the input code
+// of its output.
+func (m max581x) OutputCode(channel int) (int, error) {
 	if channel < 0 || channel > 3 {
-		return fmt.Errorf("%d is not a valid channel", channel)
+		return 0, errs.New(dac.ErrInvalidChannel, "%d is not a valid channel", channel)
+	}
+
+	conn := bus.LogI2C(m.conn, m.Logger)
+	if err := conn.Write([]byte{loadn | byte(channel)}); err != nil {
+		return 0, fmt.Errorf("failed to read channel %d: %w", channel, bus.Error{Err: err})
+	}
+
+	// The code is left aligned in 2 bytes, like it's written.
+	in := make([]byte, 2)
+	if err := conn.Read(in); err != nil {
+		return 0, fmt.Errorf("failed to read channel %d: %w", channel, bus.Error{Err: err})
+	}
+	return (int(in[0])<<8 | int(in[1])) >> uint(16-m.resolution), nil
+}
+
+// SelfTest writes the mid-scale code to all 4 channels and reads it back using
+// OutputCode. An error matching dac.ErrSelfTestFailed is returned when a
+// channel reads back another code. The outputs are left at mid-scale, so run
+// it before setting the outputs.
+func (m max581x) SelfTest() error {
+	code := 1 << uint(m.resolution-1)
+	for ch := 0; ch < 4; ch++ {
+		if err := m.SetInputCode(code, ch); err != nil {
+			return fmt.Errorf("failed to write channel %d: %w", ch, err)
+		}
+
+		v, err := m.OutputCode(ch)
+		if err != nil {
+			return err
+		}
+		if v != code {
+			return errs.New(dac.ErrSelfTestFailed, "channel %d reads back code %d, but %d has been written", ch, v, code)
+		}
+	}
+	return nil
+}
+
+// frame validates the channel and code and returns the request that sends cmd
+// with them to the DAC.
+func (m max581x) frame(cmd byte, code, channel int) ([]byte, error) {
+	if channel < 0 || channel > 3 {
+		return nil, errs.New(dac.ErrInvalidChannel, "%d is not a valid channel", channel)
 	}
 
 	max := int(math.Pow(2, float64(m.resolution)))
 	if code < 0 || code >= max {
-		return fmt.Errorf("digital input code %d is out of range of 0 <= code < %d", code, int(max))
+		return nil, errs.New(dac.ErrCodeOutOfRange, "digital input code %d is out of range of 0 <= code < %d", code, int(max))
 	}
 
 	// The requests is 3 bytes long. Byte 1 is the command, byte 2 and 3
 	// contain the output code.
-	cmd := byte(codenLoadn | channel)
 	msb := byte((code >> uint(m.resolution-8)) & 0xFF)
 	lsb := byte((code << uint(8-(m.resolution-8))) & 0xFF)
 
-	return m.conn.Write([]byte{cmd, msb, lsb})
+	return []byte{cmd | byte(channel), msb, lsb}, nil
 }
 
 // Vref sets the global reference for all channels. The device can use either
@@ -127,7 +240,12 @@ func (m max581x) SetInputCode(code, channel int) error {
 // 4.096V. If this function is called with one of these value the internel
 // reference is set to this value using the REF command. For any other value
 // the channels will use the input reference is equal to the
+// external reference. The reference must be greater than 0.
 func (m *max581x) SetVref(v float64) error {
+	if v <= 0 {
+		return fmt.Errorf("reference voltage %v is invalid, it must be greater than 0", v)
+	}
+
 	m.vref = v
 	cmd := 0x70
 
@@ -141,10 +259,13 @@ func (m *max581x) SetVref(v float64) error {
 	}
 
 	out := []byte{byte(cmd), 0, 0}
-	return m.conn.Write(out)
+	if err := bus.LogI2C(m.conn, m.Logger).Write(out); err != nil {
+		return bus.Error{Err: err}
+	}
+	return nil
 }
 
 // Conn returns the connection to the I2C device.
-func (m *max581x) Conn() *i2c.Device {
+func (m *max581x) Conn() bus.I2C {
 	return m.conn
 }
@@ -1,6 +1,7 @@
 package max
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -14,6 +15,7 @@ func TestDACinterface(t *testing.T) {
 	assert.Implements(t, (*dac.DAC)(nil), new(MAX5813))
 	assert.Implements(t, (*dac.DAC)(nil), new(MAX5814))
 	assert.Implements(t, (*dac.DAC)(nil), new(MAX5815))
+	assert.Implements(t, (*dac.MultiChannelDAC)(nil), new(MAX5813))
 }
 
 func TestNewMAX581x(t *testing.T) {
@@ -29,6 +31,19 @@ func TestNewMAX581x(t *testing.T) {
 	assert.Equal(t, 12, max5815.resolution)
 }
 
+func TestMAX581xResolution(t *testing.T) {
+	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
+
+	max5813, _ := NewMAX5813(conn, 2.5)
+	assert.Equal(t, 2.5/255, max5813.Resolution())
+
+	max5814, _ := NewMAX5814(conn, 2.5)
+	assert.Equal(t, 2.5/1023, max5814.Resolution())
+
+	max5815, _ := NewMAX5815(conn, 2.5)
+	assert.Equal(t, 2.5/4095, max5815.Resolution())
+}
+
 func TestMAX581xSetVref(t *testing.T) {
 	data := make(chan []byte, 2)
 	c := iotest.NewI2CConn()
@@ -60,6 +75,34 @@ func TestMAX581xSetVref(t *testing.T) {
 
 }
 
+// TestMAX581xWithInvalidVref calls the constructors and SetVref with a
+// reference voltage that isn't greater than 0.
+func TestMAX581xWithInvalidVref(t *testing.T) {
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		t.Fatalf("unexpected write %v", w)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	for _, vref := range []float64{0, -2.5} {
+		msg := fmt.Sprintf("reference voltage %v is invalid, it must be greater than 0", vref)
+
+		_, err := NewMAX5813(conn, vref)
+		assert.EqualError(t, err, msg)
+
+		_, err = NewMAX5814(conn, vref)
+		assert.EqualError(t, err, msg)
+
+		_, err = NewMAX5815(conn, vref)
+		assert.EqualError(t, err, msg)
+
+		m := max581x{conn: conn, vref: 2.5}
+		assert.EqualError(t, m.SetVref(vref), msg)
+		assert.Equal(t, 2.5, m.vref)
+	}
+}
+
 func TestMAX581xSetVoltage(t *testing.T) {
 	data := make(chan []byte, 2)
 	c := iotest.NewI2CConn()
@@ -81,15 +124,15 @@ func TestMAX581xSetVoltage(t *testing.T) {
 		expected   []byte
 	}{
 		{8, 2.5, 2.5, 1, []byte{0x31, 0xff, 0}},
-		{8, 5, 2.5, 2, []byte{0x32, 0x7f, 0}},
+		{8, 5, 2.5, 2, []byte{0x32, 0x80, 0}},
 		{8, 5, 0, 2, []byte{0x32, 0, 0}},
 
 		{10, 5, 5, 2, []byte{0x32, 0xff, 0xc0}},
-		{10, 5, 2.5, 2, []byte{0x32, 0x7f, 0xc0}},
+		{10, 5, 2.5, 2, []byte{0x32, 0x80, 0}},
 		{10, 5, 0, 2, []byte{0x32, 0, 0}},
 
 		{12, 2.5, 2.5, 3, []byte{0x33, 0xff, 0xf0}},
-		{12, 5, 2.5, 3, []byte{0x33, 0x7f, 0xf0}},
+		{12, 5, 2.5, 3, []byte{0x33, 0x80, 0}},
 		{12, 10, 2, 3, []byte{0x33, 0x33, 0x30}},
 	}
 
@@ -203,3 +246,96 @@ func ExampleMAX5813() {
 		panic(fmt.Sprintf("failed to set voltage using output code: %v", err))
 	}
 }
+
+func TestMAX581xSetVoltages(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		writes = append(writes, w)
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	m, _ := NewMAX5813(conn, 2.55)
+
+	// The CODE registers are written using CODEn, the last write loads
+	// all DAC registers using CODEn_LOAD_ALL.
+	writes = nil
+	assert.Nil(t, m.SetVoltages([]float64{0, 0.01, 0.02, 2.55}))
+	assert.Equal(t, [][]byte{
+		{0x00, 0, 0},
+		{0x01, 1, 0},
+		{0x02, 2, 0},
+		{0x23, 0xff, 0},
+	}, writes)
+
+	writes = nil
+	assert.EqualError(t, m.SetVoltages(make([]float64, 3)), "got 3 voltages, but DAC has 4 channels")
+
+	// Nothing is written when one of the voltages is out of range.
+	assert.EqualError(t, m.SetVoltages([]float64{0, 3, 0, 0}), "digital input code 300 is out of range of 0 <= code < 256")
+	assert.Len(t, writes, 0)
+
+	c.TxFunc(func(w, _ []byte) error {
+		return fmt.Errorf("some error")
+	})
+	assert.EqualError(t, m.SetVoltages(make([]float64, 4)), "failed to write channel 0: some error")
+}
+
+func TestMAX581xSelfTest(t *testing.T) {
+	assert.Implements(t, (*dac.SelfTester)(nil), new(MAX5813))
+
+	// codes are the DAC registers of the channels, the last write selects
+	// the register to read.
+	codes := make(map[byte][]byte)
+	var read byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		switch len(w) {
+		case 1:
+			read = w[0] &^ loadn
+		case 3:
+			codes[w[0]&^codenLoadn] = append([]byte(nil), w[1:]...)
+		}
+		copy(r, codes[read])
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	m, _ := NewMAX5815(conn, 2.5)
+	assert.Nil(t, dac.SelfTest(m))
+	for ch := byte(0); ch < 4; ch++ {
+		assert.Equal(t, []byte{0x80, 0x00}, codes[ch])
+	}
+
+	codes[2] = []byte{0xab, 0xc0}
+	code, err := m.OutputCode(2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0xabc, code)
+
+	_, err = m.OutputCode(4)
+	assert.True(t, errors.Is(err, dac.ErrInvalidChannel))
+
+	// Channel 3 doesn't respond, it reads back 0.
+	c.TxFunc(func(w, r []byte) error {
+		if len(w) == 1 {
+			read = w[0] &^ loadn
+		}
+		if read != 3 {
+			copy(r, []byte{0x80, 0x00})
+		}
+		return nil
+	})
+
+	err = m.SelfTest()
+	assert.EqualError(t, err, "channel 3 reads back code 0, but 2048 has been written")
+	assert.True(t, errors.Is(err, dac.ErrSelfTestFailed))
+
+	c.TxFunc(func(w, r []byte) error {
+		return errors.New("some error")
+	})
+
+	assert.EqualError(t, m.SelfTest(), "failed to write channel 0: some error")
+	_, err = m.OutputCode(0)
+	assert.EqualError(t, err, "failed to read channel 0: some error")
+}
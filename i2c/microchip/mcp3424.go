@@ -0,0 +1,350 @@
+package microchip
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/bus"
+)
+
+var _ adc.Sized = (*MCP3424)(nil)
+
+// The fields of the config register of the MCP3424.
+const (
+	// mcp3424RDY starts a conversion in one-shot mode when written. When
+	// read, it's 0 when the output code hasn't been read yet.
+	mcp3424RDY = 0x80
+	// mcp3424Continuous selects continuous conversion mode.
+	mcp3424Continuous = 0x10
+)
+
+// mcp3424Vref is the voltage of the internal reference of the MCP3424.
+const mcp3424Vref = 2.048
+
+// mcp3424Sleep waits for a conversion of the MCP3424.
+var mcp3424Sleep = time.Sleep
+
+// ConversionMode is the conversion mode of the MCP3424.
+type ConversionMode int
+
+const (
+	// ContinuousConversion makes the ADC convert the selected channel
+	// continuously. This is the default mode of the ADC.
+	ContinuousConversion ConversionMode = iota
+
+	// OneShotConversion makes the ADC power down after a conversion until
+	// the next conversion is started. OutputCode starts a conversion for
+	// every call.
+	OneShotConversion
+)
+
+// MCP3424 is an ADC with 4 differential inputs, a PGA and an internal
+// reference of 2.048V. The resolution is 12, 14, 16 or 18 bits, the higher
+// the resolution the lower the data rate: 240, 60, 15 or 3.75 samples per
+// second. The datasheet is here:
+// http://ww1.microchip.com/downloads/en/DeviceDoc/22088c.pdf
+//
+// The MCP3424 converts a single channel at a time. OutputCode selects the
+// channel and waits for a conversion of it when needed, so reading another
+// channel than the last one takes a conversion period. The methods of an
+// MCP3424 are safe for concurrent use.
+type MCP3424 struct {
+	Conn bus.I2C
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
+	// m guards the cached config: resolution, pga, oneShot, channel and
+	// converted.
+	m          sync.Mutex
+	resolution int
+	pga        int
+	oneShot    bool
+	channel    int
+
+	// converted is true when a conversion has been read since the config
+	// has been written, so the output register holds a conversion of the
+	// config.
+	converted bool
+}
+
+// NewMCP3424 returns an MCP3424 in continuous conversion mode. The resolution
+// must be 12, 14, 16 or 18 bits, the PGA 1, 2, 4 or 8.
+func NewMCP3424(conn bus.I2C, resolution, pga int) (*MCP3424, error) {
+	if err := checkResolution(resolution); err != nil {
+		return nil, err
+	}
+	if err := checkPGA(pga); err != nil {
+		return nil, err
+	}
+
+	m := &MCP3424{
+		Conn:       conn,
+		resolution: resolution,
+		pga:        pga,
+	}
+
+	if err := m.writeConfig(mcp3424Config(0, resolution, pga, false)); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3424: %w", err)
+	}
+	return m, nil
+}
+
+// Channels returns the number of channels, 4.
+func (m *MCP3424) Channels() int {
+	return 4
+}
+
+// SetResolution writes the resolution in bits to the ADC, 12, 14, 16 or 18.
+func (m *MCP3424) SetResolution(bits int) error {
+	if err := checkResolution(bits); err != nil {
+		return err
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if err := m.writeConfig(mcp3424Config(m.channel, bits, m.pga, m.oneShot)); err != nil {
+		return err
+	}
+
+	m.resolution = bits
+	return nil
+}
+
+// SetPGA writes the gain of the Programmable Gain Amplifier to the ADC, 1, 2,
+// 4 or 8.
+func (m *MCP3424) SetPGA(v int) error {
+	if err := checkPGA(v); err != nil {
+		return err
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	if err := m.writeConfig(mcp3424Config(m.channel, m.resolution, v, m.oneShot)); err != nil {
+		return err
+	}
+
+	m.pga = v
+	return nil
+}
+
+// SetConversionMode writes the conversion mode to the ADC.
+func (m *MCP3424) SetConversionMode(mode ConversionMode) error {
+	if mode != ContinuousConversion && mode != OneShotConversion {
+		return fmt.Errorf("conversion mode %d is invalid", mode)
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	oneShot := mode == OneShotConversion
+	if err := m.writeConfig(mcp3424Config(m.channel, m.resolution, m.pga, oneShot)); err != nil {
+		return err
+	}
+
+	m.oneShot = oneShot
+	return nil
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure. It depends on the
+// selected resolution and PGA.
+func (m *MCP3424) Resolution() float64 {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	return m.lsb()
+}
+
+// Voltage queries the channel and returns its voltage. The voltage is computed
+// with the resolution and PGA the output code has been read with.
+func (m *MCP3424) Voltage(channel int) (float64, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	code, err := m.outputCode(channel)
+	if err != nil {
+		return 0, err
+	}
+	return float64(code) * m.lsb(), nil
+}
+
+// OutputCode queries the channel and returns its digital output code. The
+// output code is a two's complement value with the number of bits of the
+// resolution, negative when the voltage on the negative input is higher than
+// on the positive input.
+//
+// In one-shot mode, or when the channel isn't the channel of the last call,
+// OutputCode writes the config to start a conversion of the channel and waits
+// until it has finished. Else it returns the last conversion of the
+// continuous conversion mode.
+func (m *MCP3424) OutputCode(channel int) (int, error) {
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	return m.outputCode(channel)
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m *MCP3424) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m *MCP3424) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// outputCode is OutputCode without locking.
+func (m *MCP3424) outputCode(channel int) (int, error) {
+	if channel < 0 || channel > 3 {
+		return 0, adc.ErrInvalidChannel{Channel: channel, Channels: 4}
+	}
+
+	if !m.oneShot && channel == m.channel && m.converted {
+		code, _, err := m.read()
+		return code, err
+	}
+
+	config := mcp3424Config(channel, m.resolution, m.pga, m.oneShot)
+	if m.oneShot {
+		config |= mcp3424RDY
+	}
+	if err := m.writeConfig(config); err != nil {
+		return 0, fmt.Errorf("failed to select channel %d: %w", channel, err)
+	}
+
+	code, err := m.waitForConversion()
+	if err != nil {
+		return 0, err
+	}
+
+	m.converted = true
+	return code, nil
+}
+
+// waitForConversion waits until the output register holds a conversion that
+// hasn't been read yet and returns its output code.
+func (m *MCP3424) waitForConversion() (int, error) {
+	d := m.period()
+	mcp3424Sleep(d)
+
+	for i := 0; i < 10; i++ {
+		code, config, err := m.read()
+		if err != nil {
+			return 0, err
+		}
+
+		if config&mcp3424RDY == 0 {
+			return code, nil
+		}
+		mcp3424Sleep(d / 10)
+	}
+
+	return 0, fmt.Errorf("conversion didn't finish within %v", 2*d)
+}
+
+// read reads the output register and returns the output code and the config
+// register. In 18 bits resolution the output code is 3 bytes, else 2. The
+// config register follows.
+func (m *MCP3424) read() (int, byte, error) {
+	n := 2
+	if m.resolution == 18 {
+		n = 3
+	}
+
+	in := make([]byte, n+1)
+	if err := bus.LogI2C(m.Conn, m.Logger).Read(in); err != nil {
+		return 0, 0, fmt.Errorf("failed to read output code: %w", bus.Error{Err: err})
+	}
+
+	// The unused high bits repeat the sign bit, but they're masked
+	// anyway.
+	var code int
+	for _, b := range in[:n] {
+		code = code<<8 | int(b)
+	}
+	code &= 1<<uint(m.resolution) - 1
+	if code >= 1<<uint(m.resolution-1) {
+		code -= 1 << uint(m.resolution)
+	}
+	return code, in[n], nil
+}
+
+// writeConfig writes v to the config register of the ADC.
+func (m *MCP3424) writeConfig(v byte) error {
+	if err := bus.LogI2C(m.Conn, m.Logger).Write([]byte{v}); err != nil {
+		return fmt.Errorf("failed to write config register: %w", bus.Error{Err: err})
+	}
+
+	m.channel = int(v>>5) & 0x3
+	m.converted = false
+	return nil
+}
+
+// lsb returns the voltage of a single step of the output code.
+func (m *MCP3424) lsb() float64 {
+	return mcp3424Vref / float64(int(1)<<uint(m.resolution-1)) / float64(m.pga)
+}
+
+// period returns the duration of a conversion in the cached resolution.
+func (m *MCP3424) period() time.Duration {
+	switch m.resolution {
+	case 12:
+		return time.Second / 240
+	case 14:
+		return time.Second / 60
+	case 16:
+		return time.Second / 15
+	default:
+		return time.Second * 4 / 15
+	}
+}
+
+// mcp3424Config returns the value of the config register that selects
+// channel, resolution, pga and the conversion mode.
+func mcp3424Config(channel, resolution, pga int, oneShot bool) byte {
+	config := byte(channel)<<5 | byte((resolution-12)/2)<<2 | pgaBits(pga)
+	if !oneShot {
+		config |= mcp3424Continuous
+	}
+	return config
+}
+
+// checkResolution returns an error when the MCP3424 doesn't support a
+// resolution of bits.
+func checkResolution(bits int) error {
+	if bits == 12 || bits == 14 || bits == 16 || bits == 18 {
+		return nil
+	}
+	return fmt.Errorf("resolution of %d bits is invalid, choose 12, 14, 16 or 18", bits)
+}
+
+// checkPGA returns an error when the MCP3424 doesn't support PGA v.
+func checkPGA(v int) error {
+	if v == 1 || v == 2 || v == 4 || v == 8 {
+		return nil
+	}
+	return fmt.Errorf("PGA of %d is invalid, choose 1, 2, 4 or 8", v)
+}
+
+// pgaBits returns the bits of the config register that select PGA v.
+func pgaBits(v int) byte {
+	switch v {
+	case 2:
+		return 1
+	case 4:
+		return 2
+	case 8:
+		return 3
+	default:
+		return 0
+	}
+}
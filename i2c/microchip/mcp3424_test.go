@@ -0,0 +1,196 @@
+package microchip
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/iotest"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/io/i2c"
+)
+
+// newMCP3424Conn returns a connection to a fake MCP3424. Every read returns
+// the next response of responses, the last one is repeated. The writes are
+// returned as well.
+func newMCP3424Conn(responses ...[]byte) (*iotest.I2CConn, *i2c.Device, *[]byte) {
+	var writes []byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		writes = append(writes, w...)
+		if len(r) > 0 {
+			copy(r, responses[0])
+			if len(responses) > 1 {
+				responses = responses[1:]
+			}
+		}
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x68)
+	return &c, conn, &writes
+}
+
+// fakeSleep replaces the sleep of the MCP3424 and records the durations. It
+// returns a function that restores the sleep.
+func fakeSleep(sleeps *[]time.Duration) func() {
+	mcp3424Sleep = func(d time.Duration) {
+		*sleeps = append(*sleeps, d)
+	}
+	return func() { mcp3424Sleep = time.Sleep }
+}
+
+func TestMCP3424Interface(t *testing.T) {
+	assert.Implements(t, (*adc.ADC)(nil), new(MCP3424))
+	assert.Equal(t, 4, new(MCP3424).Channels())
+}
+
+func TestMCP3424OutputCode(t *testing.T) {
+	var sleeps []time.Duration
+	defer fakeSleep(&sleeps)()
+
+	tests := []struct {
+		resolution int
+		in         []byte
+		config     byte
+		code       int
+		voltage    float64
+		period     time.Duration
+	}{
+		// The unused high bits repeat the sign bit.
+		{12, []byte{0x07, 0xff, 0x10}, 0x10, 2047, 2.047, time.Second / 240},
+		{12, []byte{0xf8, 0x00, 0x10}, 0x10, -2048, -2.048, time.Second / 240},
+		{12, []byte{0xff, 0xff, 0x10}, 0x10, -1, -0.001, time.Second / 240},
+		{14, []byte{0x1f, 0xff, 0x14}, 0x14, 8191, 2.047750, time.Second / 60},
+		{14, []byte{0xe0, 0x00, 0x14}, 0x14, -8192, -2.048, time.Second / 60},
+		{16, []byte{0x7f, 0xff, 0x18}, 0x18, 32767, 2.0479375, time.Second / 15},
+		{16, []byte{0x80, 0x00, 0x18}, 0x18, -32768, -2.048, time.Second / 15},
+		// In 18 bits resolution the output code is 3 bytes.
+		{18, []byte{0x01, 0xff, 0xff, 0x1c}, 0x1c, 131071, 2.047984375, time.Second * 4 / 15},
+		{18, []byte{0xfe, 0x00, 0x00, 0x1c}, 0x1c, -131072, -2.048, time.Second * 4 / 15},
+		{18, []byte{0xff, 0xff, 0xff, 0x1c}, 0x1c, -1, -0.000015625, time.Second * 4 / 15},
+	}
+
+	for _, test := range tests {
+		sleeps = nil
+		_, conn, writes := newMCP3424Conn(test.in)
+		m, err := NewMCP3424(conn, test.resolution, 1)
+		assert.Nil(t, err)
+
+		code, err := m.OutputCode(0)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code, fmt.Sprintf("% x", test.in))
+		assert.Equal(t, []byte{test.config, test.config}, *writes)
+		assert.Equal(t, []time.Duration{test.period}, sleeps)
+
+		// The channel is selected already, so the last conversion is
+		// read without waiting.
+		v, err := m.Voltage(0)
+		assert.Nil(t, err)
+		assert.InDelta(t, test.voltage, v, 1e-9)
+		assert.Equal(t, []byte{test.config, test.config}, *writes)
+		assert.Equal(t, []time.Duration{test.period}, sleeps)
+	}
+}
+
+func TestMCP3424Channels(t *testing.T) {
+	var sleeps []time.Duration
+	defer fakeSleep(&sleeps)()
+
+	// The first 2 reads are of a conversion that has been read already.
+	_, conn, writes := newMCP3424Conn(
+		[]byte{0x00, 0x01, 0x90},
+		[]byte{0x00, 0x01, 0x90},
+		[]byte{0x00, 0x02, 0x30},
+	)
+	m, err := NewMCP3424(conn, 12, 2)
+	assert.Nil(t, err)
+
+	code, err := m.OutputCode(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, code)
+	assert.Equal(t, []byte{0x11, 0x31}, *writes)
+	assert.Equal(t, []time.Duration{time.Second / 240, time.Second / 2400, time.Second / 2400}, sleeps)
+
+	_, err = m.OutputCode(4)
+	assert.Equal(t, adc.ErrInvalidChannel{Channel: 4, Channels: 4}, err)
+	_, err = m.OutputCode(-1)
+	assert.Equal(t, adc.ErrInvalidChannel{Channel: -1, Channels: 4}, err)
+}
+
+func TestMCP3424OneShot(t *testing.T) {
+	var sleeps []time.Duration
+	defer fakeSleep(&sleeps)()
+
+	_, conn, writes := newMCP3424Conn([]byte{0x00, 0x00, 0x01, 0x4f})
+	m, err := NewMCP3424(conn, 18, 8)
+	assert.Nil(t, err)
+
+	assert.Nil(t, m.SetConversionMode(OneShotConversion))
+
+	// Every call starts a conversion, also of the same channel.
+	for i := 0; i < 2; i++ {
+		code, err := m.OutputCode(2)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, code)
+	}
+	assert.Equal(t, []byte{0x1f, 0x0f, 0xcf, 0xcf}, *writes)
+
+	assert.EqualError(t, m.SetConversionMode(3), "conversion mode 3 is invalid")
+}
+
+func TestMCP3424Settings(t *testing.T) {
+	var sleeps []time.Duration
+	defer fakeSleep(&sleeps)()
+
+	_, conn, writes := newMCP3424Conn([]byte{0x00, 0x01, 0x30})
+	m, err := NewMCP3424(conn, 12, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.001, m.Resolution())
+
+	// The setters keep the selected channel.
+	_, err = m.OutputCode(1)
+	assert.Nil(t, err)
+
+	assert.Nil(t, m.SetPGA(4))
+	assert.Nil(t, m.SetResolution(16))
+	assert.Equal(t, []byte{0x10, 0x30, 0x32, 0x3a}, *writes)
+	assert.Equal(t, 0.0000156250, m.Resolution())
+
+	assert.EqualError(t, m.SetPGA(3), "PGA of 3 is invalid, choose 1, 2, 4 or 8")
+	assert.EqualError(t, m.SetResolution(10), "resolution of 10 bits is invalid, choose 12, 14, 16 or 18")
+	assert.Equal(t, []byte{0x10, 0x30, 0x32, 0x3a}, *writes)
+
+	_, err = NewMCP3424(conn, 13, 1)
+	assert.EqualError(t, err, "resolution of 13 bits is invalid, choose 12, 14, 16 or 18")
+	_, err = NewMCP3424(conn, 12, 16)
+	assert.EqualError(t, err, "PGA of 16 is invalid, choose 1, 2, 4 or 8")
+}
+
+func TestMCP3424Errors(t *testing.T) {
+	var sleeps []time.Duration
+	defer fakeSleep(&sleeps)()
+
+	// The conversion never finishes.
+	c, conn, _ := newMCP3424Conn([]byte{0x00, 0x00, 0x90})
+	m, err := NewMCP3424(conn, 12, 1)
+	assert.Nil(t, err)
+
+	_, err = m.OutputCode(0)
+	assert.EqualError(t, err, "conversion didn't finish within 8.333332ms")
+
+	c.TxFunc(func(w, r []byte) error {
+		return errors.New("some error")
+	})
+
+	_, err = m.OutputCode(0)
+	assert.EqualError(t, err, "failed to select channel 0: failed to write config register: some error")
+
+	assert.EqualError(t, m.SetPGA(2), "failed to write config register: some error")
+	assert.Equal(t, 1.0/1000, m.Resolution())
+
+	_, err = NewMCP3424(conn, 12, 1)
+	assert.EqualError(t, err, "failed to create MCP3424: failed to write config register: some error")
+}
@@ -5,7 +5,9 @@ package microchip
 import (
 	"fmt"
 
-	"golang.org/x/exp/io/i2c"
+	"github.com/advancedclimatesystems/io/bus"
+	"github.com/advancedclimatesystems/io/dac"
+	"github.com/advancedclimatesystems/io/internal/errs"
 )
 
 // The MCP4725 has a 14 bit wide EEPROM to store configuration bits (2 bits)
@@ -19,19 +21,44 @@ import (
 // manufacturing, and the A0 bit is determined by the logic state of AO pin.
 //
 // The MCP4725 has 2 modes of operation: normal mode and power-down mode. This
-// driver only supports normal mode.
+// driver only writes in normal mode, so setting an output powers the DAC up.
+// It does read the mode back though, see OutputCode, because the DAC might
+// have been left powered down by another program.
 //
 // The datasheet of the device is here:
 // http://ww1.microchip.com/downloads/en/DeviceDoc/22039d.pdf
 type MCP4725 struct {
-	conn *i2c.Device
+	conn bus.I2C
 	vref float64
 
 	Address int
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
 }
 
-// NewMCP4725 returns a new instance of MCP4725.
-func NewMCP4725(conn *i2c.Device, vref float64) (*MCP4725, error) {
+// PowerDownMode is the mode of operation of the MCP4725. In power-down mode
+// the output is switched off and pulled to ground by a resistor.
+type PowerDownMode int
+
+const (
+	// Normal is the normal mode of operation, the output is on.
+	Normal PowerDownMode = iota
+	// PowerDown1k pulls the output to ground with 1kΩ.
+	PowerDown1k
+	// PowerDown100k pulls the output to ground with 100kΩ.
+	PowerDown100k
+	// PowerDown500k pulls the output to ground with 500kΩ.
+	PowerDown500k
+)
+
+// NewMCP4725 returns a new instance of MCP4725. The reference voltage must be
+// greater than 0.
+func NewMCP4725(conn bus.I2C, vref float64) (*MCP4725, error) {
+	if vref <= 0 {
+		return nil, fmt.Errorf("reference voltage %v is invalid, it must be greater than 0", vref)
+	}
+
 	return &MCP4725{
 		conn: conn,
 		vref: vref,
@@ -41,30 +68,106 @@ func NewMCP4725(conn *i2c.Device, vref float64) (*MCP4725, error) {
 // SetVoltage sets voltage of the only channel of the MCP4725. The channel
 // parameter is required in the signature of the function to be conform with
 // the dac.DAC interface. Because the MCP4725 has only 1 channel it's only
-// allowed value is 1.
+// allowed value is 0, see SetInputCode. The input code is rounded to the
+// nearest code, so the output is as close as possible to v. Like on the other
+// DACs, code 4095 outputs vref, so v may be anything from 0 up to and
+// including vref.
 func (m MCP4725) SetVoltage(v float64, channel int) error {
-	code := v * 4095 / m.vref
-	return m.SetInputCode(int(code), channel)
+	return m.SetInputCode(dac.VoltageToCode(v, 12, m.vref), channel)
+}
+
+// SetVoltages sets the voltage of the only channel of the MCP4725. It exists
+// to be conform with the dac.MultiChannelDAC interface, so voltages must
+// contain exactly 1 voltage.
+func (m MCP4725) SetVoltages(voltages []float64) error {
+	if len(voltages) != 1 {
+		return fmt.Errorf("got %d voltages, but MCP4725 has only 1 channel", len(voltages))
+	}
+	return m.SetVoltage(voltages[0], 0)
+}
+
+// Resolution returns the voltage of a single step of the input code, that is
+// the smallest difference in output voltage the DAC can produce.
+func (m MCP4725) Resolution() float64 {
+	return dac.CodeToVoltage(1, 12, m.vref)
 }
 
 // SetInputCode sets voltage of the only channel of the MCP4725. The channel
 // parameter is required in the signature of the function to be conform with
 // the dac.DAC interface. Because the MCP4725 has only 1 channel it's only
-// allowed value is 1.
+// allowed value is 0. Channel 1 is accepted as well, it was the only valid
+// channel before channels became 0-based for all drivers. The code must be
+// in the range of 0 <= code < 4096.
 func (m MCP4725) SetInputCode(code, channel int) error {
-	if channel != 1 {
-		return fmt.Errorf("channel %d is invalid, MCP4725 has only 1 channel", channel)
+	if channel != 0 && channel != 1 {
+		return errs.New(dac.ErrInvalidChannel, "channel %d is invalid, MCP4725 has only 1 channel", channel)
 	}
 
 	if code < 0 || code >= 4096 {
-		return fmt.Errorf("digital input code %d is out of range of 0 <= code < 4096", code)
+		return errs.New(dac.ErrCodeOutOfRange, "digital input code %d is out of range of 0 <= code < 4096", code)
 	}
 
 	out := []byte{byte(code >> byte(8)), byte(code & 0xFF)}
 
-	if err := m.conn.Write(out); err != nil {
-		return fmt.Errorf("failed to write output code %d: %v", code, err)
+	if err := bus.LogI2C(m.conn, m.Logger).Write(out); err != nil {
+		return fmt.Errorf("failed to write output code %d: %w", code, bus.Error{Err: err})
 	}
 
 	return nil
 }
+
+// OutputCode reads the DAC register and returns the input code of the output
+// and the mode of operation. In power-down mode the output isn't driven, so
+// the code isn't the output of the DAC.
+func (m MCP4725) OutputCode() (int, PowerDownMode, error) {
+	// The first byte contains the status and the mode, the 12 bits input
+	// code follows left justified in 2 bytes. The last 2 bytes contain
+	// the EEPROM, those are ignored.
+	in := make([]byte, 5)
+	if err := bus.LogI2C(m.conn, m.Logger).Read(in); err != nil {
+		return 0, Normal, fmt.Errorf("failed to read DAC register: %w", bus.Error{Err: err})
+	}
+
+	code := int(in[1])<<4 | int(in[2])>>4
+	return code, PowerDownMode(in[0]>>1) & 0x3, nil
+}
+
+// Mode reads the DAC register and returns the mode of operation.
+func (m MCP4725) Mode() (PowerDownMode, error) {
+	_, mode, err := m.OutputCode()
+	return mode, err
+}
+
+// Voltage reads the DAC register and returns the voltage of the output. It's
+// 0 in power-down mode, because the output is pulled to ground then.
+func (m MCP4725) Voltage() (float64, error) {
+	code, mode, err := m.OutputCode()
+	if err != nil {
+		return 0, err
+	}
+
+	if mode != Normal {
+		return 0, nil
+	}
+	return dac.CodeToVoltage(code, 12, m.vref), nil
+}
+
+// SelfTest writes the mid-scale code to the DAC and reads it back using
+// OutputCode. An error matching dac.ErrSelfTestFailed is returned when it
+// reads back another code or when the DAC isn't in normal mode. The output is
+// left at mid-scale, so run it before setting the output.
+func (m MCP4725) SelfTest() error {
+	const code = 2048
+	if err := m.SetInputCode(code, 0); err != nil {
+		return err
+	}
+
+	v, mode, err := m.OutputCode()
+	if err != nil {
+		return err
+	}
+	if v != code || mode != Normal {
+		return errs.New(dac.ErrSelfTestFailed, "DAC reads back code %d in mode %d, but %d has been written in normal mode", v, mode, code)
+	}
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 
 func TestDACinterface(t *testing.T) {
 	assert.Implements(t, (*dac.DAC)(nil), new(MCP4725))
+	assert.Implements(t, (*dac.MultiChannelDAC)(nil), new(MCP4725))
 }
 
 func TestMCP4725WithValidVoltages(t *testing.T) {
@@ -31,30 +32,71 @@ func TestMCP4725WithValidVoltages(t *testing.T) {
 		voltage  float64
 		expected []byte
 	}{
-		{2.7, 1.73, []byte{0xa, 0x3f}},
-		{2.7, 2.6999, []byte{0x0f, 0xfe}},
+		{2.7, 1.73, []byte{0xa, 0x40}},
+		{2.7, 2.6999, []byte{0x0f, 0xff}},
 		{2.7, 0, []byte{0x0, 0x0}},
 		{5.5, 1.22, []byte{0x3, 0x8c}},
-		{5.5, 0.73, []byte{0x2, 0x1f}},
+		{5.5, 0.73, []byte{0x2, 0x20}},
 	}
 
 	for _, test := range tests {
 		m, err := NewMCP4725(conn, test.vref)
 		assert.Nil(t, err)
 
-		err = m.SetVoltage(test.voltage, 1)
+		err = m.SetVoltage(test.voltage, 0)
 		assert.Equal(t, test.expected, <-data)
 		assert.Nil(t, err)
 	}
 }
 
+func TestMCP4725FullScale(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		writes = append(writes, w)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	for _, vref := range []float64{2.7, 3.3, 5.5} {
+		writes = nil
+		m, _ := NewMCP4725(conn, vref)
+
+		// Vref results in the highest code, just above it is out of
+		// range.
+		assert.Nil(t, m.SetVoltage(vref, 0))
+		assert.Equal(t, [][]byte{{0x0f, 0xff}}, writes)
+
+		err := m.SetVoltage(vref+m.Resolution(), 0)
+		assert.EqualError(t, err, "digital input code 4096 is out of range of 0 <= code < 4096")
+		assert.True(t, errors.Is(err, dac.ErrCodeOutOfRange))
+	}
+}
+
+func TestMCP4725Resolution(t *testing.T) {
+	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
+	m, _ := NewMCP4725(conn, 2.7)
+
+	assert.Equal(t, 2.7/4095, m.Resolution())
+}
+
+func TestMCP4725WithInvalidVref(t *testing.T) {
+	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
+
+	for _, vref := range []float64{0, -2.7} {
+		m, err := NewMCP4725(conn, vref)
+		assert.Nil(t, m)
+		assert.EqualError(t, err, fmt.Sprintf("reference voltage %v is invalid, it must be greater than 0", vref))
+	}
+}
+
 func TestMCP4725WithInValidVoltages(t *testing.T) {
 	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
 	m, _ := NewMCP4725(conn, 2.7)
 
 	voltages := []float64{-1, 28.1}
 	for _, v := range voltages {
-		err := m.SetVoltage(v, 1)
+		err := m.SetVoltage(v, 0)
 		assert.NotNil(t, err)
 	}
 }
@@ -63,11 +105,14 @@ func TestMCP4725WithInvalidChannel(t *testing.T) {
 	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
 	m, _ := NewMCP4725(conn, 2.7)
 
-	channels := []int{-1, 0, 2, 28}
+	channels := []int{-1, 2, 28}
 	for _, c := range channels {
 		err := m.SetVoltage(1, c)
 		assert.NotNil(t, err)
 	}
+
+	// Channel 1 is still accepted.
+	assert.Nil(t, m.SetVoltage(1, 1))
 }
 
 func TestMCP4725WithFailingConnection(t *testing.T) {
@@ -76,7 +121,7 @@ func TestMCP4725WithFailingConnection(t *testing.T) {
 	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
 
 	m, _ := NewMCP4725(conn, 2.7)
-	err := m.SetVoltage(1, 1)
+	err := m.SetVoltage(1, 0)
 
 	assert.NotNil(t, err)
 }
@@ -98,15 +143,120 @@ func ExampleMCP4725() {
 		panic(fmt.Sprintf("failed to create MCP4725: %v", err))
 	}
 
-	// Set output of channel 1 to 1.3V. The MCP4725 has only 1 channel,
+	// Set output of channel 0 to 3V. The MCP4725 has only 1 channel,
 	// select other channels results in an error.
-	if err := dac.SetVoltage(3, 1); err != nil {
+	if err := dac.SetVoltage(3, 0); err != nil {
 		panic(fmt.Sprintf("failed to set voltage: %v", err))
 	}
 
 	// It's also possible to set output of a channel with digital output
 	// code. The value must be in range of 0 till 4096.
-	if err := dac.SetInputCode(4095, 1); err != nil {
+	if err := dac.SetInputCode(4095, 0); err != nil {
 		panic(fmt.Sprintf("failed to set voltage using output code: %v", err))
 	}
 }
+
+func TestMCP4725SetVoltages(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		writes = append(writes, w)
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	m, _ := NewMCP4725(conn, 2.7)
+
+	assert.Nil(t, m.SetVoltages([]float64{1.73}))
+	assert.Equal(t, [][]byte{{0xa, 0x40}}, writes)
+
+	assert.EqualError(t, m.SetVoltages(nil), "got 0 voltages, but MCP4725 has only 1 channel")
+	assert.EqualError(t, m.SetVoltages([]float64{1, 2}), "got 2 voltages, but MCP4725 has only 1 channel")
+}
+
+func TestMCP4725OutputCode(t *testing.T) {
+	var in []byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		copy(r, in)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	m, _ := NewMCP4725(conn, 4.095)
+
+	tests := []struct {
+		in      []byte
+		code    int
+		mode    PowerDownMode
+		voltage float64
+	}{
+		// The EEPROM contains something else, it's ignored.
+		{[]byte{0xc0, 0xab, 0xc0, 0x6f, 0xff}, 0xabc, Normal, 2.748},
+		{[]byte{0xc0, 0xff, 0xf0, 0x00, 0x00}, 4095, Normal, 4.095},
+		{[]byte{0xc2, 0x80, 0x00, 0x08, 0x00}, 2048, PowerDown1k, 0},
+		{[]byte{0xc4, 0x80, 0x00, 0x08, 0x00}, 2048, PowerDown100k, 0},
+		{[]byte{0x46, 0x00, 0x10, 0x00, 0x00}, 1, PowerDown500k, 0},
+	}
+
+	for _, test := range tests {
+		in = test.in
+
+		code, mode, err := m.OutputCode()
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+		assert.Equal(t, test.mode, mode)
+
+		mode, err = m.Mode()
+		assert.Nil(t, err)
+		assert.Equal(t, test.mode, mode)
+
+		v, err := m.Voltage()
+		assert.Nil(t, err)
+		assert.InDelta(t, test.voltage, v, 1e-9)
+	}
+
+	c.TxFunc(func(w, r []byte) error {
+		return errors.New("some error")
+	})
+
+	_, err := m.Voltage()
+	assert.EqualError(t, err, "failed to read DAC register: some error")
+	assert.True(t, errors.Is(err, dac.ErrBusFailure))
+
+	_, err = m.Mode()
+	assert.NotNil(t, err)
+}
+
+func TestMCP4725SelfTest(t *testing.T) {
+	assert.Implements(t, (*dac.SelfTester)(nil), new(MCP4725))
+
+	var written []byte
+	in := []byte{0xc0, 0x80, 0x00, 0x08, 0x00}
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if len(w) > 0 {
+			written = w
+		}
+		copy(r, in)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	m, _ := NewMCP4725(conn, 4.095)
+
+	assert.Nil(t, dac.SelfTest(m))
+	assert.Equal(t, []byte{0x08, 0x00}, written)
+
+	in = []byte{0xc0, 0x00, 0x00, 0x08, 0x00}
+	err := m.SelfTest()
+	assert.EqualError(t, err, "DAC reads back code 0 in mode 0, but 2048 has been written in normal mode")
+	assert.True(t, errors.Is(err, dac.ErrSelfTestFailed))
+
+	in = []byte{0xc2, 0x80, 0x00, 0x08, 0x00}
+	err = m.SelfTest()
+	assert.EqualError(t, err, "DAC reads back code 2048 in mode 1, but 2048 has been written in normal mode")
+
+	c.TxFunc(func(w, r []byte) error {
+		return errors.New("some error")
+	})
+	assert.EqualError(t, m.SelfTest(), "failed to write output code 2048: some error")
+}
@@ -0,0 +1,60 @@
+// Package probe finds the devices on an I2C bus, like i2cdetect does.
+package probe
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/io/i2c"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// The range of 7 bits addresses that can be used by devices. The other
+// addresses are reserved by the I2C specification.
+const (
+	minAddr = 0x03
+	maxAddr = 0x77
+)
+
+// ProbeI2C probes the addresses on the I2C bus dev, like /dev/i2c-1, and
+// returns the addresses that respond. An address is probed by reading a byte
+// from it, a device that acknowledges the read is present. Addresses that are
+// in use by a kernel driver can't be probed, they're skipped.
+//
+// Note that reading a byte might change the state of some devices, like
+// devices that respond to a read by starting a conversion. Only probe the
+// addresses of devices that tolerate it.
+func ProbeI2C(dev string, addrs []int) ([]int, error) {
+	return probe(&i2c.Devfs{Dev: dev}, addrs)
+}
+
+func probe(o driver.Opener, addrs []int) ([]int, error) {
+	for _, addr := range addrs {
+		if addr < minAddr || addr > maxAddr {
+			return nil, fmt.Errorf("address 0x%02x is invalid, it must be between 0x%02x and 0x%02x", addr, minAddr, maxAddr)
+		}
+	}
+
+	var found []int
+	for _, addr := range addrs {
+		d, err := i2c.Open(o, addr)
+		if err != nil {
+			// The address is used by a kernel driver, i2cdetect
+			// reports these addresses as UU.
+			if strings.Contains(err.Error(), "device or resource busy") {
+				continue
+			}
+			return nil, fmt.Errorf("failed to open address 0x%02x: %w", addr, err)
+		}
+
+		// A device that doesn't acknowledge its address makes the read
+		// fail.
+		err = d.Read(make([]byte, 1))
+		d.Close()
+		if err == nil {
+			found = append(found, addr)
+		}
+	}
+
+	return found, nil
+}
@@ -0,0 +1,76 @@
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/io/i2c/driver"
+)
+
+// testBus is a driver.Opener of a bus with devices at the given addresses.
+type testBus struct {
+	devices map[int]bool
+	// busy contains the addresses used by a kernel driver.
+	busy map[int]bool
+	err  error
+	// closed counts the connections that have been closed.
+	closed int
+}
+
+func (b *testBus) Open(addr int, _ bool) (driver.Conn, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.busy[addr] {
+		return nil, fmt.Errorf("error opening the address (%v) on the bus (/dev/i2c-1): device or resource busy", addr)
+	}
+	return testConn{bus: b, addr: addr}, nil
+}
+
+type testConn struct {
+	bus  *testBus
+	addr int
+}
+
+func (c testConn) Tx(w, r []byte) error {
+	if !c.bus.devices[c.addr] {
+		return errors.New("no such device or address")
+	}
+	return nil
+}
+
+func (c testConn) Close() error {
+	c.bus.closed++
+	return nil
+}
+
+func TestProbe(t *testing.T) {
+	b := &testBus{
+		devices: map[int]bool{0x48: true, 0x4a: true, 0x60: true},
+		busy:    map[int]bool{0x50: true},
+	}
+
+	found, err := probe(b, []int{0x48, 0x49, 0x4a, 0x4b, 0x50, 0x60})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0x48, 0x4a, 0x60}, found)
+	assert.Equal(t, 5, b.closed)
+
+	found, err = probe(b, []int{0x03, 0x77})
+	assert.Nil(t, err)
+	assert.Nil(t, found)
+}
+
+func TestProbeErrors(t *testing.T) {
+	b := &testBus{devices: map[int]bool{0x48: true}}
+
+	for _, addr := range []int{0x02, 0x78, -1} {
+		_, err := probe(b, []int{0x48, addr})
+		assert.EqualError(t, err, fmt.Sprintf("address 0x%02x is invalid, it must be between 0x03 and 0x77", addr))
+	}
+
+	b.err = errors.New("open /dev/i2c-9: no such file or directory")
+	_, err := probe(b, []int{0x48})
+	assert.EqualError(t, err, "failed to open address 0x48: open /dev/i2c-9: no such file or directory")
+}
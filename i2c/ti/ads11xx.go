@@ -2,10 +2,88 @@
 package ti
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
+	"sync"
+	"time"
 
-	"golang.org/x/exp/io/i2c"
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/bus"
+)
+
+var (
+	_ adc.Sized = (*ADS1100)(nil)
+	_ adc.Sized = (*ADS1110)(nil)
+	_ adc.Sized = (*ADS1112)(nil)
+)
+
+const (
+	// configST starts a conversion in single conversion mode when written to
+	// the config register. When read, it's 0 once the conversion has
+	// finished.
+	configST = 0x80
+
+	// configSC selects single conversion mode. The ADC converts
+	// continuously when it's 0.
+	configSC = 0x10
+
+	// configINP are the bits that select the input of the ADS1112. They
+	// are reserved on the ADS1100 and ADS1110.
+	configINP = 0x60
+)
+
+// ADS11xxOption is an option of NewADS1100 and NewADS1110.
+type ADS11xxOption int
+
+const (
+	// RunSelfTest makes the constructor run SelfTest after writing the
+	// config, so it fails fast when the device at the address isn't the
+	// expected ADC.
+	RunSelfTest ADS11xxOption = iota + 1
+)
+
+// ConversionMode is the conversion mode of an ADS1100 or ADS1110.
+type ConversionMode int
+
+const (
+	// ContinuousConversion makes the ADC convert continuously. Reading the
+	// output code returns the result of the last conversion. This is the
+	// default mode of the ADC.
+	ContinuousConversion ConversionMode = iota
+
+	// SingleConversion makes the ADC power down after a conversion until
+	// the next conversion is started.
+	SingleConversion
+)
+
+var (
+	// ErrStaleReading is returned by ReadFresh when the output code has
+	// been read already.
+	ErrStaleReading = errors.New("output code has been read already")
+
+	// ErrConversionBusy is returned by ReadFresh when the conversion
+	// started in single conversion mode hasn't finished yet.
+	ErrConversionBusy = errors.New("conversion hasn't finished yet")
+)
+
+// ErrSaturated is returned by Voltage when FlagSaturated is set and the output
+// code is the maximum or minimum output code. The input is at or beyond the
+// range of the ADC, so the voltage is clipped.
+type ErrSaturated struct {
+	// Voltage is the clipped voltage.
+	Voltage float64
+}
+
+func (e ErrSaturated) Error() string {
+	return fmt.Sprintf("voltage of %vV is saturated, the input is out of range", e.Voltage)
+}
+
+// streamNow and streamAfter are the clock of Stream.
+var (
+	streamNow   = time.Now
+	streamAfter = time.After
 )
 
 type dataRate struct {
@@ -19,89 +97,611 @@ type dataRate struct {
 }
 
 type ads11xx struct {
-	Conn *i2c.Device
+	Conn bus.I2C
 	Vref float64
 
-	dataRate dataRate
-	pga      int
+	// Locker, when set, is held during every transaction on Conn.
+	// Devices that share an I2C bus can share a Locker, so their
+	// transactions don't interleave. The methods of the ADC don't need it
+	// to be safe for concurrent use, a mutex of the ADC itself serializes
+	// them.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
+	// FullScale selects how an output code is converted to a voltage. It
+	// defaults to adc.CountBased, see adc.FullScaleMode. The output code
+	// is signed, so n is the number of bits of the data rate minus 1.
+	FullScale adc.FullScaleMode
+
+	// Samples is the number of conversions used for a single output code.
+	// The output codes of these conversions are reduced to a single code
+	// using Reduce. 0 and 1 both result in a single conversion. The ADC
+	// converts continuously, so sampling waits a conversion period between
+	// 2 samples.
+	Samples int
+
+	// Reduce reduces the output codes of the conversions. It defaults to
+	// adc.Mean, use adc.Median to reject spikes.
+	Reduce adc.Reducer
+
+	// Retries is the number of times a failed read is retried before
+	// giving up. Backoff is the time waited between 2 attempts, see
+	// bus.Retry.
+	Retries int
+	Backoff time.Duration
+
+	// Wait makes OutputCode wait until the conversion started by Trigger
+	// has finished, so it returns the output code of that conversion. In
+	// single conversion mode OutputCode starts a conversion itself when
+	// Wait isn't set.
+	Wait bool
+
+	// FlagSaturated makes Voltage return an ErrSaturated when the output
+	// code is saturated, see ReadWithFlags.
+	FlagSaturated bool
+
+	// m is held by every method that talks to the ADC or uses the cached
+	// config, so a setter can't tear the config written by another one,
+	// nor change the PGA or data rate while a conversion is read. It
+	// guards dataRate, pga, singleShot, started and lastFresh. Locker is
+	// only taken while holding m, never the other way around.
+	m          *sync.Mutex
+	dataRate   dataRate
+	pga        int
+	singleShot bool
+
+	// drdy is true when bit 7 of the config register reads as DRDY, like
+	// on the ADS1110. Else it reads as BSY, like on the ADS1100.
+	drdy bool
+
+	// started is true when a conversion has been started since ReadFresh
+	// read the last one.
+	started bool
+
+	// lastFresh is the time ReadFresh read a new output code.
+	lastFresh time.Time
 
 	// dataRates is a map that holds all valid values for data rate.
 	dataRates []dataRate
+
+	// name is the name of the ADC, like ADS1100.
+	name string
+
+	// channels is the number of channels. mux returns the INP bits of the
+	// config register that select a channel, it's nil for ADCs with a
+	// single input. inp holds the INP bits of the cached config.
+	channels int
+	mux      func(channel int) byte
+	inp      byte
 }
 
-func newADS11xx(conn *i2c.Device, vref float64, dataRate, pga int, dataRates []dataRate) (ads11xx, error) {
+func newADS11xx(name string, conn bus.I2C, vref float64, dataRate, pga int, dataRates []dataRate, mux func(int) byte, channels int, opts []ADS11xxOption) (ads11xx, error) {
 	a := ads11xx{
 		Conn:      conn,
 		Vref:      vref,
 		dataRates: dataRates,
+		m:         new(sync.Mutex),
+		name:      name,
+		mux:       mux,
+		channels:  channels,
 	}
 
 	if err := a.setDataRate(dataRate); err != nil {
 		return a, err
 	}
 
-	if err := a.SetPGA(pga); err != nil {
+	bits, err := pgaBits(pga)
+	if err != nil {
 		return a, err
 	}
+	a.pga = bits
+
+	// The ADC might have been configured before, so every bit of the
+	// config register is written.
+	if err := a.WriteConfig(a.config()); err != nil {
+		return a, err
+	}
+
+	for _, opt := range opts {
+		if opt == RunSelfTest {
+			if err := a.SelfTest(); err != nil {
+				return a, err
+			}
+		}
+	}
 
 	return a, nil
 }
 
-// Voltage queries the channel of an ADC and returns its voltage.
-func (a ads11xx) Voltage(channel int) (float64, error) {
-	code, err := a.OutputCode(channel)
+// Channels returns the number of channels. The ADS1100 and ADS1110 have only
+// channel 0, the ADS1112 has 4.
+func (a *ads11xx) Channels() int {
+	return a.channels
+}
+
+// checkChannel returns an error when the ADC doesn't have the channel. On an
+// ADC with a single channel, channel 1 is accepted as well, it was the only
+// valid channel before channels became 0-based for all ADCs.
+func (a *ads11xx) checkChannel(channel int) error {
+	if a.channels == 1 && channel == 1 {
+		return nil
+	}
+	if channel < 0 || channel >= a.channels {
+		return adc.ErrInvalidChannel{Channel: channel, Channels: a.channels}
+	}
+	return nil
+}
+
+// Voltage queries the channel of an ADC and returns its voltage. The voltage
+// is computed with the PGA and data rate the output code has been read with.
+func (a *ads11xx) Voltage(channel int) (float64, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	code, err := a.outputCode(channel)
 	if err != nil {
 		return 0, err
 	}
 
-	max := math.Pow(2, float64(a.dataRate.size))
-	return ((a.Vref / max) * float64(code) / float64(a.pga)), nil
+	v := float64(code) * a.resolution()
+	if a.FlagSaturated && a.saturated(code) {
+		return 0, ErrSaturated{Voltage: v}
+	}
+	return v, nil
+}
+
+// ReadWithFlags is like OutputCode, but it also reports whether the output
+// code is saturated: the input is at or beyond the full scale of the selected
+// data rate and PGA, so the output code is clipped to its maximum or minimum.
+// Widen the range by lowering the PGA, or treat the reading as a fault.
+func (a *ads11xx) ReadWithFlags(channel int) (code int, saturated bool, err error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	code, err = a.outputCode(channel)
+	if err != nil {
+		return 0, false, err
+	}
+	return code, a.saturated(code), nil
+}
+
+// saturated returns whether code is the maximum or minimum output code of the
+// cached data rate.
+func (a *ads11xx) saturated(code int) bool {
+	max := 1<<(a.dataRate.size-1) - 1
+	return code >= max || code <= -max-1
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (a *ads11xx) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, a, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (a *ads11xx) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, a, channel)
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure. It depends on the
+// selected data rate and PGA.
+func (a *ads11xx) Resolution() float64 {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.resolution()
+}
+
+// resolution is Resolution without locking.
+func (a *ads11xx) resolution() float64 {
+	max := a.FullScale.Denominator(int(a.dataRate.size) - 1)
+	return a.Vref / max / float64(a.gain())
+}
+
+// Range returns the lowest and the highest voltage Voltage can return with the
+// current data rate and PGA. The output code is signed, so min is negative.
+// The range shrinks with the gain: a PGA of 8 with a Vref of 5V measures from
+// -0.625V up to 0.625V minus a step of the output code.
+func (a *ads11xx) Range() (min, max float64) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	codes := float64(int(1) << (a.dataRate.size - 1))
+	return -codes * a.resolution(), (codes - 1) * a.resolution()
 }
 
-// OutputCode queries the channel and returns its digital output code. The
+// Gain returns the gain of the PGA: 1, 2, 4 or 8. It's the gain cached by the
+// driver, PGA reads it from the ADC.
+//
+// OutputCode returns the output code as is, the gain isn't applied. Voltage,
+// Resolution and FullScaleVoltage divide by the gain.
+func (a *ads11xx) Gain() int {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.gain()
+}
+
+// gain is Gain without locking.
+func (a *ads11xx) gain() int {
+	// The config register, and so pga, holds the log2 of the gain.
+	return 1 << uint(a.pga)
+}
+
+// FullScaleVoltage returns the highest voltage the ADC can measure with the
+// current gain, that is Vref divided by the gain. The lowest voltage is minus
+// the full scale voltage.
+func (a *ads11xx) FullScaleVoltage() float64 {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.Vref / float64(a.gain())
+}
+
+// OutputCode queries channel 0 and returns its digital output code. The
 // maximum code depends on the selected data rate.  The higher the data rate,
-// the lower the number of bits used.
-func (a ads11xx) OutputCode(channel int) (int, error) {
-	if channel != 1 {
-		return 0, fmt.Errorf("channel %d is invalid, ADC has only 1 channel", channel)
+// the lower the number of bits used. The output code is negative when the
+// voltage on the negative input is higher than on the positive input.
+//
+// In single conversion mode OutputCode starts a conversion and waits until
+// it has finished.
+func (a *ads11xx) OutputCode(channel int) (int, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.outputCode(channel)
+}
+
+// outputCode is OutputCode without locking.
+func (a *ads11xx) outputCode(channel int) (int, error) {
+	if err := a.checkChannel(channel); err != nil {
+		return 0, err
 	}
 
-	in := make([]byte, 2)
-	if err := a.Conn.Read(in); err != nil {
-		return 0, fmt.Errorf("failed to read output code: %v", err)
+	switched, err := a.selectChannel(channel)
+	if err != nil {
+		return 0, err
 	}
 
-	msb := in[0] & byte(math.Pow(2, float64(a.dataRate.size-8))-1)
-	v := (int(msb) << 8) + int(in[1])
+	reads := 0
+	return adc.Oversample(a.Samples, a.Reduce, func() (int, error) {
+		if reads > 0 {
+			if err := a.next(); err != nil {
+				return 0, err
+			}
+		} else if a.singleShot && !a.Wait && !switched {
+			if err := a.startConversion(); err != nil {
+				return 0, err
+			}
+		}
+		reads++
 
-	return v, nil
+		// The first conversion after switching channels is the
+		// first one of the new channel.
+		read := a.read
+		if a.Wait || a.singleShot || switched && reads == 1 {
+			read = a.waitForConversion
+		}
+
+		var code int
+		err := bus.Retry(func() (err error) {
+			code, err = read()
+			return err
+		}, a.Retries+1, a.Backoff)
+		return code, err
+	})
+}
+
+// Stream reads channel 0 every conversion period, that is 1 / data rate
+// seconds, and sends the samples on the returned channel. The period follows
+// the data rate, so changing it with SetDataRate re-paces the stream. The
+// channel is closed once ctx is done.
+//
+// The samples are read with OutputCode, so Samples, Wait and the conversion
+// mode apply. A failed read is sent as a sample with Err set and doesn't stop
+// the stream. Stream waits for a slow consumer, so it falls behind rather
+// than dropping samples. An error is returned when ctx is done already.
+func (a *ads11xx) Stream(ctx context.Context) (<-chan adc.Sample, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c := make(chan adc.Sample)
+	go func() {
+		defer close(c)
+
+		next := streamNow()
+		for {
+			s := adc.Sample{Time: streamNow()}
+			a.m.Lock()
+			s.Code, s.Err = a.outputCode(0)
+			if s.Err == nil {
+				s.Voltage = float64(s.Code) * a.resolution()
+			} else {
+				s.Code = 0
+			}
+			a.m.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case c <- s:
+			}
+
+			next = next.Add(a.period())
+			select {
+			case <-ctx.Done():
+				return
+			case <-streamAfter(next.Sub(streamNow())):
+			}
+		}
+	}()
+
+	return c, nil
 }
 
-// PGA reads the config register of the ADC and returns the current PGA.
+// selectChannel writes the INP bits of the channel to the config register when
+// another channel is selected. In single conversion mode that starts a
+// conversion of the channel as well. It returns whether the channel has been
+// switched. The caller must hold a.m.
+func (a *ads11xx) selectChannel(channel int) (bool, error) {
+	if a.mux == nil || a.mux(channel) == a.inp {
+		return false, nil
+	}
+
+	v := a.config()&^configINP | a.mux(channel)
+	if a.singleShot {
+		v |= configST
+	}
+	if err := a.writeConfig(v); err != nil {
+		return false, fmt.Errorf("failed to select channel %d: %w", channel, err)
+	}
+	return true, nil
+}
+
+// period returns the conversion period of the cached data rate.
+func (a *ads11xx) period() time.Duration {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return time.Second / time.Duration(a.dataRate.sps)
+}
+
+// next makes sure the next read returns a new conversion, otherwise the same
+// conversion is read again. With Wait or in single conversion mode a new
+// conversion is triggered, else it waits for the next conversion of continuous
+// conversion mode. The caller must hold a.m.
+func (a *ads11xx) next() error {
+	if a.Wait || a.singleShot {
+		return a.trigger()
+	}
+
+	time.Sleep(time.Second / time.Duration(a.dataRate.sps))
+	return nil
+}
+
+// Trigger puts the ADC in single conversion mode and starts a conversion.
+// After the conversion the ADC powers down until the next trigger. Set Wait
+// to make OutputCode wait for the conversion to finish.
+//
+// A conversion takes 1 / data rate seconds, so SetDataRate trades the
+// resolution of the output code for conversion time. SetDataRate and SetPGA
+// keep the ADC in single conversion mode, but they don't start a conversion.
+//
+// Trigger is SetConversionMode(SingleConversion) and StartConversion in a
+// single write.
+func (a *ads11xx) Trigger() error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.trigger()
+}
+
+// trigger is Trigger without locking.
+func (a *ads11xx) trigger() error {
+	if err := a.writeConfig(a.config() | configSC | configST); err != nil {
+		return fmt.Errorf("failed to start conversion: %w", err)
+	}
+	a.started = true
+	return nil
+}
+
+// SetConversionMode writes the conversion mode to the ADC. In single
+// conversion mode OutputCode starts a conversion for every sample and waits
+// until it has finished, use StartConversion to start a conversion without
+// reading it.
+func (a *ads11xx) SetConversionMode(m ConversionMode) error {
+	switch m {
+	case ContinuousConversion:
+		return a.setConfig(configSC, 0)
+	case SingleConversion:
+		return a.setConfig(configSC, configSC)
+	default:
+		return fmt.Errorf("conversion mode %d is invalid", m)
+	}
+}
+
+// StartConversion starts a conversion. The ADC must be in single conversion
+// mode, in continuous conversion mode it converts all the time.
+func (a *ads11xx) StartConversion() error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.startConversion()
+}
+
+// startConversion is StartConversion without locking.
+func (a *ads11xx) startConversion() error {
+	if !a.singleShot {
+		return errors.New("failed to start conversion: ADC isn't in single conversion mode")
+	}
+
+	if err := a.writeConfig(a.config() | configST); err != nil {
+		return fmt.Errorf("failed to start conversion: %w", err)
+	}
+	a.started = true
+	return nil
+}
+
+// read reads the output code of the last conversion.
+func (a *ads11xx) read() (int, error) {
+	code, _, err := a.readWithConfig()
+	return code, err
+}
+
+// readWithConfig reads the output code of the last conversion together with
+// the config register.
+func (a *ads11xx) readWithConfig() (int, byte, error) {
+	in := make([]byte, 3)
+	if err := a.tx(func(conn bus.I2C) error { return conn.Read(in) }); err != nil {
+		return 0, 0, fmt.Errorf("failed to read output code: %w", bus.Error{Err: err})
+	}
+
+	return a.decode(in), in[2], nil
+}
+
+// ReadFresh is like OutputCode, but it returns ErrStaleReading when the output
+// code has been read before. Reading faster than the data rate returns the
+// same output code. Samples is ignored and no conversion is started.
+//
+// The ADS1110 sets its DRDY bit once the output code has been read, the next
+// conversion clears it. The ADS1100 has a BSY bit instead, which is only set
+// while a conversion started in single conversion mode is busy. ReadFresh
+// returns ErrConversionBusy then. A finished conversion is fresh when it has
+// been started by StartConversion or Trigger after the last ReadFresh. The
+// ADS1100 can't tell whether an output code is fresh in continuous conversion
+// mode, ReadFresh returns an error then.
+//
+// ReadFresh doesn't wait for a new conversion, retry it after 1 / data rate
+// seconds when it returns ErrStaleReading or ErrConversionBusy.
+func (a *ads11xx) ReadFresh(channel int) (int, error) {
+	if err := a.checkChannel(channel); err != nil {
+		return 0, err
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.mux != nil && a.mux(channel) != a.inp {
+		return 0, fmt.Errorf("channel %d isn't selected, read it using OutputCode first", channel)
+	}
+
+	var code int
+	var config byte
+	err := bus.Retry(func() (err error) {
+		code, config, err = a.readWithConfig()
+		return err
+	}, a.Retries+1, a.Backoff)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case a.drdy && config&configST != 0:
+		return 0, ErrStaleReading
+	case a.drdy:
+	case config&configSC == 0:
+		return 0, errors.New("the ADS1100 can't tell whether an output code is fresh in continuous conversion mode")
+	case config&configST != 0:
+		return 0, ErrConversionBusy
+	case !a.started:
+		return 0, ErrStaleReading
+	}
+
+	a.started = false
+	a.lastFresh = time.Now()
+	return code, nil
+}
+
+// LastConversionAge returns the time since ReadFresh read a new output code. It
+// returns 0 when ReadFresh hasn't read an output code yet.
+func (a *ads11xx) LastConversionAge() time.Duration {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.lastFresh.IsZero() {
+		return 0
+	}
+	return time.Since(a.lastFresh)
+}
+
+// waitForConversion waits until the conversion started by Trigger has
+// finished and returns its output code.
+func (a *ads11xx) waitForConversion() (int, error) {
+	// A conversion takes 1 / data rate seconds, but the internal
+	// oscillator of the ADC might be a bit slower than specified.
+	d := time.Second / time.Duration(a.dataRate.sps)
+	time.Sleep(d)
+
+	// The output code is read together with the config register.
+	in := make([]byte, 3)
+	for i := 0; i < 10; i++ {
+		if err := a.tx(func(conn bus.I2C) error { return conn.Read(in) }); err != nil {
+			return 0, fmt.Errorf("failed to read status of conversion: %w", bus.Error{Err: err})
+		}
+
+		if in[2]&configST == 0 {
+			return a.decode(in), nil
+		}
+		time.Sleep(d / 10)
+	}
+
+	return 0, fmt.Errorf("conversion didn't finish within %v", 2*d)
+}
+
+// decode returns the output code in the first 2 bytes of in. The ADC measures
+// the difference between its inputs, so the output code is a two's complement
+// value with the number of bits of the data rate.
+func (a *ads11xx) decode(in []byte) int {
+	size := a.dataRate.size
+	code := (int(in[0])<<8 | int(in[1])) & (1<<size - 1)
+	if code >= 1<<(size-1) {
+		code -= 1 << size
+	}
+	return code
+}
+
+// PGA reads the config register of the ADC and returns the current PGA. The
+// settings read are cached, like those read by SetPGA, so Gain returns the PGA
+// of the ADC even if another master changed it.
 func (a *ads11xx) PGA() (int, error) {
-	data, err := a.config()
+	data, err := a.refreshConfig()
 	if err != nil {
 		return 0, err
 	}
 
-	return int(math.Pow(2, float64(data&0x3))), nil
+	return 1 << (data & 0x3), nil
 }
 
 // SetPGA writes the value for the Programmable Gain Amplifier to the ADC.
 // Valid values are 1, 2, 4 and 8.
 func (a *ads11xx) SetPGA(v int) error {
+	bits, err := pgaBits(v)
+	if err != nil {
+		return err
+	}
+
+	return a.setConfig(0x03, byte(bits))
+}
+
+// pgaBits returns the value of the bits in the config register that select
+// PGA v.
+func pgaBits(v int) (int, error) {
 	if v == 1 || v == 2 || v == 4 || v == 8 {
-		a.pga = int(math.Log2(float64(v)))
-		return a.setConfig()
+		return int(math.Log2(float64(v))), nil
 	}
 
-	return fmt.Errorf("PGA of %d is invalid, choose 1, 2, 4 or 8", v)
+	return 0, fmt.Errorf("PGA of %d is invalid, choose 1, 2, 4 or 8", v)
 }
 
 // DataRate reads the config register of the ADC returns the current value of
-// the data rate.
+// the data rate. The settings read are cached, like PGA does.
 func (a *ads11xx) DataRate() (int, error) {
-	data, err := a.config()
+	data, err := a.refreshConfig()
 	if err != nil {
 		return 0, err
 	}
@@ -119,42 +719,54 @@ func (a *ads11xx) DataRate() (int, error) {
 
 // SetDataRate writes the value for the data rate to the ADC.
 func (a *ads11xx) SetDataRate(r int) error {
-	if err := a.setDataRate(r); err != nil {
+	rate, err := a.findDataRate(r)
+	if err != nil {
 		return err
 	}
 
-	return a.setConfig()
+	return a.setConfig(0x0c, byte(rate.bitMask<<2))
 }
 
 func (a *ads11xx) setDataRate(sps int) error {
-	var ok bool
-	var dataRate dataRate
+	rate, err := a.findDataRate(sps)
+	if err != nil {
+		return err
+	}
+	a.dataRate = rate
 
+	return nil
+}
+
+// findDataRate returns the data rate of sps samples per second.
+func (a *ads11xx) findDataRate(sps int) (dataRate, error) {
 	for _, rate := range a.dataRates {
 		if rate.sps == sps {
-			dataRate = rate
-			ok = true
-			break
+			return rate, nil
 		}
 	}
 
-	if !ok {
-		var rates []int
-		for _, rate := range a.dataRates {
-			rates = append(rates, rate.sps)
-		}
-		return fmt.Errorf("%d is an invalid value for data rate, use on of %v", sps, rates)
+	var rates []int
+	for _, rate := range a.dataRates {
+		rates = append(rates, rate.sps)
 	}
-	a.dataRate = dataRate
+	return dataRate{}, fmt.Errorf("%d is an invalid value for data rate, use on of %v", sps, rates)
+}
 
-	return nil
+// ReadConfig reads the config register of the ADC and returns its value. Bit
+// 7 is the ST/DRDY bit, bit 4 selects single conversion mode, bits 3:2 select
+// the data rate and bits 1:0 the PGA.
+func (a *ads11xx) ReadConfig() (byte, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.readConfig()
 }
 
-// config reads the config register of the ADC and returns its value.
-func (a *ads11xx) config() (byte, error) {
+// readConfig is ReadConfig without locking.
+func (a *ads11xx) readConfig() (byte, error) {
 	in := make([]byte, 3)
-	if err := a.Conn.Read(in); err != nil {
-		return 0, err
+	if err := a.tx(func(conn bus.I2C) error { return conn.Read(in) }); err != nil {
+		return 0, fmt.Errorf("failed to read config register: %w", bus.Error{Err: err})
 	}
 
 	// The first 2 bytes contain the output code, those are ignored. The
@@ -162,21 +774,135 @@ func (a *ads11xx) config() (byte, error) {
 	return in[2], nil
 }
 
-// setConfig writes the settings for the data rate and PGA to the config
-// register.
-func (a *ads11xx) setConfig() error {
-	out := []byte{byte(a.dataRate.bitMask<<2 | a.pga)}
-	return a.Conn.Write(out)
+// WriteConfig writes v to the config register of the ADC. The value isn't
+// validated, use SetPGA and SetDataRate for that. The data rate and PGA in v
+// are used to compute voltages from now on.
+func (a *ads11xx) WriteConfig(v byte) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.writeConfig(v)
+}
+
+// writeConfig is WriteConfig without locking.
+func (a *ads11xx) writeConfig(v byte) error {
+	if err := a.tx(func(conn bus.I2C) error { return conn.Write([]byte{v}) }); err != nil {
+		return fmt.Errorf("failed to write config register: %w", bus.Error{Err: err})
+	}
+
+	a.cacheConfig(v)
+	return nil
+}
+
+// SelfTest writes the cached config to the ADC and reads it back. An error is
+// returned when the conversion mode, data rate or PGA read back differ from
+// those written, which means the device at the address of Conn likely isn't
+// this ADC. The ST bit isn't compared and SelfTest doesn't start a
+// conversion.
+func (a *ads11xx) SelfTest() error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	expected := a.config()
+	if err := a.writeConfig(expected); err != nil {
+		return err
+	}
+
+	v, err := a.readConfig()
+	if err != nil {
+		return err
+	}
+
+	// Bit 7 is the ST bit. Bits 6:5 are reserved, except on the ADS1112.
+	mask := byte(0x1f)
+	if a.mux != nil {
+		mask |= configINP
+	}
+	if v&mask != expected {
+		return fmt.Errorf("expected config 0x%02x, read 0x%02x, is the device at this address an %s?", expected, v&mask, a.name)
+	}
+	return nil
+}
+
+// refreshConfig reads the config register and caches its settings.
+func (a *ads11xx) refreshConfig() (byte, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	v, err := a.readConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	a.cacheConfig(v)
+	return v, nil
+}
+
+// cacheConfig caches the conversion mode, data rate and PGA of config v. The
+// caller must hold a.m.
+func (a *ads11xx) cacheConfig(v byte) {
+	// Every value of the 2 bits selecting the data rate is valid.
+	for _, rate := range a.dataRates {
+		if rate.bitMask == int(v&0xc)>>2 {
+			a.dataRate = rate
+		}
+	}
+	a.pga = int(v & 0x3)
+	a.singleShot = v&configSC != 0
+	if a.mux != nil {
+		a.inp = v & configINP
+	}
+}
+
+// setConfig reads the config register and writes it back with the bits in
+// mask replaced by those of v, so the other settings are preserved. The ST bit
+// is always cleared, writing it would start a conversion. The settings read
+// are cached, including those changed by another master.
+func (a *ads11xx) setConfig(mask, v byte) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	config, err := a.readConfig()
+	if err != nil {
+		return err
+	}
+
+	return a.writeConfig(config&^(mask|configST) | v&mask)
+}
+
+// tx calls f with the connection to the ADC while holding Locker, when set.
+func (a *ads11xx) tx(f func(conn bus.I2C) error) error {
+	if a.Locker != nil {
+		a.Locker.Lock()
+		defer a.Locker.Unlock()
+	}
+	return f(bus.LogI2C(a.Conn, a.Logger))
+}
+
+// config returns the value of the config register for the conversion mode,
+// data rate and PGA.
+func (a *ads11xx) config() byte {
+	v := a.inp | byte(a.dataRate.bitMask<<2|a.pga)
+	if a.singleShot {
+		v |= configSC
+	}
+	return v
 }
 
 // ADS1100 is a 16-bit ADC. It's PGA can be set to 1, 2, 4 or 8. Allowed
 // values for the data rate are 8, 16, 32 or 128 SPS.
+//
+// The methods of an ADS1100 and ADS1110 are safe for concurrent use. A call
+// waits until the calls of other goroutines have finished, so a SetPGA never
+// tears the config written by another setter or changes the PGA while
+// Voltage reads a conversion.
 type ADS1100 struct {
 	ads11xx
 }
 
-// NewADS1100 returns an ADS1100.
-func NewADS1100(conn *i2c.Device, vref float64, rate, pga int) (*ADS1100, error) {
+// NewADS1100 returns an ADS1100. Pass RunSelfTest to verify that the device
+// reads back the config written.
+func NewADS1100(conn bus.I2C, vref float64, rate, pga int, opts ...ADS11xxOption) (*ADS1100, error) {
 	dataRates := []dataRate{
 		dataRate{sps: 128, bitMask: 0x0, size: 12},
 		dataRate{sps: 32, bitMask: 0x1, size: 14},
@@ -184,9 +910,9 @@ func NewADS1100(conn *i2c.Device, vref float64, rate, pga int) (*ADS1100, error)
 		dataRate{sps: 8, bitMask: 0x3, size: 16},
 	}
 
-	inner, err := newADS11xx(conn, vref, rate, pga, dataRates)
+	inner, err := newADS11xx("ADS1100", conn, vref, rate, pga, dataRates, nil, 1, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create ADS1100: %v", err)
+		return nil, fmt.Errorf("failed to create ADS1100: %w", err)
 	}
 	return &ADS1100{
 		inner,
@@ -200,8 +926,9 @@ type ADS1110 struct {
 	ads11xx
 }
 
-// NewADS1110 returns an ADS1110.
-func NewADS1110(conn *i2c.Device, rate, pga int) (*ADS1110, error) {
+// NewADS1110 returns an ADS1110. Pass RunSelfTest to verify that the device
+// reads back the config written.
+func NewADS1110(conn bus.I2C, rate, pga int, opts ...ADS11xxOption) (*ADS1110, error) {
 	dataRates := []dataRate{
 		dataRate{sps: 240, bitMask: 0x0, size: 12},
 		dataRate{sps: 60, bitMask: 0x1, size: 14},
@@ -209,13 +936,62 @@ func NewADS1110(conn *i2c.Device, rate, pga int) (*ADS1110, error) {
 		dataRate{sps: 15, bitMask: 0x3, size: 16},
 	}
 
-	inner, err := newADS11xx(conn, 2.048, rate, pga, dataRates)
+	inner, err := newADS11xx("ADS1110", conn, 2.048, rate, pga, dataRates, nil, 1, opts)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create ADS1110: %v", err)
+		return nil, fmt.Errorf("failed to create ADS1110: %w", err)
 	}
+	inner.drdy = true
 
 	return &ADS1110{
 		inner,
 	}, nil
 }
+
+// ADS1112 is the ADS1110 with an input multiplexer. Its channels are the pairs
+// of inputs AIN0PosAIN1Neg, AIN0PosAIN3Neg, AIN1PosAIN3Neg and AIN2PosAIN3Neg.
+// Used with AIN3 as common input, like GND, it has 3 single-ended inputs.
+// Otherwise it has 2 differential inputs, AIN0PosAIN1Neg and AIN2PosAIN3Neg.
+// The datasheet is here: http://www.ti.com/lit/ds/symlink/ads1112.pdf
+//
+// Reading another channel than the last one selects it first and waits for a
+// conversion of it.
+type ADS1112 struct {
+	ads11xx
+}
+
+// NewADS1112 returns an ADS1112 with channel AIN0PosAIN1Neg selected. Pass
+// RunSelfTest to verify that the device reads back the config written.
+func NewADS1112(conn bus.I2C, rate, pga int, opts ...ADS11xxOption) (*ADS1112, error) {
+	dataRates := []dataRate{
+		dataRate{sps: 240, bitMask: 0x0, size: 12},
+		dataRate{sps: 60, bitMask: 0x1, size: 14},
+		dataRate{sps: 30, bitMask: 0x2, size: 15},
+		dataRate{sps: 15, bitMask: 0x3, size: 16},
+	}
+
+	inner, err := newADS11xx("ADS1112", conn, 2.048, rate, pga, dataRates, ads1112Mux, 4, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ADS1112: %w", err)
+	}
+	inner.drdy = true
+
+	return &ADS1112{
+		inner,
+	}, nil
+}
+
+// ads1112Mux returns the INP bits of the config register of the ADS1112 that
+// select channel.
+func ads1112Mux(channel int) byte {
+	switch channel {
+	case AIN0PosAIN3Neg:
+		return 0x40
+	case AIN1PosAIN3Neg:
+		return 0x60
+	case AIN2PosAIN3Neg:
+		return 0x20
+	default:
+		return 0x00
+	}
+}
@@ -1,42 +1,57 @@
 package ti
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/advancedclimatesystems/io/adc"
 	"github.com/advancedclimatesystems/io/iotest"
 	"github.com/stretchr/testify/assert"
 
 	"golang.org/x/exp/io/i2c"
 )
 
-// TestADS11xxPGA tests if configuring the devices works as expected.
-func TestADS11xxPGA(t *testing.T) {
-	data := make(chan []byte, 1)
+// newADS11xxConn returns a connection to a fake ADS11xx. Its config register
+// holds the last value written, the written values are returned as well.
+func newADS11xxConn(config byte) (*i2c.Device, *[]byte) {
+	var writes []byte
 	c := iotest.NewI2CConn()
-	c.TxFunc(func(w, _ []byte) error {
-		data <- w
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			writes = append(writes, w...)
+			config = w[0]
+		}
+		if len(r) == 3 {
+			r[2] = config
+		}
 		return nil
 	})
 
-	conn, err := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	return conn, &writes
+}
+
+// TestADS11xxPGA tests if configuring the devices works as expected.
+func TestADS11xxPGA(t *testing.T) {
+	conn, writes := newADS11xxConn(0x8c)
 	a, err := NewADS1100(conn, 5.0, 128, 2)
+	assert.Nil(t, err)
 
 	// Test if config register is written correctly
-	assert.Equal(t, []byte{0x1}, <-data)
+	assert.Equal(t, []byte{0x1}, *writes)
 
 	// Test with invalig value for PGA.
 	assert.NotNil(t, a.SetPGA(18))
 
 	// Test with valid value for PGA.
+	*writes = nil
 	assert.Nil(t, a.SetPGA(8))
-	assert.Equal(t, []byte{0x3}, <-data)
-
-	c.TxFunc(func(_, r []byte) error {
-		copy(r, []byte{0x0, 0x0, 0x3})
-		return nil
-	})
+	assert.Equal(t, []byte{0x3}, *writes)
 
 	pga, err := a.PGA()
 	assert.Nil(t, err)
@@ -44,32 +59,351 @@ func TestADS11xxPGA(t *testing.T) {
 }
 
 func TestADS11xxDataRate(t *testing.T) {
-	data := make(chan []byte, 1)
+	conn, writes := newADS11xxConn(0x8c)
+	a, _ := NewADS1100(conn, 5.0, 128, 2)
+	assert.Equal(t, []byte{0x1}, *writes)
+
+	*writes = nil
+	assert.Nil(t, a.SetDataRate(32))
+	assert.Equal(t, []byte{0x5}, *writes)
+
+	// Test with invalid value for data rate.
+	assert.NotNil(t, a.SetDataRate(18))
+
+	d, err := a.DataRate()
+	assert.Nil(t, err)
+	assert.Equal(t, 32, d)
+}
+
+// TestADS11xxPreservesConfig tests that changing a setting doesn't change the
+// other settings in the config register.
+func TestADS11xxPreservesConfig(t *testing.T) {
+	conn, writes := newADS11xxConn(0x8c)
+	a, _ := NewADS1110(conn, 240, 1)
+
+	// Another process puts the ADC in single conversion mode while a
+	// conversion is busy. The ST bit isn't written back, that would start
+	// another conversion.
+	assert.Nil(t, a.Conn.Write([]byte{0x90}))
+	*writes = nil
+	assert.Nil(t, a.SetPGA(4))
+	assert.Nil(t, a.SetDataRate(15))
+	assert.Equal(t, []byte{0x12, 0x1e}, *writes)
+
+	// The conversion mode read from the ADC is used from now on.
+	assert.True(t, a.singleShot)
+
+	*writes = nil
+	assert.Nil(t, a.SetConversionMode(ContinuousConversion))
+	assert.Nil(t, a.SetPGA(1))
+	assert.Equal(t, []byte{0x0e, 0x0c}, *writes)
+	assert.False(t, a.singleShot)
+
+	// Nothing is written when the config register can't be read.
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			*writes = append(*writes, w...)
+			return nil
+		}
+		return fmt.Errorf("some error")
+	})
+	conn, _ = i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	a.Conn = conn
+
+	*writes = nil
+	assert.EqualError(t, a.SetPGA(2), "failed to read config register: some error")
+	assert.EqualError(t, a.SetConversionMode(SingleConversion), "failed to read config register: some error")
+	assert.Nil(t, *writes)
+	assert.Equal(t, 0, a.pga)
+	assert.False(t, a.singleShot)
+}
+
+func TestADS11xxConfigOfOtherMaster(t *testing.T) {
+	conn, writes := newADS11xxConn(0x8c)
+	a, _ := NewADS1100(conn, 2.048, 8, 1)
+	assert.Equal(t, 2.048/32768, a.Resolution())
+
+	// Another master sets the data rate to 128 SPS and the PGA to 2.
+	assert.Nil(t, a.Conn.Write([]byte{0x01}))
+	*writes = nil
+
+	// Only the PGA changes, the data rate of the other master is kept
+	// and used to compute voltages from now on.
+	assert.Nil(t, a.SetPGA(8))
+	assert.Equal(t, []byte{0x03}, *writes)
+	assert.Equal(t, 2.048/2048/8, a.Resolution())
+
+	// Only the data rate changes.
+	assert.Nil(t, a.Conn.Write([]byte{0x11}))
+	*writes = nil
+	assert.Nil(t, a.SetDataRate(16))
+	assert.Equal(t, []byte{0x19}, *writes)
+	assert.Equal(t, 2.048/16384/2, a.Resolution())
+}
+
+func TestADS11xxGain(t *testing.T) {
+	conn, _ := newADS11xxConn(0x8c)
+	a, _ := NewADS1100(conn, 3.3, 8, 2)
+	assert.Equal(t, 2, a.Gain())
+	assert.Equal(t, 1.65, a.FullScaleVoltage())
+
+	for _, pga := range []int{1, 2, 4, 8} {
+		assert.Nil(t, a.SetPGA(pga))
+		assert.Equal(t, pga, a.Gain())
+		assert.Equal(t, 3.3/float64(pga), a.FullScaleVoltage())
+	}
+
+	// Another master changes the PGA to 4 and the data rate to 32 SPS.
+	// The cache is stale until the config is read.
+	assert.Nil(t, a.Conn.Write([]byte{0x06}))
+	assert.Equal(t, 8, a.Gain())
+
+	pga, err := a.PGA()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, pga)
+	assert.Equal(t, 4, a.Gain())
+	assert.Equal(t, 3.3/8192/4, a.Resolution())
+
+	// DataRate refreshes the cache as well.
+	assert.Nil(t, a.Conn.Write([]byte{0x0f}))
+	rate, err := a.DataRate()
+	assert.Nil(t, err)
+	assert.Equal(t, 8, rate)
+	assert.Equal(t, 8, a.Gain())
+	assert.Equal(t, 3.3/32768/8, a.Resolution())
+}
+
+func TestADS11xxStream(t *testing.T) {
+	config := byte(0x0c)
 	c := iotest.NewI2CConn()
-	c.TxFunc(func(w, _ []byte) error {
-		data <- w
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			config = w[0]
+		}
+		if len(r) == 3 {
+			copy(r, []byte{0x01, 0x00, config})
+		}
 		return nil
 	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	a, _ := NewADS1110(conn, 15, 1)
+
+	// The clock only moves when the test moves it. Waiting for the next
+	// sample blocks until the test sends a tick.
+	clock := time.Date(2020, 5, 4, 12, 0, 0, 0, time.UTC)
+	start := clock
+	durations := make(chan time.Duration)
+	tick := make(chan time.Time)
+	streamNow = func() time.Time { return clock }
+	streamAfter = func(d time.Duration) <-chan time.Time {
+		durations <- d
+		return tick
+	}
+	defer func() {
+		streamNow = time.Now
+		streamAfter = time.After
+	}()
 
+	ctx, cancel := context.WithCancel(context.Background())
+	samples, err := a.Stream(ctx)
+	assert.Nil(t, err)
+
+	s := <-samples
+	assert.Equal(t, adc.Sample{Code: 256, Voltage: 256 * 2.048 / 32768, Time: start}, s)
+	assert.Equal(t, time.Second/15, <-durations)
+
+	// Changing the data rate re-paces the stream. The next sample is
+	// read a bit late, so the wait is shortened.
+	assert.Nil(t, a.SetDataRate(240))
+	clock = start.Add(70 * time.Millisecond)
+	tick <- clock
+
+	s = <-samples
+	assert.Equal(t, adc.Sample{Code: 256, Voltage: 256 * 2.048 / 2048, Time: clock}, s)
+	assert.Equal(t, time.Second/15+time.Second/240-70*time.Millisecond, <-durations)
+
+	// A failed read doesn't stop the stream.
+	c.TxFunc(func(w, r []byte) error {
+		return errors.New("some error")
+	})
+	tick <- clock
+
+	s = <-samples
+	assert.NotNil(t, s.Err)
+	assert.Equal(t, 0, s.Code)
+	assert.Equal(t, 0.0, s.Voltage)
+	<-durations
+
+	// The channel is closed once ctx is done.
+	cancel()
+	_, ok := <-samples
+	assert.False(t, ok)
+
+	_, err = a.Stream(ctx)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestADS11xxConcurrentConfig(t *testing.T) {
+	conn, _ := newADS11xxConn(0x8c)
+	a, _ := NewADS1110(conn, 15, 1)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.Nil(t, a.SetPGA(8))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.Nil(t, a.SetDataRate(240))
+		}
+	}()
+	wg.Wait()
+
+	// Neither setter undid the change of the other one.
+	config, err := a.ReadConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, byte(0x03), config)
+}
+
+// testLocker is a sync.Locker that tracks whether it's held.
+type testLocker struct {
+	sync.Mutex
+	held bool
+}
+
+func (l *testLocker) Lock() {
+	l.Mutex.Lock()
+	l.held = true
+}
+
+func (l *testLocker) Unlock() {
+	l.held = false
+	l.Mutex.Unlock()
+}
+
+func TestADS11xxConcurrentVoltage(t *testing.T) {
+	// The input is 0.128V, so the output code depends on the PGA of the
+	// config register.
+	config := byte(0x8c)
+	var l *testLocker
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		// The constructor writes the config without a Locker.
+		if l != nil {
+			assert.True(t, l.held)
+		}
+		if w != nil {
+			config = w[0]
+		}
+		if len(r) == 3 {
+			code := 2048 << (config & 0x3)
+			r[0], r[1], r[2] = byte(code>>8), byte(code), config
+		}
+		return nil
+	})
 	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
-	a, _ := NewADS1100(conn, 5.0, 128, 2)
 
-	assert.Equal(t, []byte{0x1}, <-data)
+	a, err := NewADS1110(conn, 15, 1)
+	assert.Nil(t, err)
+	l = new(testLocker)
+	a.Locker = l
 
-	assert.Nil(t, a.SetDataRate(32))
-	assert.Equal(t, []byte{0x5}, <-data)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.Nil(t, a.SetPGA(1<<uint(i%4)))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			v, err := a.Voltage(0)
+			assert.Nil(t, err)
+			assert.Equal(t, 0.128, v)
+		}
+	}()
+	wg.Wait()
+}
 
-	// Test with invalid value for data rate.
-	assert.NotNil(t, a.SetDataRate(18))
+func TestADS11xxSelfTest(t *testing.T) {
+	// The fake reads back the config written, the ST bit is set.
+	conn, writes := newADS11xxConn(0x8c)
+	a, err := NewADS1100(conn, 3.3, 8, 2, RunSelfTest)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x0d, 0x0d}, *writes)
 
-	c.TxFunc(func(_, r []byte) error {
-		copy(r, []byte{0x0, 0x0, 0x5})
+	assert.Nil(t, a.SelfTest())
+
+	// The config register of another device reads 0x00.
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		for i := range r {
+			r[i] = 0
+		}
 		return nil
 	})
+	conn, _ = i2c.Open(iotest.NewI2CDriver(c), 0x1)
 
-	d, err := a.DataRate()
+	_, err = NewADS1100(conn, 3.3, 8, 2, RunSelfTest)
+	assert.EqualError(t, err, "failed to create ADS1100: expected config 0x0d, read 0x00, is the device at this address an ADS1100?")
+
+	b, err := NewADS1110(conn, 240, 2)
 	assert.Nil(t, err)
-	assert.Equal(t, 32, d)
+	assert.EqualError(t, b.SelfTest(), "expected config 0x01, read 0x00, is the device at this address an ADS1110?")
+
+	c.TxFunc(func(w, r []byte) error {
+		return errors.New("some error")
+	})
+	assert.EqualError(t, b.SelfTest(), "failed to write config register: some error")
+}
+
+func TestADS11xxConfig(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			writes = append(writes, w)
+		}
+		copy(r, []byte{0x12, 0x34, 0x9d})
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	a, _ := NewADS1100(conn, 2.048, 128, 1)
+
+	v, err := a.ReadConfig()
+	assert.Nil(t, err)
+	assert.Equal(t, byte(0x9d), v)
+
+	// At 128 SPS the output code has 12 bits.
+	code, err := a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x234, code)
+
+	// Continuous conversion mode, 8 SPS and a PGA of 2. At 8 SPS the
+	// output code has 16 bits.
+	writes = nil
+	assert.Nil(t, a.WriteConfig(0x0d))
+	assert.Equal(t, [][]byte{{0x0d}}, writes)
+
+	code, err = a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x1234, code)
+
+	c.TxFunc(func(_, _ []byte) error {
+		return fmt.Errorf("some error")
+	})
+
+	_, err = a.ReadConfig()
+	assert.EqualError(t, err, "failed to read config register: some error")
+	assert.EqualError(t, a.WriteConfig(0x00), "failed to write config register: some error")
 }
 
 func TestADS1100Voltage(t *testing.T) {
@@ -77,9 +411,10 @@ func TestADS1100Voltage(t *testing.T) {
 	c := iotest.NewI2CConn()
 
 	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
-	ads, _ := NewADS1100(conn, 5.0, 128, 2)
 	c.TxFunc(func(w, r []byte) error {
-		copy(r, <-data)
+		if r != nil {
+			copy(r, <-data)
+		}
 		return nil
 	})
 
@@ -89,35 +424,363 @@ func TestADS1100Voltage(t *testing.T) {
 		response []byte
 		expected float64
 	}{
-		{8, 1, []byte{0xff, 0xff}, 4.99992},
-		{8, 2, []byte{0xff, 0xff}, 2.49996},
-		{8, 4, []byte{0xff, 0xff}, 1.24998},
-		{8, 8, []byte{0xff, 0xff}, 0.62499},
-		{16, 1, []byte{0xff, 0xff}, 4.99985},
-		{16, 2, []byte{0xff, 0xff}, 2.49992},
-		{32, 2, []byte{0xff, 0xff}, 2.49985},
-		{32, 8, []byte{0x00, 0x37}, 0.0021},
-		{128, 2, []byte{0xff, 0xff}, 2.49939},
+		{8, 1, []byte{0x7f, 0xff}, 4.99985},
+		{8, 2, []byte{0x7f, 0xff}, 2.49992},
+		{8, 4, []byte{0x7f, 0xff}, 1.24996},
+		{8, 8, []byte{0x7f, 0xff}, 0.62498},
+		{16, 1, []byte{0x3f, 0xff}, 4.99969},
+		{32, 8, []byte{0x00, 0x37}, 0.0042},
+		{128, 2, []byte{0x07, 0xff}, 2.49878},
+
+		// The output code is negative when the negative input is
+		// higher than the positive input.
+		{8, 1, []byte{0xff, 0xff}, -0.00015},
+		{8, 1, []byte{0x80, 0x00}, -5},
+		{128, 2, []byte{0xf8, 0x00}, -2.5},
 	}
 
 	for _, test := range tests {
-		ads.setDataRate(test.dataRate)
-		ads.pga = test.pga
+		ads, err := NewADS1100(conn, 5.0, test.dataRate, test.pga)
+		assert.Nil(t, err)
 
 		data <- test.response
-		v, _ := ads.Voltage(1)
+		v, _ := ads.Voltage(0)
 		assert.Equal(t, test.expected, round(v))
 	}
 }
 
+func TestADS11xxSamples(t *testing.T) {
+	var tests = []struct {
+		samples int
+		reduce  adc.Reducer
+		code    int
+		reads   int
+	}{
+		{0, nil, 100, 1},
+		{3, nil, 400, 3},
+		{4, nil, 550, 4},
+		{3, adc.Median, 200, 3},
+	}
+
+	for _, test := range tests {
+		codes := []int{100, 200, 900, 1000}
+		reads := 0
+
+		c := iotest.NewI2CConn()
+		c.TxFunc(func(_, r []byte) error {
+			if r == nil {
+				return nil
+			}
+
+			code := codes[reads]
+			reads++
+			r[0], r[1] = byte(code>>8), byte(code)
+			return nil
+		})
+
+		conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+		a, _ := NewADS1110(conn, 240, 1)
+		a.Samples = test.samples
+		a.Reduce = test.reduce
+
+		code, err := a.OutputCode(0)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+		assert.Equal(t, test.reads, reads)
+	}
+}
+
+func TestADS11xxRetries(t *testing.T) {
+	reads := 0
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(_, r []byte) error {
+		if r == nil {
+			return nil
+		}
+
+		reads++
+		if reads <= 2 {
+			return fmt.Errorf("some error")
+		}
+		r[0], r[1] = 0x01, 0x02
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	a, _ := NewADS1110(conn, 15, 1)
+	a.Retries = 2
+
+	code, err := a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x0102, code)
+	assert.Equal(t, 3, reads)
+
+	reads = 0
+	a.Retries = 1
+	_, err = a.OutputCode(0)
+	assert.EqualError(t, err, "failed after 2 attempts: failed to read output code: some error")
+	assert.Equal(t, 2, reads)
+}
+
+func TestADS11xxTrigger(t *testing.T) {
+	var writes [][]byte
+	busy := 0
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			writes = append(writes, w)
+			return nil
+		}
+
+		r[0], r[1] = 0x01, 0x02
+		if len(r) == 3 {
+			r[2] = 0x10
+			if busy > 0 {
+				r[2] |= 0x80
+				busy--
+			}
+		}
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	a, _ := NewADS1110(conn, 240, 1)
+
+	// The ST bit and the bit selecting single conversion mode are set.
+	writes = nil
+	assert.Nil(t, a.Trigger())
+	assert.Equal(t, [][]byte{{0x90}}, writes)
+
+	// Single conversion mode is preserved, but no conversion is started.
+	writes = nil
+	assert.Nil(t, a.SetDataRate(15))
+	assert.Equal(t, [][]byte{{0x1c}}, writes)
+	assert.Nil(t, a.SetDataRate(240))
+
+	a.Wait = true
+	busy = 2
+	code, err := a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x0102, code)
+	assert.Equal(t, 0, busy)
+
+	// Every sample triggers a new conversion.
+	writes = nil
+	a.Samples = 3
+	_, err = a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{{0x90}, {0x90}}, writes)
+
+	busy = 100
+	a.Samples = 0
+	_, err = a.OutputCode(0)
+	assert.EqualError(t, err, "conversion didn't finish within 8.333332ms")
+
+	c.TxFunc(func(_, _ []byte) error {
+		return fmt.Errorf("some error")
+	})
+	assert.EqualError(t, a.Trigger(), "failed to start conversion: failed to write config register: some error")
+}
+
+func TestADS11xxConversionMode(t *testing.T) {
+	var writes []byte
+	var config byte
+	busy := 0
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			writes = append(writes, w...)
+			config = w[0]
+			return nil
+		}
+
+		r[0], r[1] = 0xff, 0xfe
+		if len(r) == 3 {
+			r[2] = config &^ configST
+			if busy > 0 {
+				r[2] |= configST
+				busy--
+			}
+		}
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	a, _ := NewADS1110(conn, 240, 4)
+
+	err := a.StartConversion()
+	assert.EqualError(t, err, "failed to start conversion: ADC isn't in single conversion mode")
+	assert.EqualError(t, a.SetConversionMode(ConversionMode(2)), "conversion mode 2 is invalid")
+
+	// The SC bit is set without the ST bit, so no conversion is started.
+	writes = nil
+	assert.Nil(t, a.SetConversionMode(SingleConversion))
+	assert.Nil(t, a.SetPGA(8))
+	assert.Nil(t, a.SetDataRate(60))
+	assert.Nil(t, a.StartConversion())
+	assert.Equal(t, []byte{0x12, 0x13, 0x17, 0x97}, writes)
+
+	// Every sample starts a conversion and polls the ST bit until the
+	// conversion has finished.
+	writes = nil
+	busy = 3
+	a.Samples = 2
+	code, err := a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, -2, code)
+	assert.Equal(t, []byte{0x97, 0x97}, writes)
+	assert.Equal(t, 0, busy)
+
+	busy = 100
+	a.Samples = 0
+	_, err = a.OutputCode(0)
+	assert.EqualError(t, err, "conversion didn't finish within 33.333332ms")
+
+	// In continuous conversion mode the output code is read right away.
+	writes = nil
+	assert.Nil(t, a.SetConversionMode(ContinuousConversion))
+	code, err = a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, -2, code)
+	assert.Equal(t, []byte{0x07}, writes)
+
+	// The conversion mode only changes when it has been written.
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			return fmt.Errorf("some error")
+		}
+		return nil
+	})
+	assert.EqualError(t, a.SetConversionMode(SingleConversion), "failed to write config register: some error")
+	assert.False(t, a.singleShot)
+	assert.EqualError(t, a.StartConversion(), "failed to start conversion: ADC isn't in single conversion mode")
+}
+
+func TestADS11xxReadFresh(t *testing.T) {
+	config := byte(0x0c)
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if r == nil {
+			return nil
+		}
+		copy(r, []byte{0x12, 0x34, config})
+
+		// Reading the output code sets the DRDY bit until the next
+		// conversion.
+		config |= configST
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	a, _ := NewADS1110(conn, 15, 1)
+	assert.Equal(t, time.Duration(0), a.LastConversionAge())
+
+	code, err := a.ReadFresh(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x1234, code)
+	age := a.LastConversionAge()
+	assert.True(t, age > 0 && age < time.Second)
+
+	_, err = a.ReadFresh(0)
+	assert.Equal(t, ErrStaleReading, err)
+
+	// OutputCode doesn't care.
+	code, err = a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x1234, code)
+
+	config = 0x0c
+	code, err = a.ReadFresh(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x1234, code)
+
+	_, err = a.ReadFresh(2)
+	assert.Equal(t, adc.ErrInvalidChannel{Channel: 2, Channels: 1}, err)
+
+	c.TxFunc(func(_, _ []byte) error {
+		return fmt.Errorf("some error")
+	})
+	_, err = a.ReadFresh(0)
+	assert.EqualError(t, err, "failed to read output code: some error")
+}
+
+func TestADS1100ReadFresh(t *testing.T) {
+	busy := false
+	conn, _ := newADS11xxConn(0x8c)
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			return conn.Write(w)
+		}
+
+		// The BSY bit is set while the conversion is busy.
+		config := make([]byte, 3)
+		if err := conn.Read(config); err != nil {
+			return err
+		}
+		copy(r, []byte{0x12, 0x34, config[2] &^ configST})
+		if busy {
+			r[2] |= configST
+		}
+		return nil
+	})
+
+	fake, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	a, _ := NewADS1100(fake, 2.048, 8, 1)
+
+	_, err := a.ReadFresh(0)
+	assert.EqualError(t, err, "the ADS1100 can't tell whether an output code is fresh in continuous conversion mode")
+
+	assert.Nil(t, a.SetConversionMode(SingleConversion))
+
+	// No conversion has been started yet.
+	_, err = a.ReadFresh(0)
+	assert.Equal(t, ErrStaleReading, err)
+
+	assert.Nil(t, a.StartConversion())
+	busy = true
+	_, err = a.ReadFresh(0)
+	assert.Equal(t, ErrConversionBusy, err)
+
+	busy = false
+	code, err := a.ReadFresh(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x1234, code)
+
+	_, err = a.ReadFresh(0)
+	assert.Equal(t, ErrStaleReading, err)
+
+	assert.Nil(t, a.Trigger())
+	code, err = a.ReadFresh(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x1234, code)
+}
+
+func TestADS1110ReadFreshSingleConversion(t *testing.T) {
+	conn, _ := newADS11xxConn(0x8c)
+	a, _ := NewADS1110(conn, 15, 1)
+	assert.Nil(t, a.SetConversionMode(SingleConversion))
+
+	// The DRDY bit of the ADS1110 means the output code has been read
+	// before, also in single conversion mode.
+	assert.Nil(t, conn.Write([]byte{0x9c}))
+	_, err := a.ReadFresh(0)
+	assert.Equal(t, ErrStaleReading, err)
+
+	assert.Nil(t, conn.Write([]byte{0x1c}))
+	_, err = a.ReadFresh(0)
+	assert.Nil(t, err)
+}
+
 func TestADS1110Voltage(t *testing.T) {
 	data := make(chan []byte, 1)
 	c := iotest.NewI2CConn()
 
 	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
-	ads, _ := NewADS1110(conn, 240, 2)
 	c.TxFunc(func(w, r []byte) error {
-		copy(r, <-data)
+		if r != nil {
+			copy(r, <-data)
+		}
 		return nil
 	})
 
@@ -127,26 +790,170 @@ func TestADS1110Voltage(t *testing.T) {
 		response []byte
 		expected float64
 	}{
-		{15, 1, []byte{0xff, 0xff}, 2.04797},
-		{15, 2, []byte{0x3f, 0x9f}, 0.25448},
-		{30, 4, []byte{0x3f, 0x9f}, 0.25448},
-		{30, 8, []byte{0x00, 0xae}, 0.00136},
-		{60, 2, []byte{0x11, 0x2e}, 0.27487},
-		{60, 1, []byte{0x11, 0x2e}, 0.54975},
-		{240, 1, []byte{0x0b, 0x77}, 1.4675},
-		{240, 8, []byte{0xc0, 0x83}, 0.00819},
+		{15, 2, []byte{0x3f, 0x9f}, 0.50897},
+		{30, 4, []byte{0x3f, 0x9f}, 0.50897},
+		{30, 8, []byte{0x00, 0xae}, 0.00272},
+		{60, 2, []byte{0x11, 0x2e}, 0.54975},
+		{60, 1, []byte{0x11, 0x2e}, 1.0995},
+		{240, 1, []byte{0x03, 0x77}, 0.887},
+
+		// Bits above the size of the output code are ignored, the
+		// most significant bit of the output code is the sign.
+		{240, 8, []byte{0xc0, 0x83}, 0.01638},
+		{240, 1, []byte{0x0b, 0x77}, -1.161},
+		{15, 1, []byte{0xff, 0xff}, -0.00006},
 	}
 
 	for _, test := range tests {
-		ads.setDataRate(test.dataRate)
-		ads.pga = test.pga
+		ads, err := NewADS1110(conn, test.dataRate, test.pga)
+		assert.Nil(t, err)
 
 		data <- test.response
-		v, _ := ads.Voltage(1)
+		v, _ := ads.Voltage(0)
 		assert.Equal(t, test.expected, round(v))
 	}
 }
 
+// TestADS11xxNegativeCodes tests the negative output codes of table 5 of the
+// datasheets: -1 LSB, half of the negative full scale and the negative full
+// scale at every data rate. The ADC sign-extends the output code to 16 bits.
+func TestADS11xxNegativeCodes(t *testing.T) {
+	data := make(chan []byte, 1)
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if r != nil {
+			copy(r, <-data)
+		}
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	ads1100, _ := NewADS1100(conn, 5.0, 8, 1)
+	ads1110, _ := NewADS1110(conn, 15, 1)
+
+	tests := []struct {
+		ads      *ads11xx
+		dataRate int
+		response []byte
+		code     int
+	}{
+		{&ads1100.ads11xx, 8, []byte{0xff, 0xff}, -1},
+		{&ads1100.ads11xx, 8, []byte{0xc0, 0x00}, -16384},
+		{&ads1100.ads11xx, 8, []byte{0x80, 0x00}, -32768},
+		{&ads1100.ads11xx, 16, []byte{0xff, 0xff}, -1},
+		{&ads1100.ads11xx, 16, []byte{0xe0, 0x00}, -8192},
+		{&ads1100.ads11xx, 16, []byte{0xc0, 0x00}, -16384},
+		{&ads1100.ads11xx, 32, []byte{0xff, 0xff}, -1},
+		{&ads1100.ads11xx, 32, []byte{0xf0, 0x00}, -4096},
+		{&ads1100.ads11xx, 32, []byte{0xe0, 0x00}, -8192},
+		{&ads1100.ads11xx, 128, []byte{0xff, 0xff}, -1},
+		{&ads1100.ads11xx, 128, []byte{0xfc, 0x00}, -1024},
+		{&ads1100.ads11xx, 128, []byte{0xf8, 0x00}, -2048},
+
+		{&ads1110.ads11xx, 15, []byte{0xff, 0xff}, -1},
+		{&ads1110.ads11xx, 15, []byte{0xc0, 0x00}, -16384},
+		{&ads1110.ads11xx, 15, []byte{0x80, 0x00}, -32768},
+		{&ads1110.ads11xx, 30, []byte{0xff, 0xff}, -1},
+		{&ads1110.ads11xx, 30, []byte{0xe0, 0x00}, -8192},
+		{&ads1110.ads11xx, 30, []byte{0xc0, 0x00}, -16384},
+		{&ads1110.ads11xx, 60, []byte{0xff, 0xff}, -1},
+		{&ads1110.ads11xx, 60, []byte{0xf0, 0x00}, -4096},
+		{&ads1110.ads11xx, 60, []byte{0xe0, 0x00}, -8192},
+		{&ads1110.ads11xx, 240, []byte{0xff, 0xff}, -1},
+		{&ads1110.ads11xx, 240, []byte{0xfc, 0x00}, -1024},
+		{&ads1110.ads11xx, 240, []byte{0xf8, 0x00}, -2048},
+	}
+
+	for _, test := range tests {
+		a := test.ads
+		assert.Nil(t, a.setDataRate(test.dataRate))
+
+		data <- test.response
+		code, err := a.OutputCode(0)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code, "%v at %d SPS", test.response, test.dataRate)
+
+		// The negative full scale is -Vref / PGA.
+		for _, pga := range []int{1, 2, 4, 8} {
+			// SetPGA reads the config register first.
+			data <- []byte{0, 0, byte(a.dataRate.bitMask << 2)}
+			assert.Nil(t, a.SetPGA(pga))
+
+			data <- test.response
+			v, err := a.Voltage(0)
+			assert.Nil(t, err)
+
+			fullScale := 1 << (a.dataRate.size - 1)
+			assert.InDelta(t, -a.Vref/float64(pga)*float64(-test.code)/float64(fullScale), v, 1e-12)
+		}
+	}
+}
+
+func TestADS11xxResolution(t *testing.T) {
+	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
+
+	tests := []struct {
+		dataRate int
+		pga      int
+		expected float64
+	}{
+		{15, 1, 0.00006},
+		{15, 2, 0.00003},
+		{30, 1, 0.00013},
+		{60, 4, 0.00006},
+		{240, 1, 0.001},
+		{240, 8, 0.00013},
+	}
+
+	for _, test := range tests {
+		ads, err := NewADS1110(conn, test.dataRate, test.pga)
+		assert.Nil(t, err)
+
+		assert.Equal(t, test.expected, round(ads.Resolution()))
+	}
+}
+
+func TestADS11xxVoltageContext(t *testing.T) {
+	data := make(chan []byte, 1)
+	c := iotest.NewI2CConn()
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	ads, _ := NewADS1100(conn, 5.0, 8, 1)
+	c.TxFunc(func(w, r []byte) error {
+		copy(r, <-data)
+		return nil
+	})
+
+	data <- []byte{0x7f, 0xff}
+	code, err := ads.OutputCodeContext(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x7fff, code)
+
+	// Nothing is send on data, so the read hangs until the context expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	_, err = ads.VoltageContext(ctx, 1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// Unblock the read that has been abandoned.
+	data <- []byte{0x0, 0x0}
+}
+
+func TestADS11xxWithInvalidChannel(t *testing.T) {
+	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
+	ads, _ := NewADS1100(conn, 5.0, 8, 1)
+	assert.Equal(t, 1, ads.Channels())
+
+	for _, channel := range []int{-1, 2} {
+		_, err := ads.OutputCode(channel)
+		assert.Equal(t, adc.ErrInvalidChannel{Channel: channel, Channels: 1}, err)
+	}
+
+	// Channel 1 is still accepted.
+	_, err := ads.OutputCode(1)
+	assert.Nil(t, err)
+}
+
 func round(f float64) float64 {
 	shift := math.Pow(10, 5)
 	return math.Floor((f*shift)+0.5) / shift
@@ -169,19 +976,223 @@ func ExampleADS1100() {
 		panic(fmt.Sprintf("failed to create ADS1100: %v", err))
 	}
 
-	// Retrieve voltage of channel 1...
-	v, err := adc.Voltage(1)
+	// Retrieve voltage of channel 0...
+	v, err := adc.Voltage(0)
 
 	if err != nil {
-		panic(fmt.Sprintf("failed to read channel 1 of ADS1100: %s", err))
+		panic(fmt.Sprintf("failed to read channel 0 of ADS1100: %s", err))
 	}
 
-	// ...read the raw value of channel 1. PGA has not been applied.
-	c, err := adc.OutputCode(1)
+	// ...read the raw value of channel 0. PGA has not been applied.
+	c, err := adc.OutputCode(0)
 
 	if err != nil {
-		panic(fmt.Sprintf("failed to read channel 1 of ADS1100: %s", err))
+		panic(fmt.Sprintf("failed to read channel 0 of ADS1100: %s", err))
+	}
+
+	fmt.Printf("channel 0 reads %f or digital output code  %d", v, c)
+}
+
+func TestADS1112Channels(t *testing.T) {
+	var writes []byte
+	var config byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			writes = append(writes, w...)
+			config = w[0]
+			return nil
+		}
+
+		// Conversions finish immediately.
+		r[0], r[1] = 0x01, 0x02
+		r[2] = config &^ configST
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	a, err := NewADS1112(conn, 240, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, a.Channels())
+	assert.Equal(t, []byte{0x01}, writes)
+
+	tests := []struct {
+		channel int
+		config  byte
+	}{
+		{AIN0PosAIN3Neg, 0x41},
+		{AIN1PosAIN3Neg, 0x61},
+		{AIN2PosAIN3Neg, 0x21},
+		{AIN0PosAIN1Neg, 0x01},
+	}
+
+	for _, test := range tests {
+		writes = nil
+		code, err := a.OutputCode(test.channel)
+		assert.Nil(t, err)
+		assert.Equal(t, 0x0102, code)
+		assert.Equal(t, []byte{test.config}, writes)
+
+		// The channel is selected already.
+		writes = nil
+		_, err = a.OutputCode(test.channel)
+		assert.Nil(t, err)
+		assert.Nil(t, writes)
+	}
+
+	// In single conversion mode selecting the channel starts a conversion.
+	assert.Nil(t, a.SetConversionMode(SingleConversion))
+	writes = nil
+	_, err = a.OutputCode(AIN1PosAIN3Neg)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xf1}, writes)
+
+	writes = nil
+	_, err = a.OutputCode(AIN1PosAIN3Neg)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xf1}, writes)
+
+	// The INP bits are preserved by the setters.
+	writes = nil
+	assert.Nil(t, a.SetPGA(8))
+	assert.Equal(t, []byte{0x73}, writes)
+	assert.Nil(t, a.SelfTest())
+
+	_, err = a.ReadFresh(AIN0PosAIN1Neg)
+	assert.EqualError(t, err, "channel 0 isn't selected, read it using OutputCode first")
+
+	for _, channel := range []int{-1, 4} {
+		_, err = a.OutputCode(channel)
+		assert.Equal(t, adc.ErrInvalidChannel{Channel: channel, Channels: 4}, err)
+	}
+}
+
+func TestADS11xxReadWithFlags(t *testing.T) {
+	var out []byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		copy(r, out)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	tests := []struct {
+		rate      int
+		out       []byte
+		code      int
+		saturated bool
+	}{
+		// 16 bits at 15 SPS.
+		{15, []byte{0x7f, 0xff, 0x0c}, 32767, true},
+		{15, []byte{0x7f, 0xfe, 0x0c}, 32766, false},
+		{15, []byte{0x80, 0x00, 0x0c}, -32768, true},
+		{15, []byte{0x80, 0x01, 0x0c}, -32767, false},
+		{15, []byte{0x00, 0x00, 0x0c}, 0, false},
+		// 12 bits at 240 SPS, the unused high bits repeat the sign bit.
+		{240, []byte{0x07, 0xff, 0x00}, 2047, true},
+		{240, []byte{0x07, 0xfe, 0x00}, 2046, false},
+		{240, []byte{0xf8, 0x00, 0x00}, -2048, true},
+		{240, []byte{0xf8, 0x01, 0x00}, -2047, false},
+	}
+
+	for _, test := range tests {
+		a, err := NewADS1110(conn, test.rate, 1)
+		assert.Nil(t, err)
+
+		out = test.out
+		code, saturated, err := a.ReadWithFlags(0)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+		assert.Equal(t, test.saturated, saturated, fmt.Sprintf("% x", test.out))
 	}
 
-	fmt.Printf("channel 1 reads %f or digital output code  %d", v, c)
+	a, _ := NewADS1110(conn, 15, 1)
+	_, _, err := a.ReadWithFlags(2)
+	assert.Equal(t, adc.ErrInvalidChannel{Channel: 2, Channels: 1}, err)
+}
+
+func TestADS11xxRange(t *testing.T) {
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error { return nil })
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	tests := []struct {
+		pga int
+		min float64
+		max float64
+	}{
+		{1, -5, 4.999847412109375},
+		{2, -2.5, 2.4999237060546875},
+		{4, -1.25, 1.24996185302734375},
+		{8, -0.625, 0.624980926513671875},
+	}
+
+	for _, test := range tests {
+		a, err := NewADS1100(conn, 5, 8, test.pga)
+		assert.Nil(t, err)
+
+		min, max := a.Range()
+		assert.InDelta(t, test.min, min, 1e-12, fmt.Sprintf("PGA %d", test.pga))
+		assert.InDelta(t, test.max, max, 1e-12, fmt.Sprintf("PGA %d", test.pga))
+	}
+
+	tests = []struct {
+		pga int
+		min float64
+		max float64
+	}{
+		{1, -2.048, 2.0479375},
+		{2, -1.024, 1.02396875},
+		{4, -0.512, 0.511984375},
+		{8, -0.256, 0.2559921875},
+	}
+
+	for _, test := range tests {
+		a, err := NewADS1110(conn, 15, test.pga)
+		assert.Nil(t, err)
+
+		min, max := a.Range()
+		assert.InDelta(t, test.min, min, 1e-12, fmt.Sprintf("PGA %d", test.pga))
+		assert.InDelta(t, test.max, max, 1e-12, fmt.Sprintf("PGA %d", test.pga))
+	}
+
+	// The range follows the number of bits of the data rate.
+	a, _ := NewADS1110(conn, 240, 1)
+	min, max := a.Range()
+	assert.InDelta(t, -2.048, min, 1e-12)
+	assert.InDelta(t, 2.047, max, 1e-12)
+}
+
+func TestADS11xxFlagSaturated(t *testing.T) {
+	var out []byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		copy(r, out)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	a, _ := NewADS1110(conn, 15, 1)
+
+	// Without the flag the clipped voltage is returned.
+	out = []byte{0x7f, 0xff, 0x0c}
+	v, err := a.Voltage(0)
+	assert.Nil(t, err)
+	assert.InDelta(t, 2.0479375, v, 1e-12)
+
+	a.FlagSaturated = true
+	_, err = a.Voltage(0)
+	assert.Equal(t, ErrSaturated{Voltage: 2.0479375}, err)
+	assert.EqualError(t, err, "voltage of 2.0479375V is saturated, the input is out of range")
+
+	out = []byte{0x80, 0x00, 0x0c}
+	_, err = a.Voltage(0)
+	var saturated ErrSaturated
+	assert.True(t, errors.As(err, &saturated))
+	assert.Equal(t, -2.048, saturated.Voltage)
+
+	out = []byte{0x7f, 0xfe, 0x0c}
+	v, err = a.Voltage(0)
+	assert.Nil(t, err)
+	assert.InDelta(t, 2.047875, v, 1e-12)
 }
@@ -0,0 +1,659 @@
+package ti
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/bus"
+	"github.com/advancedclimatesystems/io/gpio"
+)
+
+var (
+	_ adc.Sized = (*ADS1015)(nil)
+	_ adc.Sized = (*ADS1114)(nil)
+	_ adc.Sized = (*ADS1115)(nil)
+)
+
+// The registers of the ADS1x15, selected by writing their address to the
+// pointer register.
+const (
+	regConversion = 0x0
+	regConfig     = 0x1
+	regLoThresh   = 0x2
+	regHiThresh   = 0x3
+)
+
+// The values of the threshold registers after power on. They are the most
+// negative and most positive output codes, so the comparator never triggers.
+const (
+	loThreshDefault = 0x8000
+	hiThreshDefault = 0x7fff
+)
+
+// The fields of the config register of the ADS1x15.
+const (
+	// configOS starts a single conversion when written. When read, it is
+	// 1 when no conversion is in progress.
+	configOS = 0x8000
+	// configMuxSingleEnded selects AIN0 relative to GND as input. The
+	// inputs AIN1 - AIN3 follow.
+	configMuxSingleEnded = 0x4000
+	// configModeSingle puts the ADC in single-shot mode.
+	configModeSingle = 0x0100
+	// configCompMode selects the window comparator.
+	configCompMode = 0x0010
+	// configCompDisable disables the comparator.
+	configCompDisable = 0x0003
+	// configDefault is the value of the config register after power on.
+	configDefault = 0x8583
+)
+
+// ComparatorMode is the mode of the comparator of the ADS1x15.
+type ComparatorMode int
+
+const (
+	// TraditionalComparator asserts ALERT when a conversion is above the
+	// high threshold. It deasserts ALERT when a conversion is below the
+	// low threshold, so the thresholds form a hysteresis.
+	TraditionalComparator ComparatorMode = iota
+	// WindowComparator asserts ALERT when a conversion is above the high
+	// threshold or below the low threshold.
+	WindowComparator
+)
+
+// The differential inputs of the ADS1x15. When InputType is
+// adc.PseudoDifferential, the channel selects one of these pairs of inputs.
+// They are the channels of the ADS1112 as well.
+// The inputs are fully differential, so the output code is negative when the
+// positive input is below the negative input.
+const (
+	// AIN0PosAIN1Neg measures AIN0 relative to AIN1.
+	AIN0PosAIN1Neg = iota
+	// AIN0PosAIN3Neg measures AIN0 relative to AIN3.
+	AIN0PosAIN3Neg
+	// AIN1PosAIN3Neg measures AIN1 relative to AIN3.
+	AIN1PosAIN3Neg
+	// AIN2PosAIN3Neg measures AIN2 relative to AIN3.
+	AIN2PosAIN3Neg
+)
+
+// FullScaleRange is the range of input voltages the ADS1x15 can measure. It is
+// selected by the Programmable Gain Amplifier of the ADC. The value of a
+// FullScaleRange is the value of the PGA field in the config register.
+type FullScaleRange int
+
+// The full scale ranges supported by the ADS1015 and ADS1115. Note that the
+// input voltage may never exceed VDD + 0.3V, even if the full scale range is
+// larger.
+const (
+	// FSR6144 is a full scale range of +/-6.144V.
+	FSR6144 FullScaleRange = iota
+	// FSR4096 is a full scale range of +/-4.096V.
+	FSR4096
+	// FSR2048 is a full scale range of +/-2.048V, which is the default.
+	FSR2048
+	// FSR1024 is a full scale range of +/-1.024V.
+	FSR1024
+	// FSR512 is a full scale range of +/-0.512V.
+	FSR512
+	// FSR256 is a full scale range of +/-0.256V.
+	FSR256
+)
+
+// fullScaleVoltages maps a FullScaleRange to its voltage.
+var fullScaleVoltages = map[FullScaleRange]float64{
+	FSR6144: 6.144,
+	FSR4096: 4.096,
+	FSR2048: 2.048,
+	FSR1024: 1.024,
+	FSR512:  0.512,
+	FSR256:  0.256,
+}
+
+// Voltage returns the positive bound of the full scale range in volts. It
+// returns 0 for an invalid full scale range.
+func (f FullScaleRange) Voltage() float64 {
+	return fullScaleVoltages[f]
+}
+
+// ads1x15 implements the ADS1015, ADS1114 and ADS1115, which share their
+// register model. They differ in resolution, supported data rates and number
+// of channels.
+type ads1x15 struct {
+	Conn bus.I2C
+
+	// Locker, when set, is held while talking to the ADC, so devices on
+	// the same I2C bus can't interleave with the write of the pointer
+	// register and the read that follows it.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
+	// FullScale selects how an output code is converted to a voltage. It
+	// defaults to adc.CountBased, see adc.FullScaleMode. The output code
+	// is signed, so n is the number of bits of the ADC minus 1.
+	FullScale adc.FullScaleMode
+
+	// InputType selects whether a channel is one of the inputs AIN0 - AIN3
+	// relative to GND, or one of the differential pairs like
+	// AIN0PosAIN1Neg.
+	InputType adc.InputType
+
+	// m serializes the methods of the ADC, so a conversion can't be
+	// started while another one is read. It guards rate, fsr and comp.
+	// Locker is only taken while holding m.
+	m *sync.Mutex
+
+	// rates contains the supported data rates. The index of a rate is the
+	// value of the DR field in the config register.
+	rates []int
+	rate  int
+
+	fsr FullScaleRange
+
+	// bits is the resolution of the ADC. The result is left justified in
+	// the 16 bits conversion register.
+	bits uint
+
+	// comp contains the comparator fields of the config register.
+	comp int
+
+	// channels is the number of channels, 4 or 1 for the ADS1114, which
+	// has no input multiplexer.
+	channels int
+}
+
+func newADS1x15(conn bus.I2C, rate int, rates []int, bits uint, channels int) (ads1x15, error) {
+	a := ads1x15{
+		Conn:     conn,
+		m:        new(sync.Mutex),
+		rates:    rates,
+		bits:     bits,
+		fsr:      FSR2048,
+		comp:     configCompDisable,
+		channels: channels,
+	}
+
+	if err := a.SetDataRate(rate); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// OutputCode starts a single conversion of the channel and returns its output
+// code once the conversion has finished. The channels 0 - 3 measure the inputs
+// AIN0 - AIN3 relative to GND, or the differential pairs AIN0PosAIN1Neg -
+// AIN2PosAIN3Neg when InputType is adc.PseudoDifferential. The ADS1114 only
+// has channel 0, AIN0 relative to AIN1. The output code is signed, it is
+// negative when the input is below GND or the negative input.
+func (a *ads1x15) OutputCode(channel int) (int, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.outputCode(channel)
+}
+
+// outputCode is OutputCode without taking m.
+func (a *ads1x15) outputCode(channel int) (int, error) {
+	if err := a.checkChannel(channel); err != nil {
+		return 0, err
+	}
+
+	config := configOS | a.mux(channel) | int(a.fsr)<<9 | configModeSingle | a.dataRateBits()<<5 | a.comp
+	if err := a.writeRegister(regConfig, uint16(config)); err != nil {
+		return 0, fmt.Errorf("failed to start conversion: %w", err)
+	}
+
+	if err := a.waitForConversion(); err != nil {
+		return 0, err
+	}
+
+	v, err := a.readRegister(regConversion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read output code: %w", err)
+	}
+
+	return a.code(v), nil
+}
+
+// code returns the output code of the value of the conversion register.
+func (a *ads1x15) code(v uint16) int {
+	// The conversion register contains a two's complement value, the
+	// least significant bits of the ADS1015 are always 0.
+	return int(int16(v)) >> (16 - a.bits)
+}
+
+// Channels returns the number of channels, 4 or 1 for the ADS1114.
+func (a *ads1x15) Channels() int {
+	return a.channels
+}
+
+// checkChannel returns an error when the ADC doesn't have the channel.
+func (a *ads1x15) checkChannel(channel int) error {
+	if channel < 0 || channel >= a.channels {
+		return adc.ErrInvalidChannel{Channel: channel, Channels: a.channels}
+	}
+	return nil
+}
+
+// mux returns the MUX field of the config register for the channel.
+func (a *ads1x15) mux(channel int) int {
+	// The ADS1114 ignores the MUX field and always measures AIN0 relative
+	// to AIN1, which is the value 0.
+	if a.channels == 1 {
+		return 0
+	}
+
+	// The MUX field selects the differential pairs with values 0 - 3 and
+	// the single-ended inputs with values 4 - 7.
+	mux := channel << 12
+	if a.InputType == adc.SingleEnded {
+		mux |= configMuxSingleEnded
+	}
+	return mux
+}
+
+// Voltage starts a single conversion of the channel and returns its voltage.
+// The voltage is scaled using the active full scale range.
+func (a *ads1x15) Voltage(channel int) (float64, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	code, err := a.outputCode(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(code) * a.resolution(), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (a *ads1x15) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, a, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (a *ads1x15) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, a, channel)
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure. It depends on the
+// active full scale range.
+func (a *ads1x15) Resolution() float64 {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.resolution()
+}
+
+// resolution is Resolution without taking m.
+func (a *ads1x15) resolution() float64 {
+	return a.fsr.Voltage() / a.FullScale.Denominator(int(a.bits)-1)
+}
+
+// FSR returns the active full scale range.
+func (a *ads1x15) FSR() FullScaleRange {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.fsr
+}
+
+// SetFSR sets the full scale range. It is used by the next conversion.
+func (a *ads1x15) SetFSR(f FullScaleRange) error {
+	if _, ok := fullScaleVoltages[f]; !ok {
+		return fmt.Errorf("full scale range %d is invalid", f)
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	a.fsr = f
+	return nil
+}
+
+// Gain returns the gain of the PGA, that is 4.096V divided by the voltage of
+// the full scale range. FSR6144 has a gain of 2/3, FSR256 a gain of 16.
+func (a *ads1x15) Gain() float64 {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return FSR4096.Voltage() / a.fsr.Voltage()
+}
+
+// SetGain sets the full scale range with the given gain of the PGA. Valid
+// gains are 2/3, 1, 2, 4, 8 and 16. It is used by the next conversion.
+func (a *ads1x15) SetGain(g float64) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for f, v := range fullScaleVoltages {
+		if math.Abs(FSR4096.Voltage()/v-g) < 1e-3 {
+			a.fsr = f
+			return nil
+		}
+	}
+
+	return fmt.Errorf("gain of %v is invalid, choose 2/3, 1, 2, 4, 8 or 16", g)
+}
+
+// ConfigureComparator enables the comparator with the thresholds low and high
+// in volts. The comparator asserts the ALERT/RDY pin, which is active low,
+// once queue successive conversions, 1, 2 or 4, crossed a threshold.
+//
+// The thresholds are converted to output codes using the active full scale
+// range, so set the gain before configuring the comparator. The comparator is
+// used by every conversion from now on, see OnAlert to watch a channel
+// continuously.
+func (a *ads1x15) ConfigureComparator(low, high float64, mode ComparatorMode, queue int) error {
+	if low >= high {
+		return fmt.Errorf("low threshold %vV must be below high threshold %vV", low, high)
+	}
+
+	comp := 0
+	switch mode {
+	case TraditionalComparator:
+	case WindowComparator:
+		comp |= configCompMode
+	default:
+		return fmt.Errorf("comparator mode %d is invalid", mode)
+	}
+
+	// The COMP_QUE field holds 0, 1 or 2 for a queue of 1, 2 or 4
+	// conversions.
+	switch queue {
+	case 1, 2:
+		comp |= queue - 1
+	case 4:
+		comp |= 2
+	default:
+		return fmt.Errorf("queue of %d conversions is invalid, choose 1, 2 or 4", queue)
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	lo, err := a.threshold(low)
+	if err != nil {
+		return err
+	}
+	hi, err := a.threshold(high)
+	if err != nil {
+		return err
+	}
+
+	if err := a.writeThresholds(lo, hi); err != nil {
+		return err
+	}
+
+	a.comp = comp
+	return nil
+}
+
+// DisableComparator disables the comparator and restores the config and the
+// thresholds to their values after power on. This also stops the continuous
+// conversions started by OnAlert.
+func (a *ads1x15) DisableComparator() error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	// Writing OS starts a conversion, which isn't part of restoring the
+	// config.
+	if err := a.writeRegister(regConfig, configDefault&^configOS); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	if err := a.writeThresholds(loThreshDefault, hiThreshDefault); err != nil {
+		return err
+	}
+
+	a.comp = configCompDisable
+	return nil
+}
+
+// OnAlert starts continuous conversions of the channel and calls f with the
+// voltage of the conversion that made the comparator assert ALERT/RDY. pin
+// must be connected to ALERT/RDY, OnAlert configures it as an input that
+// triggers on the falling edge. The comparator must have been configured with
+// ConfigureComparator.
+//
+// f is called by the watcher of pin, with the error of reading the voltage
+// if that failed. The conversions continue until DisableComparator is called
+// or OutputCode starts a single conversion.
+func (a *ads1x15) OnAlert(channel int, pin gpio.GPIO, f func(v float64, err error)) error {
+	if err := a.checkChannel(channel); err != nil {
+		return err
+	}
+	if err := a.checkComparator(); err != nil {
+		return err
+	}
+
+	// The pin is set up without holding m, the watcher of the pin might
+	// be calling the callback of a previous call.
+	if err := pin.SetDirection(gpio.InDirection); err != nil {
+		return fmt.Errorf("failed to set direction of ALERT/RDY pin: %w", err)
+	}
+
+	err := pin.SetEdge(gpio.FallingEdge, func(*gpio.Pin) {
+		f(a.alertVoltage())
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set edge of ALERT/RDY pin: %w", err)
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	// The comparator might have been disabled while setting up the pin.
+	if a.comp == configCompDisable {
+		return errDisabledComparator
+	}
+
+	// The MODE field is 0, which selects continuous conversions.
+	config := a.mux(channel) | int(a.fsr)<<9 | a.dataRateBits()<<5 | a.comp
+	if err := a.writeRegister(regConfig, uint16(config)); err != nil {
+		return fmt.Errorf("failed to start conversions: %w", err)
+	}
+	return nil
+}
+
+// alertVoltage reads the voltage of the conversion that asserted ALERT/RDY.
+// The callback of OnAlert is called after it has released m, so the callback
+// can use the ADC.
+func (a *ads1x15) alertVoltage() (float64, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	v, err := a.readRegister(regConversion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read output code: %w", err)
+	}
+	return float64(a.code(v)) * a.resolution(), nil
+}
+
+// errDisabledComparator is returned by OnAlert when the comparator is
+// disabled.
+var errDisabledComparator = errors.New("failed to watch ALERT/RDY: the comparator is disabled")
+
+// checkComparator returns an error when the comparator is disabled.
+func (a *ads1x15) checkComparator() error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.comp == configCompDisable {
+		return errDisabledComparator
+	}
+	return nil
+}
+
+// threshold returns the value of a threshold register for v volts.
+func (a *ads1x15) threshold(v float64) (uint16, error) {
+	code := int(math.Round(v / a.resolution()))
+
+	max := 1<<(a.bits-1) - 1
+	if code < -max-1 || code > max {
+		return 0, fmt.Errorf("threshold of %vV is outside the full scale range of +/-%vV", v, a.fsr.Voltage())
+	}
+
+	// Like the conversion register, the thresholds are left justified.
+	return uint16(int16(code << (16 - a.bits))), nil
+}
+
+// writeThresholds writes the Lo_thresh and Hi_thresh registers.
+func (a *ads1x15) writeThresholds(lo, hi uint16) error {
+	if err := a.writeRegister(regLoThresh, lo); err != nil {
+		return fmt.Errorf("failed to write low threshold: %w", err)
+	}
+	if err := a.writeRegister(regHiThresh, hi); err != nil {
+		return fmt.Errorf("failed to write high threshold: %w", err)
+	}
+	return nil
+}
+
+// DataRate returns the data rate in samples per second.
+func (a *ads1x15) DataRate() int {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	return a.rate
+}
+
+// SetDataRate sets the data rate in samples per second. It is used by the
+// next conversion.
+func (a *ads1x15) SetDataRate(sps int) error {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	for _, r := range a.rates {
+		if r == sps {
+			a.rate = sps
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%d is an invalid value for data rate, use on of %v", sps, a.rates)
+}
+
+// dataRateBits returns the value of the DR field in the config register for
+// the current data rate.
+func (a *ads1x15) dataRateBits() int {
+	for i, r := range a.rates {
+		if r == a.rate {
+			return i
+		}
+	}
+	return 0
+}
+
+// waitForConversion waits until the ADC has finished the conversion that has
+// been started by writing the config register.
+func (a *ads1x15) waitForConversion() error {
+	// A conversion takes 1 / data rate seconds, but the internal
+	// oscillator of the ADC might be a bit slower than specified.
+	d := time.Second / time.Duration(a.rate)
+	time.Sleep(d)
+
+	for i := 0; i < 10; i++ {
+		config, err := a.readRegister(regConfig)
+		if err != nil {
+			return fmt.Errorf("failed to read status of conversion: %w", err)
+		}
+
+		if config&configOS != 0 {
+			return nil
+		}
+		time.Sleep(d / 10)
+	}
+
+	return fmt.Errorf("conversion didn't finish within %v", 2*d)
+}
+
+// readRegister reads a register of the ADC, see readRegister16.
+func (a *ads1x15) readRegister(reg byte) (uint16, error) {
+	var v uint16
+	err := a.tx(func(conn bus.I2C) (err error) {
+		v, err = readRegister16(conn, reg)
+		return err
+	})
+	return v, err
+}
+
+// writeRegister writes a register of the ADC, see writeRegister16.
+func (a *ads1x15) writeRegister(reg byte, v uint16) error {
+	return a.tx(func(conn bus.I2C) error {
+		return writeRegister16(conn, reg, v)
+	})
+}
+
+// tx calls f with the connection to the ADC while holding Locker, when set.
+func (a *ads1x15) tx(f func(conn bus.I2C) error) error {
+	if a.Locker != nil {
+		a.Locker.Lock()
+		defer a.Locker.Unlock()
+	}
+	return f(bus.LogI2C(a.Conn, a.Logger))
+}
+
+// ADS1015 is a 12-bits ADC with 4 inputs. Allowed values for the data rate are
+// 128, 250, 490, 920, 1600, 2400 and 3300 SPS. The datasheet is here:
+// http://www.ti.com/lit/ds/symlink/ads1015.pdf
+type ADS1015 struct {
+	ads1x15
+}
+
+// NewADS1015 returns an ADS1015.
+func NewADS1015(conn bus.I2C, rate int) (*ADS1015, error) {
+	rates := []int{128, 250, 490, 920, 1600, 2400, 3300}
+
+	inner, err := newADS1x15(conn, rate, rates, 12, 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ADS1015: %w", err)
+	}
+	return &ADS1015{inner}, nil
+}
+
+// ADS1114 is the ADS1115 with a single differential input, AIN0 relative to
+// AIN1, which is channel 0. Allowed values for the data rate are 8, 16, 32, 64,
+// 128, 250, 475 and 860 SPS. The datasheet is here:
+// http://www.ti.com/lit/ds/symlink/ads1114.pdf
+type ADS1114 struct {
+	ads1x15
+}
+
+// NewADS1114 returns an ADS1114.
+func NewADS1114(conn bus.I2C, rate int) (*ADS1114, error) {
+	rates := []int{8, 16, 32, 64, 128, 250, 475, 860}
+
+	inner, err := newADS1x15(conn, rate, rates, 16, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ADS1114: %w", err)
+	}
+	return &ADS1114{inner}, nil
+}
+
+// ADS1115 is a 16-bits ADC with 4 inputs. Allowed values for the data rate are
+// 8, 16, 32, 64, 128, 250, 475 and 860 SPS. The datasheet is here:
+// http://www.ti.com/lit/ds/symlink/ads1115.pdf
+type ADS1115 struct {
+	ads1x15
+}
+
+// NewADS1115 returns an ADS1115.
+func NewADS1115(conn bus.I2C, rate int) (*ADS1115, error) {
+	rates := []int{8, 16, 32, 64, 128, 250, 475, 860}
+
+	inner, err := newADS1x15(conn, rate, rates, 16, 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ADS1115: %w", err)
+	}
+	return &ADS1115{inner}, nil
+}
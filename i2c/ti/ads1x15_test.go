@@ -0,0 +1,610 @@
+package ti
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/gpio"
+	"github.com/advancedclimatesystems/io/gpio/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeADS1x15 is a bus.I2C that behaves like the registers of an ADS1x15.
+type fakeADS1x15 struct {
+	pointer byte
+	regs    map[byte]uint16
+	// busy is the number of reads of the config register that report a
+	// conversion in progress.
+	busy   int
+	writes [][]byte
+	err    error
+	// locker, when set, must be held by every transfer. unlocked is set
+	// by a transfer without it.
+	locker   *testLocker
+	unlocked bool
+}
+
+func newFakeADS1x15() *fakeADS1x15 {
+	return &fakeADS1x15{regs: make(map[byte]uint16)}
+}
+
+func (f *fakeADS1x15) Write(b []byte) error {
+	f.checkLocker()
+	if f.err != nil {
+		return f.err
+	}
+
+	f.writes = append(f.writes, append([]byte(nil), b...))
+	f.pointer = b[0]
+	if len(b) == 3 {
+		f.regs[b[0]] = uint16(b[1])<<8 | uint16(b[2])
+	}
+	return nil
+}
+
+func (f *fakeADS1x15) Read(b []byte) error {
+	f.checkLocker()
+	if f.err != nil {
+		return f.err
+	}
+
+	v := f.regs[f.pointer]
+	if f.pointer == regConfig {
+		v &^= configOS
+		if f.busy > 0 {
+			f.busy--
+		} else {
+			v |= configOS
+		}
+	}
+
+	b[0], b[1] = byte(v>>8), byte(v)
+	return nil
+}
+
+func (f *fakeADS1x15) checkLocker() {
+	if f.locker != nil && !f.locker.held {
+		f.unlocked = true
+	}
+}
+
+func TestADS1115OutputCode(t *testing.T) {
+	f := newFakeADS1x15()
+	a, err := NewADS1115(f, 860)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		channel    int
+		conversion uint16
+		config     []byte
+		code       int
+	}{
+		{0, 0x0000, []byte{0x01, 0xc5, 0xe3}, 0},
+		{1, 0x7fff, []byte{0x01, 0xd5, 0xe3}, 32767},
+		{2, 0x0001, []byte{0x01, 0xe5, 0xe3}, 1},
+		{3, 0xffff, []byte{0x01, 0xf5, 0xe3}, -1},
+		{3, 0x8000, []byte{0x01, 0xf5, 0xe3}, -32768},
+	}
+
+	for _, test := range tests {
+		f.writes = nil
+		f.regs[regConversion] = test.conversion
+
+		code, err := a.OutputCode(test.channel)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+
+		// The config is written, the status polled and the conversion read.
+		assert.Equal(t, [][]byte{test.config, {regConfig}, {regConversion}}, f.writes)
+	}
+}
+
+func TestADS1015OutputCode(t *testing.T) {
+	f := newFakeADS1x15()
+	a, err := NewADS1015(f, 1600)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		conversion uint16
+		code       int
+	}{
+		{0x0000, 0},
+		{0x7ff0, 2047},
+		{0x0010, 1},
+		{0xfff0, -1},
+		{0x8000, -2048},
+	}
+
+	for _, test := range tests {
+		f.writes = nil
+		f.regs[regConversion] = test.conversion
+
+		code, err := a.OutputCode(1)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+		assert.Equal(t, []byte{0x01, 0xd5, 0x83}, f.writes[0])
+	}
+}
+
+func TestADS1x15Differential(t *testing.T) {
+	f := newFakeADS1x15()
+	a, _ := NewADS1115(f, 860)
+	a.InputType = adc.PseudoDifferential
+
+	tests := []struct {
+		channel int
+		config  []byte
+	}{
+		{AIN0PosAIN1Neg, []byte{0x01, 0x85, 0xe3}},
+		{AIN0PosAIN3Neg, []byte{0x01, 0x95, 0xe3}},
+		{AIN1PosAIN3Neg, []byte{0x01, 0xa5, 0xe3}},
+		{AIN2PosAIN3Neg, []byte{0x01, 0xb5, 0xe3}},
+	}
+
+	f.regs[regConversion] = 0xc000
+	for _, test := range tests {
+		f.writes = nil
+
+		v, err := a.Voltage(test.channel)
+		assert.Nil(t, err)
+		assert.Equal(t, -1.024, v)
+		assert.Equal(t, [][]byte{test.config, {regConfig}, {regConversion}}, f.writes)
+	}
+
+	for _, ch := range []int{-1, 4} {
+		_, err := a.OutputCode(ch)
+		assert.Equal(t, adc.ErrInvalidChannel{Channel: ch, Channels: 4}, err)
+	}
+}
+
+func TestADS1x15Gain(t *testing.T) {
+	tests := []struct {
+		gain float64
+		fsr  FullScaleRange
+	}{
+		{2.0 / 3, FSR6144},
+		{0.6667, FSR6144},
+		{1, FSR4096},
+		{2, FSR2048},
+		{4, FSR1024},
+		{8, FSR512},
+		{16, FSR256},
+	}
+
+	a, _ := NewADS1015(newFakeADS1x15(), 3300)
+	assert.Equal(t, float64(2), a.Gain())
+
+	for _, test := range tests {
+		assert.Nil(t, a.SetGain(test.gain))
+		assert.Equal(t, test.fsr, a.FSR())
+		assert.InDelta(t, test.gain, a.Gain(), 1e-3)
+	}
+
+	for _, g := range []float64{0, 3, 32} {
+		assert.EqualError(t, a.SetGain(g), fmt.Sprintf("gain of %v is invalid, choose 2/3, 1, 2, 4, 8 or 16", g))
+	}
+	assert.Equal(t, FSR256, a.FSR())
+}
+
+func TestADS1x15Voltage(t *testing.T) {
+	f := newFakeADS1x15()
+	ads1115, _ := NewADS1115(f, 860)
+	ads1015, _ := NewADS1015(f, 3300)
+
+	f.regs[regConversion] = 0x4000
+	v, err := ads1115.Voltage(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.024, v)
+
+	v, err = ads1015.Voltage(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 1.024, v)
+
+	f.regs[regConversion] = 0xc000
+	v, err = ads1115.Voltage(0)
+	assert.Nil(t, err)
+	assert.Equal(t, -1.024, v)
+
+	assert.Equal(t, 2.048/32768, ads1115.Resolution())
+	assert.Equal(t, 2.048/2048, ads1015.Resolution())
+}
+
+func TestADS1x15FullScaleRange(t *testing.T) {
+	tests := []struct {
+		fsr FullScaleRange
+		// config is the config register written for a conversion of
+		// channel 0 at 860 SPS.
+		config []byte
+		// voltage is the voltage decoded from output code 0x4000, which
+		// is half of the positive full scale range.
+		voltage float64
+	}{
+		{FSR6144, []byte{0x01, 0xc1, 0xe3}, 3.072},
+		{FSR4096, []byte{0x01, 0xc3, 0xe3}, 2.048},
+		{FSR2048, []byte{0x01, 0xc5, 0xe3}, 1.024},
+		{FSR1024, []byte{0x01, 0xc7, 0xe3}, 0.512},
+		{FSR512, []byte{0x01, 0xc9, 0xe3}, 0.256},
+		{FSR256, []byte{0x01, 0xcb, 0xe3}, 0.128},
+	}
+
+	for _, test := range tests {
+		f := newFakeADS1x15()
+		f.regs[regConversion] = 0x4000
+
+		a, _ := NewADS1115(f, 860)
+		assert.Equal(t, FSR2048, a.FSR())
+		assert.Nil(t, a.SetFSR(test.fsr))
+		assert.Equal(t, test.fsr, a.FSR())
+
+		v, err := a.Voltage(0)
+		assert.Nil(t, err)
+		assert.Equal(t, test.config, f.writes[0])
+		assert.InDelta(t, test.voltage, v, 1e-9)
+		assert.InDelta(t, test.fsr.Voltage()/32768, a.Resolution(), 1e-12)
+
+		// Full scale negative.
+		f.regs[regConversion] = 0x8000
+		v, err = a.Voltage(0)
+		assert.Nil(t, err)
+		assert.InDelta(t, -test.fsr.Voltage(), v, 1e-9)
+	}
+
+	a, _ := NewADS1015(newFakeADS1x15(), 3300)
+	assert.Nil(t, a.SetFSR(FSR6144))
+	assert.Equal(t, 6.144/2048, a.Resolution())
+
+	for _, fsr := range []FullScaleRange{-1, 6, 7} {
+		assert.EqualError(t, a.SetFSR(fsr), fmt.Sprintf("full scale range %d is invalid", fsr))
+		assert.Equal(t, float64(0), fsr.Voltage())
+	}
+	assert.Equal(t, FSR6144, a.FSR())
+}
+
+func TestADS1x15FullScale(t *testing.T) {
+	tests := []struct {
+		mode       adc.FullScaleMode
+		conversion uint16
+		voltage    float64
+	}{
+		{adc.CountBased, 0x0000, 0},
+		{adc.CountBased, 0x7fff, 2.048 * 32767 / 32768},
+		{adc.CountBased, 0x8000, -2.048},
+		{adc.CodeBased, 0x0000, 0},
+		{adc.CodeBased, 0x7fff, 2.048},
+		{adc.CodeBased, 0x8000, -2.048 * 32768 / 32767},
+	}
+
+	for _, test := range tests {
+		f := newFakeADS1x15()
+		f.regs[regConversion] = test.conversion
+
+		a, _ := NewADS1115(f, 860)
+		a.FullScale = test.mode
+
+		v, err := a.Voltage(0)
+		assert.Nil(t, err)
+		assert.InDelta(t, test.voltage, v, 1e-12)
+	}
+}
+
+func TestADS1x15DataRate(t *testing.T) {
+	f := newFakeADS1x15()
+
+	_, err := NewADS1115(f, 3300)
+	assert.EqualError(t, err, "failed to create ADS1115: 3300 is an invalid value for data rate, use on of [8 16 32 64 128 250 475 860]")
+
+	_, err = NewADS1015(f, 860)
+	assert.EqualError(t, err, "failed to create ADS1015: 860 is an invalid value for data rate, use on of [128 250 490 920 1600 2400 3300]")
+
+	a, _ := NewADS1015(f, 3300)
+	assert.Equal(t, 3300, a.DataRate())
+	assert.Nil(t, a.SetDataRate(128))
+	assert.Equal(t, 128, a.DataRate())
+
+	_, err = a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x01, 0xc5, 0x03}, f.writes[0])
+}
+
+func TestADS1x15WaitsForConversion(t *testing.T) {
+	f := newFakeADS1x15()
+	a, _ := NewADS1115(f, 860)
+
+	f.busy = 3
+	_, err := a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Len(t, f.writes, 6)
+
+	f.busy = 100
+	_, err = a.OutputCode(0)
+	assert.EqualError(t, err, "conversion didn't finish within 2.32558ms")
+}
+
+func TestADS1x15Errors(t *testing.T) {
+	f := newFakeADS1x15()
+	a, _ := NewADS1115(f, 860)
+	assert.Equal(t, 4, a.Channels())
+
+	for _, ch := range []int{-1, 4} {
+		_, err := a.OutputCode(ch)
+		assert.Equal(t, adc.ErrInvalidChannel{Channel: ch, Channels: 4}, err)
+	}
+
+	f.err = errors.New("some error")
+	_, err := a.Voltage(0)
+	assert.EqualError(t, err, "failed to start conversion: some error")
+	assert.True(t, errors.Is(err, adc.ErrBusFailure))
+}
+
+func TestADS1x15ConfigureComparator(t *testing.T) {
+	tests := []struct {
+		new       func(*fakeADS1x15) *ads1x15
+		gain      float64
+		low, high float64
+		lo, hi    uint16
+	}{
+		{newTestADS1115, 2, 1, 1.5, 0x3e80, 0x5dc0},
+		{newTestADS1115, 16, 0.1, 0.2, 0x3200, 0x6400},
+		{newTestADS1115, 2.0 / 3, -3, 3, 0xc180, 0x3e80},
+		{newTestADS1115, 1, -4.096, 4.0959, 0x8000, 0x7fff},
+		{newTestADS1015, 2, 1, 1.5, 0x3e80, 0x5dc0},
+		{newTestADS1015, 8, -0.25, 0.5, 0xc180, 0x7d00},
+	}
+
+	for _, test := range tests {
+		f := newFakeADS1x15()
+		a := test.new(f)
+		assert.Nil(t, a.SetGain(test.gain))
+
+		f.writes = nil
+		assert.Nil(t, a.ConfigureComparator(test.low, test.high, TraditionalComparator, 1))
+		assert.Equal(t, [][]byte{
+			{regLoThresh, byte(test.lo >> 8), byte(test.lo)},
+			{regHiThresh, byte(test.hi >> 8), byte(test.hi)},
+		}, f.writes)
+	}
+}
+
+func newTestADS1115(f *fakeADS1x15) *ads1x15 {
+	a, _ := NewADS1115(f, 860)
+	return &a.ads1x15
+}
+
+func newTestADS1015(f *fakeADS1x15) *ads1x15 {
+	a, _ := NewADS1015(f, 1600)
+	return &a.ads1x15
+}
+
+func TestADS1x15ComparatorConfig(t *testing.T) {
+	tests := []struct {
+		mode   ComparatorMode
+		queue  int
+		config []byte
+	}{
+		{TraditionalComparator, 1, []byte{0x01, 0xc5, 0xe0}},
+		{TraditionalComparator, 2, []byte{0x01, 0xc5, 0xe1}},
+		{WindowComparator, 4, []byte{0x01, 0xc5, 0xf2}},
+	}
+
+	for _, test := range tests {
+		f := newFakeADS1x15()
+		a, _ := NewADS1115(f, 860)
+		assert.Nil(t, a.ConfigureComparator(0, 1, test.mode, test.queue))
+
+		// Conversions use the comparator.
+		f.writes = nil
+		_, err := a.OutputCode(0)
+		assert.Nil(t, err)
+		assert.Equal(t, test.config, f.writes[0])
+
+		// Disabling the comparator restores the config and the
+		// thresholds.
+		f.writes = nil
+		assert.Nil(t, a.DisableComparator())
+		assert.Equal(t, [][]byte{{regConfig, 0x05, 0x83}, {regLoThresh, 0x80, 0x00}, {regHiThresh, 0x7f, 0xff}}, f.writes)
+
+		f.writes = nil
+		_, err = a.OutputCode(0)
+		assert.Nil(t, err)
+		assert.Equal(t, []byte{0x01, 0xc5, 0xe3}, f.writes[0])
+	}
+}
+
+func TestADS1x15ComparatorErrors(t *testing.T) {
+	f := newFakeADS1x15()
+	a, _ := NewADS1115(f, 860)
+
+	assert.EqualError(t, a.ConfigureComparator(1, 1, TraditionalComparator, 1), "low threshold 1V must be below high threshold 1V")
+	assert.EqualError(t, a.ConfigureComparator(0, 1, ComparatorMode(2), 1), "comparator mode 2 is invalid")
+	assert.EqualError(t, a.ConfigureComparator(0, 1, TraditionalComparator, 3), "queue of 3 conversions is invalid, choose 1, 2 or 4")
+	assert.EqualError(t, a.ConfigureComparator(0, 2.1, TraditionalComparator, 1), "threshold of 2.1V is outside the full scale range of +/-2.048V")
+	assert.EqualError(t, a.ConfigureComparator(-2.1, 0, TraditionalComparator, 1), "threshold of -2.1V is outside the full scale range of +/-2.048V")
+	assert.Nil(t, f.writes)
+
+	f.err = errors.New("some error")
+	err := a.ConfigureComparator(0, 1, TraditionalComparator, 1)
+	assert.EqualError(t, err, "failed to write low threshold: some error")
+	assert.True(t, errors.Is(err, adc.ErrBusFailure))
+
+	// The comparator stays disabled when writing the thresholds failed.
+	f.err = nil
+	_, err = a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x01, 0xc5, 0xe3}, f.writes[0])
+}
+
+func TestADS1x15OnAlert(t *testing.T) {
+	f := newFakeADS1x15()
+	a, _ := NewADS1115(f, 860)
+	p := mock.NewMockPin()
+	p.SetValue(1)
+
+	var voltages []float64
+	var errs []error
+	handler := func(v float64, err error) {
+		voltages = append(voltages, v)
+		errs = append(errs, err)
+	}
+
+	assert.EqualError(t, a.OnAlert(0, p, handler), "failed to watch ALERT/RDY: the comparator is disabled")
+	assert.Equal(t, adc.ErrInvalidChannel{Channel: 4, Channels: 4}, a.OnAlert(4, p, handler))
+
+	assert.Nil(t, a.ConfigureComparator(1, 1.5, WindowComparator, 1))
+
+	f.writes = nil
+	assert.Nil(t, a.OnAlert(2, p, handler))
+	// Continuous conversions of AIN2 start.
+	assert.Equal(t, [][]byte{{regConfig, 0x64, 0xf0}}, f.writes)
+
+	edge, _ := p.Edge()
+	assert.Equal(t, gpio.FallingEdge, edge)
+
+	// ALERT/RDY is active low.
+	f.regs[regConversion] = 0x6000
+	p.SetValue(0)
+	p.SetValue(1)
+	assert.Equal(t, []float64{1.536}, voltages)
+	assert.Equal(t, []error{nil}, errs)
+
+	f.err = errors.New("some error")
+	p.SetValue(0)
+	assert.Equal(t, 0.0, voltages[1])
+	assert.EqualError(t, errs[1], "failed to read output code: some error")
+
+	p.Err = errors.New("pin error")
+	assert.EqualError(t, a.OnAlert(0, p, handler), "failed to set direction of ALERT/RDY pin: pin error")
+}
+
+// pgaADS1x15 is a fakeADS1x15 with an input of 0.128V, so the output code
+// depends on the PGA field of the config.
+type pgaADS1x15 struct {
+	*fakeADS1x15
+}
+
+func (f pgaADS1x15) Write(b []byte) error {
+	if err := f.fakeADS1x15.Write(b); err != nil {
+		return err
+	}
+	if len(b) == 3 && b[0] == regConfig {
+		pga := uint(b[1]>>1) & 0x7
+		f.regs[regConversion] = uint16(512 << pga)
+	}
+	return nil
+}
+
+func TestADS1x15ConcurrentVoltage(t *testing.T) {
+	f := newFakeADS1x15()
+	a, err := NewADS1115(pgaADS1x15{f}, 860)
+	assert.Nil(t, err)
+	f.locker = new(testLocker)
+	a.Locker = f.locker
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			assert.Nil(t, a.SetFSR(FSR4096+FullScaleRange(i%5)))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			v, err := a.Voltage(0)
+			assert.Nil(t, err)
+			assert.Equal(t, 0.128, v)
+		}
+	}()
+	wg.Wait()
+
+	assert.False(t, f.unlocked)
+}
+
+// TestADS1x15ConcurrentAlert tests if an alert while a single conversion is
+// read doesn't interleave with the conversion. There's no Locker, so the ADC
+// must serialize the callback itself.
+func TestADS1x15ConcurrentAlert(t *testing.T) {
+	f := newFakeADS1x15()
+	a, err := NewADS1115(pgaADS1x15{f}, 860)
+	assert.Nil(t, err)
+
+	p := mock.NewMockPin()
+	p.SetValue(1)
+	assert.Nil(t, a.ConfigureComparator(0, 0.5, WindowComparator, 1))
+
+	alerts := make(chan error, 50)
+	assert.Nil(t, a.OnAlert(0, p, func(v float64, err error) {
+		alerts <- err
+	}))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			p.SetValue(i % 2)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			v, err := a.Voltage(0)
+			assert.Nil(t, err)
+			assert.Equal(t, 0.128, v)
+		}
+	}()
+	wg.Wait()
+
+	close(alerts)
+	n := 0
+	for err := range alerts {
+		assert.Nil(t, err)
+		n++
+	}
+	assert.Equal(t, 25, n)
+}
+
+func TestADS1x15Logger(t *testing.T) {
+	f := newFakeADS1x15()
+	f.regs[regConversion] = 0x1234
+
+	var buf bytes.Buffer
+	a, _ := NewADS1115(f, 860)
+	a.Logger = log.New(&buf, "", 0)
+
+	_, err := a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "i2c: write 01 c5 e3\ni2c: write 01\ni2c: read c5 e3\ni2c: write 00\ni2c: read 12 34\n", buf.String())
+}
+
+func TestADS1114(t *testing.T) {
+	f := newFakeADS1x15()
+	a, err := NewADS1114(f, 860)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, a.Channels())
+
+	// The ADS1114 has no input multiplexer, the MUX field is 0 for both
+	// input types.
+	f.regs[regConversion] = 0x7fff
+	for _, inputType := range []adc.InputType{adc.SingleEnded, adc.PseudoDifferential} {
+		f.writes = nil
+		a.InputType = inputType
+		code, err := a.OutputCode(0)
+		assert.Nil(t, err)
+		assert.Equal(t, 32767, code)
+		assert.Equal(t, []byte{0x01, 0x85, 0xe3}, f.writes[0])
+	}
+
+	for _, channel := range []int{-1, 1} {
+		_, err = a.OutputCode(channel)
+		assert.Equal(t, adc.ErrInvalidChannel{Channel: channel, Channels: 1}, err)
+	}
+
+	_, err = NewADS1114(f, 3300)
+	assert.EqualError(t, err, "failed to create ADS1114: 3300 is an invalid value for data rate, use on of [8 16 32 64 128 250 475 860]")
+}
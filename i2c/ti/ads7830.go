@@ -0,0 +1,153 @@
+package ti
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/bus"
+)
+
+var _ adc.Sized = (*ADS7830)(nil)
+
+// ADS7830InternalVref is the voltage of the internal reference of the ADS7830.
+const ADS7830InternalVref = 2.5
+
+// The fields of the command byte of the ADS7830.
+const (
+	// cmdSingleEnded selects single-ended inputs, differential inputs are
+	// used when it's 0.
+	cmdSingleEnded = 0x80
+	// cmdRefOn keeps the internal reference powered on.
+	cmdRefOn = 0x08
+	// cmdConverterOn keeps the converter powered on between conversions.
+	cmdConverterOn = 0x04
+)
+
+// ADS7830 is an 8-bits ADC with 8 channels. The channels are either
+// single-ended inputs or 4 pairs of differential inputs. The ADC uses the
+// voltage on its REF pin as reference, unless InternalRef is set.
+type ADS7830 struct {
+	Conn bus.I2C
+	Vref float64
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
+	// InputType selects single-ended or differential inputs. A
+	// differential channel measures its input relative to the other input
+	// of its pair, so channel 0 measures CH0 relative to CH1 and channel 1
+	// measures CH1 relative to CH0. The output code is 0 when the input is
+	// below the other input of its pair.
+	InputType adc.InputType
+
+	// InternalRef enables the internal reference of 2.5V. Vref is ignored
+	// when it's set. Decouple the REF pin with a capacitor when using the
+	// internal reference.
+	InternalRef bool
+
+	// FullScale selects how an output code is converted to a voltage. It
+	// defaults to adc.CountBased, see adc.FullScaleMode.
+	FullScale adc.FullScaleMode
+
+	// Retries is the number of times a failed read is retried before
+	// giving up. Backoff is the time waited between 2 attempts, see
+	// bus.Retry.
+	Retries int
+	Backoff time.Duration
+}
+
+// NewADS7830 returns an ADS7830 that uses vref as reference voltage and the
+// given input type for its channels.
+func NewADS7830(conn bus.I2C, vref float64, inputType adc.InputType) *ADS7830 {
+	return &ADS7830{
+		Conn:      conn,
+		Vref:      vref,
+		InputType: inputType,
+	}
+}
+
+// Channels returns the number of channels, 8.
+func (a ADS7830) Channels() int {
+	return 8
+}
+
+// OutputCode converts the channel and returns its output code, a value
+// between 0 and 255.
+func (a ADS7830) OutputCode(channel int) (int, error) {
+	cmd, err := a.command(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	var code int
+	err = bus.Retry(func() error {
+		// The conversion is started by writing the command byte and
+		// its result is returned by the next read.
+		if err := bus.LogI2C(a.Conn, a.Logger).Write([]byte{cmd}); err != nil {
+			return fmt.Errorf("failed to start conversion: %w", bus.Error{Err: err})
+		}
+
+		in := make([]byte, 1)
+		if err := bus.LogI2C(a.Conn, a.Logger).Read(in); err != nil {
+			return fmt.Errorf("failed to read output code: %w", bus.Error{Err: err})
+		}
+		code = int(in[0])
+		return nil
+	}, a.Retries+1, a.Backoff)
+
+	return code, err
+}
+
+// Voltage converts the channel and returns its voltage.
+func (a ADS7830) Voltage(channel int) (float64, error) {
+	code, err := a.OutputCode(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	return a.Resolution() * float64(code), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (a ADS7830) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, a, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (a ADS7830) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, a, channel)
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (a ADS7830) Resolution() float64 {
+	vref := a.Vref
+	if a.InternalRef {
+		vref = ADS7830InternalVref
+	}
+	return vref / a.FullScale.Denominator(8)
+}
+
+// command returns the command byte that converts the channel.
+func (a ADS7830) command(channel int) (byte, error) {
+	if channel < 0 || channel > 7 {
+		return 0, adc.ErrInvalidChannel{Channel: channel, Channels: 8}
+	}
+
+	// The channel select bits C2 - C0 aren't the number of the channel.
+	// C2 selects the odd channels, C1 and C0 select the pair of channels.
+	// In differential mode that gives the positive input of the pair, so
+	// for both input types channel n uses CHn as (positive) input.
+	cmd := byte(channel&1<<6|channel>>1<<4) | cmdConverterOn
+	if a.InputType == adc.SingleEnded {
+		cmd |= cmdSingleEnded
+	}
+	if a.InternalRef {
+		cmd |= cmdRefOn
+	}
+	return cmd, nil
+}
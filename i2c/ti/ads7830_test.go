@@ -0,0 +1,151 @@
+package ti
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/iotest"
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/exp/io/i2c"
+)
+
+// newADS7830Conn returns a connection to a fake ADS7830 that returns code and
+// sends the command bytes it receives on the returned channel.
+func newADS7830Conn(code byte) (*i2c.Device, chan byte) {
+	cmds := make(chan byte, 1)
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		if w != nil {
+			cmds <- w[0]
+		}
+		if r != nil {
+			r[0] = code
+		}
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x48)
+	return conn, cmds
+}
+
+func TestADS7830Command(t *testing.T) {
+	// The command bytes of table 2 of the datasheet, with the internal
+	// reference off and the converter on.
+	tests := []struct {
+		inputType adc.InputType
+		channel   int
+		cmd       byte
+	}{
+		{adc.SingleEnded, 0, 0x84},
+		{adc.SingleEnded, 1, 0xc4},
+		{adc.SingleEnded, 2, 0x94},
+		{adc.SingleEnded, 3, 0xd4},
+		{adc.SingleEnded, 4, 0xa4},
+		{adc.SingleEnded, 5, 0xe4},
+		{adc.SingleEnded, 6, 0xb4},
+		{adc.SingleEnded, 7, 0xf4},
+
+		// CH0 relative to CH1, CH1 relative to CH0 and so on.
+		{adc.PseudoDifferential, 0, 0x04},
+		{adc.PseudoDifferential, 1, 0x44},
+		{adc.PseudoDifferential, 2, 0x14},
+		{adc.PseudoDifferential, 3, 0x54},
+		{adc.PseudoDifferential, 4, 0x24},
+		{adc.PseudoDifferential, 5, 0x64},
+		{adc.PseudoDifferential, 6, 0x34},
+		{adc.PseudoDifferential, 7, 0x74},
+	}
+
+	for _, test := range tests {
+		conn, cmds := newADS7830Conn(0x80)
+		a := NewADS7830(conn, 5, test.inputType)
+
+		code, err := a.OutputCode(test.channel)
+		assert.Nil(t, err)
+		assert.Equal(t, 0x80, code)
+		assert.Equal(t, test.cmd, <-cmds, "channel %d", test.channel)
+	}
+}
+
+func TestADS7830Voltage(t *testing.T) {
+	conn, cmds := newADS7830Conn(0xff)
+	a := NewADS7830(conn, 5, adc.SingleEnded)
+
+	v, err := a.Voltage(2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 5.0*255/256, v, 1e-9)
+	assert.Equal(t, byte(0x94), <-cmds)
+
+	// The internal reference is used instead of Vref.
+	a.InternalRef = true
+	v, err = a.Voltage(2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 2.5*255/256, v, 1e-9)
+	assert.Equal(t, byte(0x9c), <-cmds)
+
+	a.FullScale = adc.CodeBased
+	assert.InDelta(t, 2.5/255, a.Resolution(), 1e-12)
+}
+
+func TestADS7830Errors(t *testing.T) {
+	conn, _ := newADS7830Conn(0)
+	a := NewADS7830(conn, 5, adc.SingleEnded)
+	assert.Equal(t, 8, a.Channels())
+
+	for _, ch := range []int{-1, 8} {
+		_, err := a.OutputCode(ch)
+		assert.Equal(t, adc.ErrInvalidChannel{Channel: ch, Channels: 8}, err)
+	}
+
+	reads := 0
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(_, r []byte) error {
+		if r == nil {
+			return nil
+		}
+
+		reads++
+		if reads <= 2 {
+			return fmt.Errorf("some error")
+		}
+		r[0] = 0x12
+		return nil
+	})
+
+	conn, _ = i2c.Open(iotest.NewI2CDriver(c), 0x48)
+	a = NewADS7830(conn, 5, adc.SingleEnded)
+	a.Retries = 2
+
+	code, err := a.OutputCode(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0x12, code)
+
+	reads = 0
+	a.Retries = 0
+	_, err = a.Voltage(0)
+	assert.EqualError(t, err, "failed to read output code: some error")
+	assert.True(t, errors.Is(err, adc.ErrBusFailure))
+}
+
+func ExampleADS7830() {
+	dev, err := i2c.Open(&i2c.Devfs{
+		Dev: "/dev/i2c-0",
+	}, 0x48)
+
+	if err != nil {
+		panic(fmt.Sprintf("failed to open device: %v", err))
+	}
+	defer dev.Close()
+
+	a := NewADS7830(dev, 3.3, adc.SingleEnded)
+
+	v, err := a.Voltage(5)
+	if err != nil {
+		panic(fmt.Sprintf("failed to read channel 5: %v", err))
+	}
+
+	fmt.Printf("channel 5 reads %f Volts.", v)
+}
@@ -4,14 +4,67 @@ package ti
 import (
 	"fmt"
 	"math"
+	"sort"
 
-	"golang.org/x/exp/io/i2c"
+	"github.com/advancedclimatesystems/io/bus"
+	"github.com/advancedclimatesystems/io/dac"
+	"github.com/advancedclimatesystems/io/internal/errs"
+)
+
+var (
+	_ dac.MultiChannelDAC = (*DAC5578)(nil)
+	_ dac.MultiChannelDAC = (*DAC6578)(nil)
+	_ dac.MultiChannelDAC = (*DAC7578)(nil)
 )
 
 const (
 	// cmd is the command used to write to DAC input register channel n,
 	// and update DAC register channel n. See table 6 of the datasheet.
 	cmd = 0x30
+
+	// cmdWrite is the command used to write to DAC input register channel
+	// n, without updating the output of the channel.
+	cmdWrite = 0x00
+
+	// cmdWriteUpdateAll is the command used to write to DAC input register
+	// channel n and update all DAC registers.
+	cmdWriteUpdateAll = 0x20
+
+	// cmdReadDAC is the command byte to send before reading the DAC
+	// register of channel n. See table 8 of the datasheet.
+	cmdReadDAC = 0x10
+
+	// broadcast is the access address that addresses all channels at
+	// once. See table 6 of the datasheet.
+	broadcast = 0x0f
+
+	// cmdInternalRef is the command used to write to the internal
+	// reference register.
+	cmdInternalRef = 0x80
+
+	// cmdPowerDown is the command used to power down or power up
+	// channels.
+	cmdPowerDown = 0x40
+
+	// cmdReset is the command used to reset the DAC to its power-on
+	// state.
+	cmdReset = 0x70
+)
+
+// PowerDownMode is the way the outputs of a DACx578 are connected while they
+// are powered down.
+type PowerDownMode int
+
+const (
+	// PowerDown1K connects the outputs to GND through a 1 kΩ resistor.
+	PowerDown1K PowerDownMode = iota + 1
+
+	// PowerDown100K connects the outputs to GND through a 100 kΩ
+	// resistor.
+	PowerDown100K
+
+	// PowerDownHiZ leaves the outputs floating.
+	PowerDownHiZ
 )
 
 // DAC5578 is a 8 channel DAC with a resolution of 8 bits. The datasheet is
@@ -21,12 +74,13 @@ type DAC5578 struct {
 }
 
 // NewDAC5578 returns a new instance of DAC5578.
-func NewDAC5578(conn *i2c.Device, vref float64) *DAC5578 {
+func NewDAC5578(conn bus.I2C, vref float64) *DAC5578 {
 	m := &DAC5578{
 		dacx578: dacx578{
 			conn:       conn,
 			resolution: 8,
 			vref:       vref,
+			extRef:     vref,
 		},
 	}
 	return m
@@ -38,65 +92,331 @@ type DAC6578 struct {
 	dacx578
 }
 
-// NewDAC6578 returns a new instance of DAC5578.
-func NewDAC6578(conn *i2c.Device, vref float64) *DAC5578 {
-	m := &DAC5578{
+// NewDAC6578 returns a new instance of DAC6578.
+func NewDAC6578(conn bus.I2C, vref float64) *DAC6578 {
+	m := &DAC6578{
 		dacx578: dacx578{
 			conn:       conn,
 			resolution: 10,
 			vref:       vref,
+			extRef:     vref,
 		},
 	}
 	return m
 }
 
-// DAC7578 is a 8 channel DAC with a resolution of 10 bits. The datasheet is
+// DAC7578 is a 8 channel DAC with a resolution of 12 bits. The datasheet is
 // here: http://www.ti.com/lit/ds/symlink/dac7578.pdf
 type DAC7578 struct {
 	dacx578
 }
 
-// NewDAC7578 returns a new instance of DAC5578.
-func NewDAC7578(conn *i2c.Device, vref float64) *DAC5578 {
-	m := &DAC5578{
+// NewDAC7578 returns a new instance of DAC7578.
+func NewDAC7578(conn bus.I2C, vref float64) *DAC7578 {
+	m := &DAC7578{
 		dacx578: dacx578{
 			conn:       conn,
 			resolution: 12,
 			vref:       vref,
+			extRef:     vref,
 		},
 	}
 	return m
 }
 
 type dacx578 struct {
-	conn       *i2c.Device
+	conn       bus.I2C
 	resolution int
 	vref       float64
+
+	// extRef is the voltage of the external reference, vref falls back
+	// to it when Reset disables the internal reference.
+	extRef float64
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
 }
 
 // SetVoltage set output voltage of channel. Using the Vref the input code is
-// calculated and then SetInputCode is called.
+// calculated and then SetInputCode is called. The input code is rounded to
+// the nearest code, so the output is as close as possible to v. An error is
+// returned when the DAC has been created with a reference voltage that isn't
+// greater than 0.
 func (d *dacx578) SetVoltage(v float64, channel int) error {
-	code := v * ((math.Pow(2, float64(d.resolution)) - 1) / d.vref)
-	return d.SetInputCode(int(code), channel)
+	if d.vref <= 0 {
+		return fmt.Errorf("reference voltage %v is invalid, it must be greater than 0", d.vref)
+	}
+
+	return d.SetInputCode(dac.VoltageToCode(v, d.resolution, d.vref), channel)
+}
+
+// SetVoltageAll sets the output voltage of all 8 channels to v. All outputs
+// change at once, the DAC is addressed with a single broadcast command.
+func (d *dacx578) SetVoltageAll(v float64) error {
+	if d.vref <= 0 {
+		return fmt.Errorf("reference voltage %v is invalid, it must be greater than 0", d.vref)
+	}
+
+	return d.SetInputCodeAll(dac.VoltageToCode(v, d.resolution, d.vref))
+}
+
+// SetVoltages sets the output voltages of all 8 channels. Element i of
+// voltages is the voltage of channel i. An error is returned if not exactly 8
+// voltages are given.
+//
+// The input registers of all channels are written first, the last write
+// updates the outputs of all channels at once. So the outputs don't change
+// one by one and they don't change at all when a voltage is invalid or a write
+// fails.
+func (d *dacx578) SetVoltages(voltages []float64) error {
+	if len(voltages) != 8 {
+		return fmt.Errorf("got %d voltages, but DAC has 8 channels", len(voltages))
+	}
+
+	channels := make([]int, len(voltages))
+	for ch := range channels {
+		channels[ch] = ch
+	}
+	return d.setVoltages(channels, voltages)
+}
+
+// SetVoltagesMap is like SetVoltages, but it only sets the channels in
+// voltages, which maps a channel to its voltage. The outputs of the other
+// channels are updated too, so they only change when their input register has
+// been written before without updating the output. Nothing is written when
+// voltages is empty.
+func (d *dacx578) SetVoltagesMap(voltages map[int]float64) error {
+	channels := make([]int, 0, len(voltages))
+	for ch := range voltages {
+		channels = append(channels, ch)
+	}
+	sort.Ints(channels)
+
+	vs := make([]float64, len(channels))
+	for i, ch := range channels {
+		vs[i] = voltages[ch]
+	}
+	return d.setVoltages(channels, vs)
+}
+
+// setVoltages writes voltages[i] to the input register of channels[i]. The
+// last write updates the outputs of all channels. Nothing is written when one
+// of the channels or voltages is invalid.
+func (d *dacx578) setVoltages(channels []int, voltages []float64) error {
+	if d.vref <= 0 {
+		return fmt.Errorf("reference voltage %v is invalid, it must be greater than 0", d.vref)
+	}
+
+	frames := make([][]byte, len(voltages))
+	for i, v := range voltages {
+		command := byte(cmdWrite)
+		if i == len(voltages)-1 {
+			command = cmdWriteUpdateAll
+		}
+
+		frame, err := d.frame(command, dac.VoltageToCode(v, d.resolution, d.vref), channels[i])
+		if err != nil {
+			return err
+		}
+		frames[i] = frame
+	}
+
+	for i, frame := range frames {
+		if err := bus.LogI2C(d.conn, d.Logger).Write(frame); err != nil {
+			return fmt.Errorf("failed to write channel %d: %w", channels[i], bus.Error{Err: err})
+		}
+	}
+	return nil
+}
+
+// UseInternalReference enables the internal reference of the DAC and uses v as
+// reference voltage from now on. The internal reference register is only
+// present on variants with an internal reference, like the pin compatible
+// DAC7678. The reference must be greater than 0.
+func (d *dacx578) UseInternalReference(v float64) error {
+	if v <= 0 {
+		return fmt.Errorf("reference voltage %v is invalid, it must be greater than 0", v)
+	}
+
+	// Setting bit 4 of the data enables the internal reference in static
+	// mode, so it stays powered up, even when all channels are powered down.
+	if err := bus.LogI2C(d.conn, d.Logger).Write([]byte{cmdInternalRef, 0x00, 0x10}); err != nil {
+		return fmt.Errorf("failed to enable internal reference: %w", bus.Error{Err: err})
+	}
+
+	d.vref = v
+	return nil
+}
+
+// UseExternalReference uses v as reference voltage from now on. v must be
+// equal to the voltage on the VREFIN pin of the DAC. The reference must be
+// greater than 0.
+func (d *dacx578) UseExternalReference(v float64) error {
+	if v <= 0 {
+		return fmt.Errorf("reference voltage %v is invalid, it must be greater than 0", v)
+	}
+
+	d.vref, d.extRef = v, v
+	return nil
+}
+
+// Resolution returns the voltage of a single step of the input code, that is
+// the smallest difference in output voltage the DAC can produce.
+func (d *dacx578) Resolution() float64 {
+	return dac.CodeToVoltage(1, d.resolution, d.vref)
 }
 
 // SetInputCode writes the digital input code to the DAC
 func (d *dacx578) SetInputCode(code, channel int) error {
+	frame, err := d.frame(cmd, code, channel)
+	if err != nil {
+		return err
+	}
+
+	if err := bus.LogI2C(d.conn, d.Logger).Write(frame); err != nil {
+		return bus.Error{Err: err}
+	}
+	return nil
+}
+
+// SetInputCodeAll writes the digital input code to all 8 channels with a
+// single broadcast command, so all outputs change at once.
+func (d *dacx578) SetInputCodeAll(code int) error {
+	frame, err := d.codeFrame(cmd|broadcast, code)
+	if err != nil {
+		return err
+	}
+
+	if err := bus.LogI2C(d.conn, d.Logger).Write(frame); err != nil {
+		return fmt.Errorf("failed to write all channels: %w", bus.Error{Err: err})
+	}
+	return nil
+}
+
+// OutputCode reads the DAC register of a channel and returns the input code
+// of its output.
+func (d *dacx578) OutputCode(channel int) (int, error) {
 	if channel < 0 || channel > 7 {
-		return fmt.Errorf("%d is not a valid channel", channel)
+		return 0, errs.New(dac.ErrInvalidChannel, "%d is not a valid channel", channel)
+	}
+
+	conn := bus.LogI2C(d.conn, d.Logger)
+	if err := conn.Write([]byte{cmdReadDAC | byte(channel)}); err != nil {
+		return 0, fmt.Errorf("failed to read channel %d: %w", channel, bus.Error{Err: err})
+	}
+
+	// The code is left aligned in 2 bytes, like it's written.
+	in := make([]byte, 2)
+	if err := conn.Read(in); err != nil {
+		return 0, fmt.Errorf("failed to read channel %d: %w", channel, bus.Error{Err: err})
+	}
+	return (int(in[0])<<8 | int(in[1])) >> uint(16-d.resolution), nil
+}
+
+// SelfTest writes the mid-scale code to all 8 channels and reads it back using
+// OutputCode. An error matching dac.ErrSelfTestFailed is returned when a
+// channel reads back another code. The outputs are left at mid-scale, so run
+// it before setting the outputs.
+func (d *dacx578) SelfTest() error {
+	code := 1 << uint(d.resolution-1)
+	for ch := 0; ch < 8; ch++ {
+		if err := d.SetInputCode(code, ch); err != nil {
+			return fmt.Errorf("failed to write channel %d: %w", ch, err)
+		}
+
+		v, err := d.OutputCode(ch)
+		if err != nil {
+			return err
+		}
+		if v != code {
+			return errs.New(dac.ErrSelfTestFailed, "channel %d reads back code %d, but %d has been written", ch, v, code)
+		}
 	}
+	return nil
+}
+
+// PowerDown powers down the channels, their outputs are connected according
+// to mode. The other channels are left as they are. The input and DAC
+// registers keep their codes, PowerUp restores the outputs.
+func (d *dacx578) PowerDown(channels []int, mode PowerDownMode) error {
+	if mode != PowerDown1K && mode != PowerDown100K && mode != PowerDownHiZ {
+		return fmt.Errorf("power-down mode %d is invalid", mode)
+	}
+
+	if err := d.power(channels, mode); err != nil {
+		return fmt.Errorf("failed to power down channels %v: %w", channels, err)
+	}
+	return nil
+}
+
+// PowerUp powers up the channels, the other channels are left as they are.
+func (d *dacx578) PowerUp(channels []int) error {
+	if err := d.power(channels, 0); err != nil {
+		return fmt.Errorf("failed to power up channels %v: %w", channels, err)
+	}
+	return nil
+}
+
+// Reset resets the DAC to its power-on state using the software reset: all
+// registers are cleared and all channels are powered up. The internal
+// reference is disabled, so the reference voltage falls back to the external
+// reference given to the constructor or to UseExternalReference.
+func (d *dacx578) Reset() error {
+	if err := bus.LogI2C(d.conn, d.Logger).Write([]byte{cmdReset, 0x00, 0x00}); err != nil {
+		return fmt.Errorf("failed to reset DAC: %w", bus.Error{Err: err})
+	}
+
+	d.vref = d.extRef
+	return nil
+}
+
+// power writes the power-down register. The data of the request selects the
+// mode in bits 13 and 14, mode 0 powers up. Bits 5 up to 12 select the
+// channels, bit 5 is channel 0.
+//
+// x PD1 PD0 H G F E D   C B A x x x x x
+func (d *dacx578) power(channels []int, mode PowerDownMode) error {
+	if len(channels) == 0 {
+		return errs.New(dac.ErrInvalidChannel, "no channels given")
+	}
+
+	var mask int
+	for _, ch := range channels {
+		if ch < 0 || ch > 7 {
+			return errs.New(dac.ErrInvalidChannel, "%d is not a valid channel", ch)
+		}
+		mask |= 1 << uint(ch)
+	}
+
+	data := int(mode)<<13 | mask<<5
+	if err := bus.LogI2C(d.conn, d.Logger).Write([]byte{cmdPowerDown, byte(data >> 8), byte(data)}); err != nil {
+		return bus.Error{Err: err}
+	}
+	return nil
+}
+
+// frame validates the channel and code and returns the request that sends
+// command with them to the DAC.
+func (d *dacx578) frame(command byte, code, channel int) ([]byte, error) {
+	if channel < 0 || channel > 7 {
+		return nil, errs.New(dac.ErrInvalidChannel, "%d is not a valid channel", channel)
+	}
+
+	return d.codeFrame(command|byte(channel), code)
+}
 
+// codeFrame validates the code and returns the request that sends it with
+// access, the command and the address of the channel.
+func (d *dacx578) codeFrame(access byte, code int) ([]byte, error) {
 	max := int(math.Pow(2, float64(d.resolution)))
 	if code < 0 || code >= max {
-		return fmt.Errorf("digital input code %d is out of range of 0 <= code < %d ", code, max)
+		return nil, errs.New(dac.ErrCodeOutOfRange, "digital input code %d is out of range of 0 <= code < %d ", code, max)
 	}
 
 	// The requests is 3 bytes long. Byte 1 is the command, byte 2 and 3
 	// contain the output code.
-	cmdAccess := byte(cmd | channel)
 	msb := byte((code >> uint(d.resolution-8)) & 0xFF)
 	lsb := byte((code << uint(8-(d.resolution-8))) & 0xFF)
 
-	return d.conn.Write([]byte{cmdAccess, msb, lsb})
+	return []byte{access, msb, lsb}, nil
 }
@@ -15,19 +15,31 @@ func TestDACinterface(t *testing.T) {
 	assert.Implements(t, (*dac.DAC)(nil), new(DAC5578))
 	assert.Implements(t, (*dac.DAC)(nil), new(DAC6578))
 	assert.Implements(t, (*dac.DAC)(nil), new(DAC7578))
+	assert.Implements(t, (*dac.MultiChannelDAC)(nil), new(DAC5578))
 }
 
 func TestNewDACX578(t *testing.T) {
 	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
 
-	dac5578 := NewDAC5578(conn, 3)
-	assert.Equal(t, 8, dac5578.resolution)
+	var dac5578 interface{} = NewDAC5578(conn, 3)
+	assert.IsType(t, &DAC5578{}, dac5578)
+	assert.Equal(t, 8, dac5578.(*DAC5578).resolution)
 
-	dac6578 := NewDAC6578(conn, 3)
-	assert.Equal(t, 10, dac6578.resolution)
+	var dac6578 interface{} = NewDAC6578(conn, 3)
+	assert.IsType(t, &DAC6578{}, dac6578)
+	assert.Equal(t, 10, dac6578.(*DAC6578).resolution)
 
-	dac7578 := NewDAC7578(conn, 3)
-	assert.Equal(t, 12, dac7578.resolution)
+	var dac7578 interface{} = NewDAC7578(conn, 3)
+	assert.IsType(t, &DAC7578{}, dac7578)
+	assert.Equal(t, 12, dac7578.(*DAC7578).resolution)
+}
+
+func TestDACX578Resolution(t *testing.T) {
+	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
+
+	assert.Equal(t, 10.0/255, NewDAC5578(conn, 10).Resolution())
+	assert.Equal(t, 10.0/1023, NewDAC6578(conn, 10).Resolution())
+	assert.Equal(t, 10.0/4095, NewDAC7578(conn, 10).Resolution())
 }
 
 func TestDACX578SetVoltage(t *testing.T) {
@@ -51,15 +63,15 @@ func TestDACX578SetVoltage(t *testing.T) {
 		expected   []byte
 	}{
 		{8, 10, 10, 1, []byte{0x31, 0xff, 0}},
-		{8, 10, 5, 1, []byte{0x31, 0x7f, 0}},
+		{8, 10, 5, 1, []byte{0x31, 0x80, 0}},
 		{8, 10, 0, 2, []byte{0x32, 0x0, 0}},
 		{8, 5, 5, 2, []byte{0x32, 0xff, 0}},
-		{8, 20, 10, 2, []byte{0x32, 0x7f, 0}},
+		{8, 20, 10, 2, []byte{0x32, 0x80, 0}},
 		{10, 10, 10, 2, []byte{0x32, 0xff, 0xc0}},
-		{10, 10, 5, 2, []byte{0x32, 0x7f, 0xc0}},
+		{10, 10, 5, 2, []byte{0x32, 0x80, 0x00}},
 		{10, 10, 0, 2, []byte{0x32, 0x00, 0x00}},
 		{12, 10, 10, 3, []byte{0x33, 0xff, 0xf0}},
-		{12, 10, 5, 3, []byte{0x33, 0x7f, 0xf0}},
+		{12, 10, 5, 3, []byte{0x33, 0x80, 0x00}},
 		{12, 10, 0, 4, []byte{0x34, 0x00, 0x00}},
 	}
 
@@ -101,7 +113,12 @@ func TestDACX578SetVoltageChannelOutOfRange(t *testing.T) {
 
 	for _, test := range tests {
 		err := m.SetVoltage(5, test.channel)
-		assert.Equal(t, test.expected, err)
+		if test.expected == nil {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.expected.Error())
+		assert.True(t, errors.Is(err, dac.ErrInvalidChannel))
 	}
 }
 
@@ -125,9 +142,9 @@ func TestDACX578SetVoltageOutRange(t *testing.T) {
 		{10, 10, 8, nil},
 		{10, 10, 10, nil},
 		{10, 10, 12, nil},
-		{10, 11, 8, errors.New("digital input code 280 is out of range of 0 <= code < 256 ")},
+		{10, 11, 8, errors.New("digital input code 281 is out of range of 0 <= code < 256 ")},
 		{10, 11, 10, errors.New("digital input code 1125 is out of range of 0 <= code < 1024 ")},
-		{10, 11, 12, errors.New("digital input code 4504 is out of range of 0 <= code < 4096 ")},
+		{10, 11, 12, errors.New("digital input code 4505 is out of range of 0 <= code < 4096 ")},
 	}
 
 	for _, test := range tests {
@@ -135,7 +152,29 @@ func TestDACX578SetVoltageOutRange(t *testing.T) {
 		m.vref = test.vref
 
 		err := m.SetVoltage(test.voltage, 1)
-		assert.Equal(t, test.expected, err)
+		if test.expected == nil {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.expected.Error())
+		assert.True(t, errors.Is(err, dac.ErrCodeOutOfRange))
+	}
+}
+
+func TestDACX578SetVoltageWithInvalidVref(t *testing.T) {
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		t.Fatalf("unexpected write %v", w)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	for _, vref := range []float64{0, -10} {
+		d := NewDAC5578(conn, vref)
+		assert.EqualError(
+			t,
+			d.SetVoltage(5, 1),
+			fmt.Sprintf("reference voltage %v is invalid, it must be greater than 0", vref))
 	}
 }
 
@@ -168,3 +207,318 @@ func ExampleDAC5578() {
 		panic(fmt.Sprintf("failed to set voltage using output code: %v", err))
 	}
 }
+
+func TestDACX578SetVoltages(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		writes = append(writes, w)
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	d := NewDAC5578(conn, 2.55)
+
+	// The input registers of channel 0 to 6 are written without updating
+	// their outputs. The write to channel 7 updates all outputs.
+	assert.Nil(t, d.SetVoltages([]float64{0, 0.01, 0.02, 0.03, 0.04, 0.05, 0.06, 2.55}))
+	assert.Len(t, writes, 8)
+	for ch, w := range writes[:7] {
+		assert.Equal(t, []byte{byte(ch), byte(ch), 0}, w)
+	}
+	assert.Equal(t, []byte{0x27, 0xff, 0}, writes[7])
+
+	writes = nil
+	assert.EqualError(t, d.SetVoltages(make([]float64, 7)), "got 7 voltages, but DAC has 8 channels")
+	assert.EqualError(t, d.SetVoltages(make([]float64, 9)), "got 9 voltages, but DAC has 8 channels")
+
+	// Nothing is written when one of the voltages is out of range.
+	err := d.SetVoltages([]float64{0, 0, 0, 0, 0, 0, 3, 0})
+	assert.EqualError(t, err, "digital input code 300 is out of range of 0 <= code < 256 ")
+	assert.True(t, errors.Is(err, dac.ErrCodeOutOfRange))
+	assert.Len(t, writes, 0)
+
+	c.TxFunc(func(w, _ []byte) error {
+		return errors.New("some error")
+	})
+	assert.EqualError(t, d.SetVoltages(make([]float64, 8)), "failed to write channel 0: some error")
+}
+
+func TestDACX578SetVoltagesMap(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		writes = append(writes, w)
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	// The channels are written in order, the last write updates all
+	// outputs.
+	d5578 := NewDAC5578(conn, 2.55)
+	assert.Nil(t, d5578.SetVoltagesMap(map[int]float64{6: 2.55, 1: 0.01, 3: 0.03}))
+	assert.Equal(t, [][]byte{{0x01, 0x01, 0}, {0x03, 0x03, 0}, {0x26, 0xff, 0}}, writes)
+
+	writes = nil
+	d6578 := NewDAC6578(conn, 1.023)
+	assert.Nil(t, d6578.SetVoltagesMap(map[int]float64{2: 0.512}))
+	assert.Equal(t, [][]byte{{0x22, 0x80, 0x00}}, writes)
+
+	writes = nil
+	d7578 := NewDAC7578(conn, 4.095)
+	assert.Nil(t, d7578.SetVoltagesMap(map[int]float64{0: 4.095, 7: 0.001}))
+	assert.Equal(t, [][]byte{{0x00, 0xff, 0xf0}, {0x27, 0x00, 0x10}}, writes)
+
+	// Nothing is written for an empty map, an invalid channel or an
+	// invalid voltage.
+	writes = nil
+	assert.Nil(t, d5578.SetVoltagesMap(nil))
+	err := d5578.SetVoltagesMap(map[int]float64{0: 1, 8: 1})
+	assert.EqualError(t, err, "8 is not a valid channel")
+	assert.True(t, errors.Is(err, dac.ErrInvalidChannel))
+	err = d5578.SetVoltagesMap(map[int]float64{0: 1, 1: 3})
+	assert.True(t, errors.Is(err, dac.ErrCodeOutOfRange))
+	assert.Len(t, writes, 0)
+
+	c.TxFunc(func(w, _ []byte) error {
+		return errors.New("some error")
+	})
+	assert.EqualError(t, d5578.SetVoltagesMap(map[int]float64{5: 1}), "failed to write channel 5: some error")
+}
+
+func TestDACX578SetInputCodeAll(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		writes = append(writes, w)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	tests := []struct {
+		d     *dacx578
+		code  int
+		frame []byte
+	}{
+		{&NewDAC5578(conn, 5).dacx578, 0xab, []byte{0x3f, 0xab, 0x00}},
+		{&NewDAC6578(conn, 5).dacx578, 0x2ab, []byte{0x3f, 0xaa, 0xc0}},
+		{&NewDAC7578(conn, 5).dacx578, 0xabc, []byte{0x3f, 0xab, 0xc0}},
+	}
+
+	for _, test := range tests {
+		writes = nil
+		assert.Nil(t, test.d.SetInputCodeAll(test.code))
+		assert.Equal(t, [][]byte{test.frame}, writes)
+
+		writes = nil
+		err := test.d.SetInputCodeAll(1 << uint(test.d.resolution))
+		assert.True(t, errors.Is(err, dac.ErrCodeOutOfRange))
+		assert.Len(t, writes, 0)
+	}
+
+	d := NewDAC7578(conn, 4.095)
+	writes = nil
+	assert.Nil(t, d.SetVoltageAll(1))
+	assert.Equal(t, [][]byte{{0x3f, 0x3e, 0x80}}, writes)
+
+	d = NewDAC7578(conn, 0)
+	assert.EqualError(t, d.SetVoltageAll(1), "reference voltage 0 is invalid, it must be greater than 0")
+
+	c.TxFunc(func(w, _ []byte) error {
+		return errors.New("some error")
+	})
+	assert.EqualError(t, NewDAC5578(conn, 5).SetInputCodeAll(0), "failed to write all channels: some error")
+}
+
+func TestDACX578UseInternalReference(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		writes = append(writes, w)
+		return nil
+	})
+
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	d := NewDAC7578(conn, 5)
+
+	assert.Nil(t, d.UseInternalReference(2.5))
+	assert.Equal(t, [][]byte{{0x80, 0x00, 0x10}}, writes)
+	assert.Equal(t, 2.5, d.vref)
+
+	writes = nil
+	assert.EqualError(t, d.UseInternalReference(0), "reference voltage 0 is invalid, it must be greater than 0")
+	assert.Len(t, writes, 0)
+	assert.Equal(t, 2.5, d.vref)
+
+	c.TxFunc(func(w, _ []byte) error {
+		return errors.New("some error")
+	})
+	assert.EqualError(t, d.UseInternalReference(4), "failed to enable internal reference: some error")
+	assert.Equal(t, 2.5, d.vref)
+}
+
+func TestDACX578UseExternalReference(t *testing.T) {
+	conn, _ := i2c.Open(iotest.NewI2CDriver(iotest.NewI2CConn()), 0x1)
+	d := NewDAC7578(conn, 5)
+
+	assert.Nil(t, d.UseExternalReference(3.3))
+	assert.Equal(t, 3.3, d.vref)
+	assert.Equal(t, 3.3/4095, d.Resolution())
+
+	assert.EqualError(t, d.UseExternalReference(0), "reference voltage 0 is invalid, it must be greater than 0")
+	assert.EqualError(t, d.UseExternalReference(-1), "reference voltage -1 is invalid, it must be greater than 0")
+	assert.Equal(t, 3.3, d.vref)
+}
+
+func TestDACX578SelfTest(t *testing.T) {
+	assert.Implements(t, (*dac.SelfTester)(nil), new(DAC5578))
+
+	// codes are the DAC registers of the channels, the last write selects
+	// the register to read.
+	codes := make(map[byte][]byte)
+	var read byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, r []byte) error {
+		switch len(w) {
+		case 1:
+			read = w[0] &^ cmdReadDAC
+		case 3:
+			codes[w[0]&^cmd] = append([]byte(nil), w[1:]...)
+		}
+		copy(r, codes[read])
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+
+	d := NewDAC7578(conn, 5)
+	assert.Nil(t, dac.SelfTest(d))
+	for ch := byte(0); ch < 8; ch++ {
+		assert.Equal(t, []byte{0x80, 0x00}, codes[ch])
+	}
+
+	codes[2] = []byte{0xab, 0xc0}
+	code, err := d.OutputCode(2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0xabc, code)
+
+	d5578 := NewDAC5578(conn, 5)
+	codes[2] = []byte{0xab, 0x00}
+	code, err = d5578.OutputCode(2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0xab, code)
+
+	_, err = d5578.OutputCode(8)
+	assert.True(t, errors.Is(err, dac.ErrInvalidChannel))
+
+	// Channel 5 doesn't respond, it reads back 0.
+	c.TxFunc(func(w, r []byte) error {
+		if len(w) == 1 {
+			read = w[0] &^ cmdReadDAC
+		}
+		if read != 5 {
+			copy(r, []byte{0x80, 0x00})
+		}
+		return nil
+	})
+
+	err = d5578.SelfTest()
+	assert.EqualError(t, err, "channel 5 reads back code 0, but 128 has been written")
+	assert.True(t, errors.Is(err, dac.ErrSelfTestFailed))
+
+	c.TxFunc(func(w, r []byte) error {
+		return errors.New("some error")
+	})
+
+	assert.EqualError(t, d5578.SelfTest(), "failed to write channel 0: some error")
+	_, err = d5578.OutputCode(0)
+	assert.EqualError(t, err, "failed to read channel 0: some error")
+}
+
+func TestDACX578PowerDown(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		writes = append(writes, w)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	d := NewDAC7578(conn, 5)
+
+	tests := []struct {
+		channels []int
+		mode     PowerDownMode
+		frame    []byte
+	}{
+		{[]int{0}, PowerDown1K, []byte{0x40, 0x20, 0x20}},
+		{[]int{2}, PowerDown1K, []byte{0x40, 0x20, 0x80}},
+		{[]int{3}, PowerDown100K, []byte{0x40, 0x41, 0x00}},
+		{[]int{7}, PowerDownHiZ, []byte{0x40, 0x70, 0x00}},
+		{[]int{0, 7}, PowerDownHiZ, []byte{0x40, 0x70, 0x20}},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7}, PowerDown100K, []byte{0x40, 0x5f, 0xe0}},
+		// A channel given twice is powered down once.
+		{[]int{5, 5}, PowerDown1K, []byte{0x40, 0x24, 0x00}},
+	}
+
+	for _, test := range tests {
+		writes = nil
+		assert.Nil(t, d.PowerDown(test.channels, test.mode))
+		assert.Equal(t, [][]byte{test.frame}, writes, fmt.Sprintf("%v", test.channels))
+	}
+
+	writes = nil
+	assert.Nil(t, d.PowerUp([]int{1, 6}))
+	assert.Equal(t, [][]byte{{0x40, 0x08, 0x40}}, writes)
+
+	writes = nil
+	err := d.PowerDown([]int{1, 8}, PowerDownHiZ)
+	assert.EqualError(t, err, "failed to power down channels [1 8]: 8 is not a valid channel")
+	assert.True(t, errors.Is(err, dac.ErrInvalidChannel))
+
+	err = d.PowerUp([]int{-1})
+	assert.EqualError(t, err, "failed to power up channels [-1]: -1 is not a valid channel")
+	assert.True(t, errors.Is(err, dac.ErrInvalidChannel))
+
+	err = d.PowerUp(nil)
+	assert.EqualError(t, err, "failed to power up channels []: no channels given")
+	assert.EqualError(t, d.PowerDown([]int{0}, 0), "power-down mode 0 is invalid")
+	assert.EqualError(t, d.PowerDown([]int{0}, 4), "power-down mode 4 is invalid")
+	assert.Len(t, writes, 0)
+
+	c.TxFunc(func(w, _ []byte) error {
+		return errors.New("some error")
+	})
+	assert.EqualError(t, d.PowerDown([]int{0}, PowerDownHiZ), "failed to power down channels [0]: some error")
+	assert.EqualError(t, d.PowerUp([]int{0}), "failed to power up channels [0]: some error")
+}
+
+func TestDACX578Reset(t *testing.T) {
+	var writes [][]byte
+	c := iotest.NewI2CConn()
+	c.TxFunc(func(w, _ []byte) error {
+		writes = append(writes, w)
+		return nil
+	})
+	conn, _ := i2c.Open(iotest.NewI2CDriver(c), 0x1)
+	d := NewDAC7578(conn, 5)
+
+	// The reset disables the internal reference.
+	assert.Nil(t, d.UseInternalReference(2.5))
+	writes = nil
+	assert.Nil(t, d.Reset())
+	assert.Equal(t, [][]byte{{0x70, 0x00, 0x00}}, writes)
+	assert.Equal(t, 5.0, d.vref)
+
+	assert.Nil(t, d.UseExternalReference(3.3))
+	assert.Nil(t, d.UseInternalReference(2.5))
+	assert.Nil(t, d.Reset())
+	assert.Equal(t, 3.3, d.vref)
+
+	// The reference is kept when the reset fails.
+	assert.Nil(t, d.UseInternalReference(2.5))
+	c.TxFunc(func(w, _ []byte) error {
+		return errors.New("some error")
+	})
+	assert.EqualError(t, d.Reset(), "failed to reset DAC: some error")
+	assert.Equal(t, 2.5, d.vref)
+}
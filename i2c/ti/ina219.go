@@ -0,0 +1,144 @@
+package ti
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/advancedclimatesystems/io/bus"
+)
+
+// The registers of the INA219.
+const (
+	ina219RegShunt       = 0x01
+	ina219RegBus         = 0x02
+	ina219RegPower       = 0x03
+	ina219RegCurrent     = 0x04
+	ina219RegCalibration = 0x05
+)
+
+// The bits of the bus voltage register of the INA219.
+const (
+	// ina219OVF is set when the current or power calculation overflowed.
+	ina219OVF = 0x1
+)
+
+// ina219MaxShuntVoltage is the highest shunt voltage the INA219 measures in
+// its default configuration, with a PGA of /8.
+const ina219MaxShuntVoltage = 0.32
+
+// ErrOverflow is returned by the INA219 when it has set its math overflow
+// flag: the current or power exceeded the range of their registers, so they
+// are meaningless. It usually means the current is above the maximum current
+// the INA219 has been calibrated for.
+var ErrOverflow = errors.New("current or power calculation overflowed")
+
+// INA219 is a monitor of current and bus voltage. It measures the voltage
+// over a shunt resistor and the voltage of the bus on the load side of that
+// shunt. The INA219 is used in its default configuration: a bus voltage range
+// of 32V and a shunt voltage range of 320mV, converted continuously. The
+// datasheet is here: http://www.ti.com/lit/ds/symlink/ina219.pdf
+type INA219 struct {
+	Conn bus.I2C
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
+	// currentLSB is the current of a single step of the current register
+	// in amperes. The power register has steps of 20 times this.
+	currentLSB float64
+}
+
+// NewINA219 returns an INA219 with a shunt of shunt ohms. It writes the
+// calibration register so a current up to maxCurrent amperes can be
+// measured with the highest resolution. The calibration is lost when the
+// INA219 resets, so create a new INA219 after a power cycle.
+func NewINA219(conn bus.I2C, shunt, maxCurrent float64) (*INA219, error) {
+	if shunt <= 0 {
+		return nil, fmt.Errorf("shunt of %vΩ is invalid, it must be greater than 0", shunt)
+	}
+	if maxCurrent <= 0 {
+		return nil, fmt.Errorf("max current of %vA is invalid, it must be greater than 0", maxCurrent)
+	}
+	if v := shunt * maxCurrent; v > ina219MaxShuntVoltage {
+		return nil, fmt.Errorf("max current of %vA results in %vV over the shunt, but the INA219 measures at most %vV", maxCurrent, v, ina219MaxShuntVoltage)
+	}
+
+	// The current register has 15 bits plus a sign bit, so the current
+	// has steps of maxCurrent / 2^15. The calibration follows from that,
+	// see section 8.5.1 of the datasheet. Its least significant bit is
+	// always 0.
+	c := math.Floor(0.04096 / (maxCurrent / 32768 * shunt))
+	if c > 0xfffe {
+		return nil, fmt.Errorf("max current of %vA is too low to calibrate the INA219 for a shunt of %vΩ", maxCurrent, shunt)
+	}
+	cal := uint16(c) &^ 1
+
+	// The calibration has been rounded down, so the steps of the current
+	// are slightly larger than requested.
+	i := &INA219{
+		Conn:       conn,
+		currentLSB: 0.04096 / (float64(cal) * shunt),
+	}
+
+	if err := writeRegister16(conn, ina219RegCalibration, cal); err != nil {
+		return nil, fmt.Errorf("failed to write calibration: %w", err)
+	}
+	return i, nil
+}
+
+// ShuntVoltage returns the voltage over the shunt in volts. It's negative
+// when the current flows from the load side to the bus side of the shunt.
+func (i *INA219) ShuntVoltage() (float64, error) {
+	v, err := i.read(ina219RegShunt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read shunt voltage: %w", err)
+	}
+
+	// The register is a two's complement value with steps of 10µV.
+	return float64(int16(v)) * 10e-6, nil
+}
+
+// BusVoltage returns the voltage between the load side of the shunt and
+// ground in volts. When the INA219 flags that the current or power
+// calculation overflowed, the voltage is returned together with ErrOverflow.
+func (i *INA219) BusVoltage() (float64, error) {
+	v, err := i.read(ina219RegBus)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read bus voltage: %w", err)
+	}
+
+	// Bits 15:3 hold the voltage with steps of 4mV.
+	voltage := float64(v>>3) * 4e-3
+	if v&ina219OVF != 0 {
+		return voltage, ErrOverflow
+	}
+	return voltage, nil
+}
+
+// Current returns the current through the shunt in amperes. Like the shunt
+// voltage it's negative when the current flows from the load side.
+func (i *INA219) Current() (float64, error) {
+	v, err := i.read(ina219RegCurrent)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read current: %w", err)
+	}
+
+	return float64(int16(v)) * i.currentLSB, nil
+}
+
+// Power returns the power delivered to the load in watts, that is the bus
+// voltage times the current.
+func (i *INA219) Power() (float64, error) {
+	v, err := i.read(ina219RegPower)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read power: %w", err)
+	}
+
+	return float64(v) * 20 * i.currentLSB, nil
+}
+
+// read reads a register, see readRegister16.
+func (i *INA219) read(reg byte) (uint16, error) {
+	return readRegister16(bus.LogI2C(i.Conn, i.Logger), reg)
+}
@@ -0,0 +1,148 @@
+package ti
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRegisters is a bus.I2C that behaves like the 16 bits registers of a
+// device like the INA219 or TMP102.
+type fakeRegisters struct {
+	pointer byte
+	regs    map[byte]uint16
+	writes  [][]byte
+	err     error
+}
+
+func newFakeRegisters() *fakeRegisters {
+	return &fakeRegisters{regs: make(map[byte]uint16)}
+}
+
+func (f *fakeRegisters) Write(b []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	f.writes = append(f.writes, append([]byte(nil), b...))
+	f.pointer = b[0]
+	if len(b) == 3 {
+		f.regs[b[0]] = uint16(b[1])<<8 | uint16(b[2])
+	}
+	return nil
+}
+
+func (f *fakeRegisters) Read(b []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	v := f.regs[f.pointer]
+	b[0], b[1] = byte(v>>8), byte(v)
+	return nil
+}
+
+func TestNewINA219(t *testing.T) {
+	// Example 1 of the datasheet: a current LSB of 100µA with a shunt of
+	// 0.05Ω results in a calibration of 8192.
+	f := newFakeRegisters()
+	i, err := NewINA219(f, 0.05, 3.2768)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{{0x05, 0x20, 0x00}}, f.writes)
+	assert.InDelta(t, 100e-6, i.currentLSB, 1e-12)
+
+	// The least significant bit of the calibration is always 0.
+	f = newFakeRegisters()
+	_, err = NewINA219(f, 0.1, 3.1)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{{0x05, 0x10, 0xe8}}, f.writes)
+
+	tests := []struct {
+		shunt      float64
+		maxCurrent float64
+		err        string
+	}{
+		{0, 1, "shunt of 0Ω is invalid, it must be greater than 0"},
+		{0.1, -1, "max current of -1A is invalid, it must be greater than 0"},
+		{0.1, 4, "max current of 4A results in 0.4V over the shunt, but the INA219 measures at most 0.32V"},
+		{0.1, 0.1, "max current of 0.1A is too low to calibrate the INA219 for a shunt of 0.1Ω"},
+	}
+
+	for _, test := range tests {
+		f := newFakeRegisters()
+		_, err := NewINA219(f, test.shunt, test.maxCurrent)
+		assert.EqualError(t, err, test.err)
+		assert.Nil(t, f.writes)
+	}
+
+	f = newFakeRegisters()
+	f.err = errors.New("some error")
+	_, err = NewINA219(f, 0.1, 1)
+	assert.EqualError(t, err, "failed to write calibration: some error")
+}
+
+func TestINA219(t *testing.T) {
+	f := newFakeRegisters()
+	i, err := NewINA219(f, 0.05, 3.2768)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		reg byte
+		v   uint16
+		f   func() (float64, error)
+		exp float64
+	}{
+		// The examples of table 7 of the datasheet.
+		{ina219RegShunt, 0x7d00, i.ShuntVoltage, 0.32},
+		{ina219RegShunt, 0x07d0, i.ShuntVoltage, 0.02},
+		{ina219RegShunt, 0xf830, i.ShuntVoltage, -0.02},
+		{ina219RegShunt, 0x8300, i.ShuntVoltage, -0.32},
+		// 3000 steps of 4mV, the CNVR bit is ignored.
+		{ina219RegBus, 0x5dc0, i.BusVoltage, 12},
+		{ina219RegBus, 0x5dc2, i.BusVoltage, 12},
+		{ina219RegCurrent, 0x2710, i.Current, 1},
+		{ina219RegCurrent, 0xd8f0, i.Current, -1},
+		// 100 steps of 2mW.
+		{ina219RegPower, 0x0064, i.Power, 0.2},
+	}
+
+	for _, test := range tests {
+		f.regs[test.reg] = test.v
+		v, err := test.f()
+		assert.Nil(t, err)
+		assert.InDelta(t, test.exp, v, 1e-9, fmt.Sprintf("register 0x%02x: 0x%04x", test.reg, test.v))
+	}
+}
+
+func TestINA219Overflow(t *testing.T) {
+	f := newFakeRegisters()
+	i, err := NewINA219(f, 0.05, 3.2768)
+	assert.Nil(t, err)
+
+	f.regs[ina219RegBus] = 0x5dc3
+	v, err := i.BusVoltage()
+	assert.Equal(t, ErrOverflow, err)
+	assert.InDelta(t, 12, v, 1e-9)
+}
+
+func TestINA219Errors(t *testing.T) {
+	f := newFakeRegisters()
+	i, err := NewINA219(f, 0.05, 3.2768)
+	assert.Nil(t, err)
+
+	f.err = errors.New("some error")
+
+	_, err = i.ShuntVoltage()
+	assert.EqualError(t, err, "failed to read shunt voltage: some error")
+
+	_, err = i.BusVoltage()
+	assert.EqualError(t, err, "failed to read bus voltage: some error")
+
+	_, err = i.Current()
+	assert.EqualError(t, err, "failed to read current: some error")
+
+	_, err = i.Power()
+	assert.EqualError(t, err, "failed to read power: some error")
+}
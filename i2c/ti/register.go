@@ -0,0 +1,27 @@
+package ti
+
+import "github.com/advancedclimatesystems/io/bus"
+
+// readRegister16 selects a 16 bits register using the pointer register of a
+// device like the ADS1115 or INA219 and reads its big-endian value. Errors are
+// wrapped in a bus.Error.
+func readRegister16(conn bus.I2C, reg byte) (uint16, error) {
+	if err := conn.Write([]byte{reg}); err != nil {
+		return 0, bus.Error{Err: err}
+	}
+
+	in := make([]byte, 2)
+	if err := conn.Read(in); err != nil {
+		return 0, bus.Error{Err: err}
+	}
+	return uint16(in[0])<<8 | uint16(in[1]), nil
+}
+
+// writeRegister16 writes v big-endian to a 16 bits register. Errors are
+// wrapped in a bus.Error.
+func writeRegister16(conn bus.I2C, reg byte, v uint16) error {
+	if err := conn.Write([]byte{reg, byte(v >> 8), byte(v)}); err != nil {
+		return bus.Error{Err: err}
+	}
+	return nil
+}
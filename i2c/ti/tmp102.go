@@ -0,0 +1,181 @@
+package ti
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/advancedclimatesystems/io/bus"
+)
+
+// The registers of the TMP102.
+const (
+	tmp102RegTemperature = 0x00
+	tmp102RegConfig      = 0x01
+	tmp102RegTLow        = 0x02
+	tmp102RegTHigh       = 0x03
+)
+
+// The fields of the config register of the TMP102.
+const (
+	// tmp102ConfigCR is the conversion rate, 0.25Hz, 1Hz, 4Hz or 8Hz.
+	tmp102ConfigCR = 0x00c0
+	// tmp102ConfigEM enables the extended mode.
+	tmp102ConfigEM = 0x0010
+)
+
+// tmp102EM is set in the temperature register when the TMP102 is in extended
+// mode.
+const tmp102EM = 0x1
+
+// tmp102LSB is the temperature of a single step of the temperature and
+// threshold registers in °C.
+const tmp102LSB = 0.0625
+
+// TMP102 is a temperature sensor with a resolution of 0.0625°C. In normal mode
+// temperatures are 12 bits and range from -128°C up to 127.9375°C. In
+// extended mode temperatures are 13 bits, so temperatures above 128°C can be
+// measured, up to 150°C. The datasheet is here:
+// http://www.ti.com/lit/ds/symlink/tmp102.pdf
+type TMP102 struct {
+	Conn bus.I2C
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+}
+
+// NewTMP102 returns a new instance of TMP102.
+func NewTMP102(conn bus.I2C) *TMP102 {
+	return &TMP102{
+		Conn: conn,
+	}
+}
+
+// Temperature returns the result of the last conversion in °C. It works in
+// normal and in extended mode, the TMP102 flags the mode of the conversion in
+// the temperature register.
+func (t *TMP102) Temperature() (float64, error) {
+	v, err := t.read(tmp102RegTemperature)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read temperature: %w", err)
+	}
+
+	return tmp102Temperature(v, v&tmp102EM != 0), nil
+}
+
+// SetConversionRate sets the number of conversions per second. Allowed values
+// are 0.25, 1, 4 and 8. The TMP102 starts at 4 conversions per second.
+func (t *TMP102) SetConversionRate(rate float64) error {
+	var cr uint16
+	switch rate {
+	case 0.25:
+		cr = 0x0000
+	case 1:
+		cr = 0x0040
+	case 4:
+		cr = 0x0080
+	case 8:
+		cr = 0x00c0
+	default:
+		return fmt.Errorf("conversion rate of %vHz is invalid, choose 0.25, 1, 4 or 8", rate)
+	}
+
+	return t.updateConfig(tmp102ConfigCR, cr)
+}
+
+// SetExtendedMode enables or disables the extended mode. Until the next
+// conversion the temperature register holds a conversion in the previous
+// mode, Temperature handles that.
+func (t *TMP102) SetExtendedMode(enabled bool) error {
+	var em uint16
+	if enabled {
+		em = tmp102ConfigEM
+	}
+
+	return t.updateConfig(tmp102ConfigEM, em)
+}
+
+// SetThresholds writes the T_LOW and T_HIGH registers with temperatures in °C.
+// In comparator mode, the default, the ALERT pin becomes active when the
+// temperature exceeds high and inactive again when it drops below low. The
+// thresholds are encoded in the current mode, so set them again after
+// changing the mode with SetExtendedMode.
+func (t *TMP102) SetThresholds(low, high float64) error {
+	if low > high {
+		return fmt.Errorf("low threshold of %v°C is above high threshold of %v°C", low, high)
+	}
+
+	config, err := t.read(tmp102RegConfig)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	extended := config&tmp102ConfigEM != 0
+
+	lo, err := tmp102Code(low, extended)
+	if err != nil {
+		return err
+	}
+	hi, err := tmp102Code(high, extended)
+	if err != nil {
+		return err
+	}
+
+	if err := t.write(tmp102RegTLow, lo); err != nil {
+		return fmt.Errorf("failed to write low threshold: %w", err)
+	}
+	if err := t.write(tmp102RegTHigh, hi); err != nil {
+		return fmt.Errorf("failed to write high threshold: %w", err)
+	}
+	return nil
+}
+
+// updateConfig reads the config register and writes it with the bits of mask
+// replaced by v.
+func (t *TMP102) updateConfig(mask, v uint16) error {
+	config, err := t.read(tmp102RegConfig)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := t.write(tmp102RegConfig, config&^mask|v); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// read reads a register, see readRegister16.
+func (t *TMP102) read(reg byte) (uint16, error) {
+	return readRegister16(bus.LogI2C(t.Conn, t.Logger), reg)
+}
+
+// write writes a register, see writeRegister16.
+func (t *TMP102) write(reg byte, v uint16) error {
+	return writeRegister16(bus.LogI2C(t.Conn, t.Logger), reg, v)
+}
+
+// tmp102Temperature converts the value of a temperature or threshold register
+// to °C. The temperature is a two's complement value, left aligned in 12 bits
+// or in 13 bits in extended mode.
+func tmp102Temperature(v uint16, extended bool) float64 {
+	shift := uint(4)
+	if extended {
+		shift = 3
+	}
+	return float64(int16(v)>>shift) * tmp102LSB
+}
+
+// tmp102Code converts a temperature in °C to the value of a threshold
+// register. The temperature is rounded to the nearest step.
+func tmp102Code(temperature float64, extended bool) (uint16, error) {
+	bits, shift := 12, uint(4)
+	if extended {
+		bits, shift = 13, 3
+	}
+
+	min := -math.Pow(2, float64(bits-1)) * tmp102LSB
+	max := (math.Pow(2, float64(bits-1)) - 1) * tmp102LSB
+	if temperature < min || temperature > max {
+		return 0, fmt.Errorf("threshold of %v°C is out of range of %v°C <= threshold <= %v°C", temperature, min, max)
+	}
+
+	return uint16(int16(math.Round(temperature/tmp102LSB)) << shift), nil
+}
@@ -0,0 +1,133 @@
+package ti
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTMP102Temperature(t *testing.T) {
+	f := newFakeRegisters()
+	tmp := NewTMP102(f)
+
+	tests := []struct {
+		v           uint16
+		temperature float64
+	}{
+		// The examples of table 5 of the datasheet, in normal mode.
+		{0x7ff0, 127.9375},
+		{0x6400, 100},
+		{0x1900, 25},
+		{0x0040, 0.25},
+		{0x0010, 0.0625},
+		{0x0000, 0},
+		{0xfff0, -0.0625},
+		{0xffc0, -0.25},
+		{0xe700, -25},
+		{0xc900, -55},
+		// The same table in extended mode, the EM bit is set.
+		{0x4b01, 150},
+		{0x4001, 128},
+		{0x3ff9, 127.9375},
+		{0xf381, -25},
+		{0xe481, -55},
+	}
+
+	for _, test := range tests {
+		f.regs[tmp102RegTemperature] = test.v
+		temperature, err := tmp.Temperature()
+		assert.Nil(t, err)
+		assert.Equal(t, test.temperature, temperature, fmt.Sprintf("0x%04x", test.v))
+	}
+}
+
+func TestTMP102SetConversionRate(t *testing.T) {
+	f := newFakeRegisters()
+	f.regs[tmp102RegConfig] = 0x60a0
+	tmp := NewTMP102(f)
+
+	tests := []struct {
+		rate   float64
+		config uint16
+	}{
+		{0.25, 0x6020},
+		{1, 0x6060},
+		{4, 0x60a0},
+		{8, 0x60e0},
+	}
+
+	for _, test := range tests {
+		assert.Nil(t, tmp.SetConversionRate(test.rate))
+		assert.Equal(t, test.config, f.regs[tmp102RegConfig])
+	}
+
+	assert.EqualError(t, tmp.SetConversionRate(2), "conversion rate of 2Hz is invalid, choose 0.25, 1, 4 or 8")
+	assert.Equal(t, uint16(0x60e0), f.regs[tmp102RegConfig])
+}
+
+func TestTMP102SetExtendedMode(t *testing.T) {
+	f := newFakeRegisters()
+	f.regs[tmp102RegConfig] = 0x60a0
+	tmp := NewTMP102(f)
+
+	assert.Nil(t, tmp.SetExtendedMode(true))
+	assert.Equal(t, uint16(0x60b0), f.regs[tmp102RegConfig])
+
+	assert.Nil(t, tmp.SetExtendedMode(false))
+	assert.Equal(t, uint16(0x60a0), f.regs[tmp102RegConfig])
+}
+
+func TestTMP102SetThresholds(t *testing.T) {
+	f := newFakeRegisters()
+	f.regs[tmp102RegConfig] = 0x60a0
+	tmp := NewTMP102(f)
+
+	// The values of T_LOW and T_HIGH after power on.
+	assert.Nil(t, tmp.SetThresholds(75, 80))
+	assert.Equal(t, uint16(0x4b00), f.regs[tmp102RegTLow])
+	assert.Equal(t, uint16(0x5000), f.regs[tmp102RegTHigh])
+
+	assert.Nil(t, tmp.SetThresholds(-25, -0.04))
+	assert.Equal(t, uint16(0xe700), f.regs[tmp102RegTLow])
+	assert.Equal(t, uint16(0xfff0), f.regs[tmp102RegTHigh])
+
+	// In extended mode the thresholds are 13 bits.
+	f.regs[tmp102RegConfig] = 0x60b0
+	assert.Nil(t, tmp.SetThresholds(-25, 150))
+	assert.Equal(t, uint16(0xf380), f.regs[tmp102RegTLow])
+	assert.Equal(t, uint16(0x4b00), f.regs[tmp102RegTHigh])
+
+	f = newFakeRegisters()
+	tmp = NewTMP102(f)
+
+	tests := []struct {
+		low  float64
+		high float64
+		err  string
+	}{
+		{30, 20, "low threshold of 30°C is above high threshold of 20°C"},
+		{-129, 20, "threshold of -129°C is out of range of -128°C <= threshold <= 127.9375°C"},
+		{20, 150, "threshold of 150°C is out of range of -128°C <= threshold <= 127.9375°C"},
+	}
+
+	for _, test := range tests {
+		assert.EqualError(t, tmp.SetThresholds(test.low, test.high), test.err)
+		assert.NotContains(t, f.regs, byte(tmp102RegTLow))
+		assert.NotContains(t, f.regs, byte(tmp102RegTHigh))
+	}
+}
+
+func TestTMP102Errors(t *testing.T) {
+	f := newFakeRegisters()
+	f.err = errors.New("some error")
+	tmp := NewTMP102(f)
+
+	_, err := tmp.Temperature()
+	assert.EqualError(t, err, "failed to read temperature: some error")
+
+	assert.EqualError(t, tmp.SetConversionRate(1), "failed to read config: some error")
+	assert.EqualError(t, tmp.SetExtendedMode(true), "failed to read config: some error")
+	assert.EqualError(t, tmp.SetThresholds(20, 30), "failed to read config: some error")
+}
@@ -0,0 +1,28 @@
+// Package errs creates errors that carry their own message, but match one of
+// the exported sentinel errors of this repository when compared using
+// errors.Is.
+package errs
+
+import "fmt"
+
+// New returns an error with a message formatted according to format. The error
+// wraps target, so errors.Is(err, target) reports true.
+func New(target error, format string, a ...interface{}) error {
+	return &sentinelError{
+		msg:    fmt.Sprintf(format, a...),
+		target: target,
+	}
+}
+
+type sentinelError struct {
+	msg    string
+	target error
+}
+
+func (e *sentinelError) Error() string {
+	return e.msg
+}
+
+func (e *sentinelError) Unwrap() error {
+	return e.target
+}
@@ -0,0 +1,19 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	target := errors.New("target")
+
+	err := New(target, "channel %d is invalid", 3)
+	assert.EqualError(t, err, "channel 3 is invalid")
+	assert.True(t, errors.Is(err, target))
+	assert.True(t, errors.Is(fmt.Errorf("failed: %w", err), target))
+	assert.False(t, errors.Is(err, errors.New("target")))
+}
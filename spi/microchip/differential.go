@@ -0,0 +1,49 @@
+package microchip
+
+import (
+	"fmt"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/internal/errs"
+)
+
+// DifferentialPair selects the 2 inputs of a pseudo-differential measurement
+// and their polarity. The value of a DifferentialPair is the value of the
+// channel selection bits the ADCs expect in pseudo-differential mode, so
+// CH1PosCH0Neg is 1 and sets the ODD/SIGN bit.
+type DifferentialPair int
+
+// The differential pairs. The 2 channel ADCs only support the first 2 pairs,
+// the 4 channel ADCs the first 4 pairs and the 8 channel ADCs all pairs.
+const (
+	// CH0PosCH1Neg measures CH0 relative to CH1.
+	CH0PosCH1Neg DifferentialPair = iota
+	// CH1PosCH0Neg measures CH1 relative to CH0.
+	CH1PosCH0Neg
+	// CH2PosCH3Neg measures CH2 relative to CH3.
+	CH2PosCH3Neg
+	// CH3PosCH2Neg measures CH3 relative to CH2.
+	CH3PosCH2Neg
+	// CH4PosCH5Neg measures CH4 relative to CH5.
+	CH4PosCH5Neg
+	// CH5PosCH4Neg measures CH5 relative to CH4.
+	CH5PosCH4Neg
+	// CH6PosCH7Neg measures CH6 relative to CH7.
+	CH6PosCH7Neg
+	// CH7PosCH6Neg measures CH7 relative to CH6.
+	CH7PosCH6Neg
+)
+
+// String returns the inputs of the pair, like "CH0+/CH1-".
+func (p DifferentialPair) String() string {
+	return fmt.Sprintf("CH%d+/CH%d-", int(p), int(p)^1)
+}
+
+// checkPair returns an error if pair isn't a differential pair of an ADC with
+// the given number of channels. The error matches adc.ErrInvalidChannel.
+func checkPair(pair DifferentialPair, channels int) error {
+	if pair < 0 || int(pair) >= channels {
+		return errs.New(adc.ErrInvalidChannel{}, "differential pair %d is invalid, ADC has only %d channels", pair, channels)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package microchip
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/stretchr/testify/assert"
+)
+
+type differentialADC interface {
+	VoltageDifferential(DifferentialPair) (float64, error)
+}
+
+func TestVoltageDifferential(t *testing.T) {
+	tests := []struct {
+		adc  func(c testConn) differentialADC
+		pair DifferentialPair
+		// cmd is the command send to the ADC. The ODD/SIGN bit is the
+		// least significant bit of the channel selection bits.
+		cmd []byte
+	}{
+		{func(c testConn) differentialADC { return MCP3002{Conn: c, InputType: adc.SingleEnded} }, CH0PosCH1Neg, []byte{0x48, 0}},
+		{func(c testConn) differentialADC { return MCP3002{Conn: c} }, CH1PosCH0Neg, []byte{0x58, 0}},
+		{func(c testConn) differentialADC { return MCP3202{Conn: c, InputType: adc.SingleEnded} }, CH0PosCH1Neg, []byte{1, 0x20, 0}},
+		{func(c testConn) differentialADC { return MCP3202{Conn: c} }, CH1PosCH0Neg, []byte{1, 0x60, 0}},
+		{func(c testConn) differentialADC { return MCP3004{Conn: c, InputType: adc.SingleEnded} }, CH0PosCH1Neg, []byte{1, 0x00, 0}},
+		{func(c testConn) differentialADC { return MCP3004{Conn: c} }, CH1PosCH0Neg, []byte{1, 0x10, 0}},
+		{func(c testConn) differentialADC { return MCP3004{Conn: c} }, CH2PosCH3Neg, []byte{1, 0x20, 0}},
+		{func(c testConn) differentialADC { return MCP3004{Conn: c} }, CH3PosCH2Neg, []byte{1, 0x30, 0}},
+		{func(c testConn) differentialADC { return MCP3008{Conn: c} }, CH4PosCH5Neg, []byte{1, 0x40, 0}},
+		{func(c testConn) differentialADC { return MCP3008{Conn: c} }, CH5PosCH4Neg, []byte{1, 0x50, 0}},
+		{func(c testConn) differentialADC { return MCP3008{Conn: c} }, CH6PosCH7Neg, []byte{1, 0x60, 0}},
+		{func(c testConn) differentialADC { return MCP3008{Conn: c, InputType: adc.SingleEnded} }, CH7PosCH6Neg, []byte{1, 0x70, 0}},
+		{func(c testConn) differentialADC { return MCP3204{Conn: c, InputType: adc.SingleEnded} }, CH0PosCH1Neg, []byte{0x04, 0x00, 0}},
+		{func(c testConn) differentialADC { return MCP3204{Conn: c} }, CH1PosCH0Neg, []byte{0x04, 0x40, 0}},
+		{func(c testConn) differentialADC { return MCP3204{Conn: c} }, CH3PosCH2Neg, []byte{0x04, 0xc0, 0}},
+		{func(c testConn) differentialADC { return MCP3208{Conn: c} }, CH4PosCH5Neg, []byte{0x05, 0x00, 0}},
+		{func(c testConn) differentialADC { return MCP3208{Conn: c} }, CH7PosCH6Neg, []byte{0x05, 0xc0, 0}},
+		{func(c testConn) differentialADC { return MCP3302{Conn: c, InputType: adc.SingleEnded} }, CH0PosCH1Neg, []byte{0x08, 0x00, 0}},
+		{func(c testConn) differentialADC { return MCP3302{Conn: c} }, CH1PosCH0Neg, []byte{0x08, 0x80, 0}},
+		{func(c testConn) differentialADC { return MCP3302{Conn: c} }, CH3PosCH2Neg, []byte{0x09, 0x80, 0}},
+		{func(c testConn) differentialADC { return MCP3304{Conn: c} }, CH6PosCH7Neg, []byte{0x0b, 0x00, 0}},
+		{func(c testConn) differentialADC { return MCP3304{Conn: c} }, CH7PosCH6Neg, []byte{0x0b, 0x80, 0}},
+	}
+
+	for _, test := range tests {
+		var cmd []byte
+		c := testConn{
+			tx: func(w, r []byte) error {
+				cmd = w
+				return nil
+			},
+		}
+
+		_, err := test.adc(c).VoltageDifferential(test.pair)
+		assert.Nil(t, err)
+		assert.Equal(t, test.cmd, cmd, "pair %v", test.pair)
+	}
+}
+
+func TestVoltageDifferentialWithInvalidPairs(t *testing.T) {
+	tests := []struct {
+		adc  differentialADC
+		pair DifferentialPair
+		err  string
+	}{
+		{MCP3002{}, CH2PosCH3Neg, "differential pair 2 is invalid, ADC has only 2 channels"},
+		{MCP3202{}, -1, "differential pair -1 is invalid, ADC has only 2 channels"},
+		{MCP3004{}, CH4PosCH5Neg, "differential pair 4 is invalid, ADC has only 4 channels"},
+		{MCP3204{}, CH7PosCH6Neg, "differential pair 7 is invalid, ADC has only 4 channels"},
+		{MCP3302{}, CH5PosCH4Neg, "differential pair 5 is invalid, ADC has only 4 channels"},
+		{MCP3008{}, 8, "differential pair 8 is invalid, ADC has only 8 channels"},
+		{MCP3208{}, 8, "differential pair 8 is invalid, ADC has only 8 channels"},
+		{MCP3304{}, 8, "differential pair 8 is invalid, ADC has only 8 channels"},
+	}
+
+	for _, test := range tests {
+		_, err := test.adc.VoltageDifferential(test.pair)
+		assert.EqualError(t, err, test.err)
+		assert.True(t, errors.Is(err, adc.ErrInvalidChannel{}))
+	}
+}
+
+func TestDifferentialPairString(t *testing.T) {
+	assert.Equal(t, "CH0+/CH1-", CH0PosCH1Neg.String())
+	assert.Equal(t, "CH1+/CH0-", CH1PosCH0Neg.String())
+	assert.Equal(t, "CH6+/CH7-", CH6PosCH7Neg.String())
+	assert.Equal(t, "CH7+/CH6-", CH7PosCH6Neg.String())
+}
@@ -0,0 +1,145 @@
+package microchip
+
+import (
+	"fmt"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/bus"
+)
+
+// frame describes the SPI frame used to query a channel of an ADC. The
+// command is a start bit, followed by the SGL/DIFF bit and the bits selecting
+// the channel. While the command is clocked in, the ADC clocks out the result
+// aligned to the end of the frame, so the command is padded with 0's at the
+// start of the frame. Adding an ADC is a matter of adding its frame to the
+// table below.
+//
+// Bit positions are counted from the least significant bit of the last byte
+// of the frame, which is 0.
+type frame struct {
+	// size is the number of bytes of the frame.
+	size int
+
+	// start is the position of the start bit. The SGL/DIFF bit directly
+	// follows it, it's 1 for single-ended input. ADCs that don't take a
+	// command, like the MCP3001, have no start bit, start is 0 for them.
+	start uint
+
+	// channel is the position of the least significant bit of the bits
+	// selecting the channel.
+	channel uint
+
+	// set are the bits that are always set in the command.
+	set int
+
+	// bits is the number of bits of the result and shift the number of bits
+	// received after the result.
+	bits  uint
+	shift uint
+
+	// decode converts the result to an output code. It's nil for ADCs with
+	// an unsigned output code, the result is the output code then.
+	decode func(v int) int
+}
+
+// The frames of the ADCs in this package.
+var (
+	// The MCP3001 clocks out 2 undefined bits and a null bit, followed by
+	// the result. After B0 it sends the result again, least significant bit
+	// first, so B1 - B3 follow.
+	//
+	// x x 0 1 1 1 1 1   1 1 1 1 1 x x x
+	//     | --------------------- B9 - B0
+	//     ----------------------- null bit
+	frame3001 = frame{size: 2, bits: 10, shift: 3}
+
+	// The MCP3201 is like the MCP3001, but its result is 12 bits, so only
+	// B1 follows.
+	//
+	// x x 0 1 1 1 1 1   1 1 1 1 1 1 1 x
+	//     | ------------------------- B11 - B0
+	//     --------------------------- null bit
+	frame3201 = frame{size: 2, bits: 12, shift: 1}
+
+	// The command of the MCP3002 is a leading 0, the start bit, SGL/DIFF,
+	// ODD/SIGN and MSBF. MSBF is always set, so the ADC sends the result
+	// only once, most significant bit first. The ADC then clocks out a null
+	// bit and the result.
+	//
+	// 0 1 x x 1 0 0 0   0 0 0 0 0 0 0 0
+	//   | | | | ------- 3 don't care bits, during which the null bit and B9
+	//   | | | |         and B8 are received.
+	//   | | | --------- MSBF
+	//   | | ----------- ODD/SIGN, selecting the channel
+	//   | ------------- SGL/DIFF
+	//   --------------- start bit
+	frame3002 = frame{size: 2, start: 14, channel: 12, set: 1 << 11, bits: 10}
+
+	// The MCP3202 is like the MCP3002, but its frame is 3 bytes, so it fits
+	// the 12 bits of the result.
+	//
+	// 0 0 0 0 0 0 0 1   x x 1 0 0 0 0 0   0 0 0 0 0 0 0 0
+	frame3202 = frame{size: 3, start: 16, channel: 14, set: 1 << 13, bits: 12}
+
+	// The MCP3004 and MCP3008 take 3 bits selecting the channel.
+	//
+	// 0 0 0 0 0 0 0 1   x x x x 0 0 0 0   0 0 0 0 0 0 0 0
+	//               |   | ------- 3 bits selecting the channel
+	//               |   --------- SGL/DIFF
+	//               ------------- start bit
+	frame300x = frame{size: 3, start: 16, channel: 12, bits: 10}
+
+	// The MCP3204 and MCP3208 are like the MCP3004 and MCP3008, but the
+	// command starts 2 bits earlier to fit the 12 bits of the result.
+	//
+	// 0 0 0 0 0 1 x x   x x 0 0 0 0 0 0   0 0 0 0 0 0 0 0
+	frame320x = frame{size: 3, start: 18, channel: 14, bits: 12}
+
+	// The MCP3302 and MCP3304 send a sign bit before the 12 bits of the
+	// result. Together they form a 13 bits two's complement value.
+	//
+	// 0 0 0 0 1 x x x   x 0 0 0 0 0 0 0   0 0 0 0 0 0 0 0
+	frame330x = frame{size: 3, start: 19, channel: 15, bits: 13, decode: signed13}
+)
+
+// tx queries a channel. The first half of buf is used for the command, the
+// second half for the response, so buf must be at least twice the size of the
+// frame.
+func (f frame) tx(conn bus.SPI, channel int, inputType adc.InputType, buf []byte) (int, error) {
+	var cmd int
+	if f.start > 0 {
+		cmd = 1<<f.start | channel<<f.channel | f.set
+		if inputType == adc.SingleEnded {
+			cmd |= 1 << (f.start - 1)
+		}
+	}
+
+	// For every byte send the SPI master reads a byte.
+	out, in := buf[:f.size], buf[f.size:2*f.size]
+	for i := range out {
+		out[i] = byte(cmd >> (8 * uint(f.size-1-i)))
+	}
+
+	if err := conn.Tx(out, in); err != nil {
+		return 0, fmt.Errorf("failed to read channel %d: %w", channel, bus.Error{Err: err})
+	}
+
+	var v int
+	for _, b := range in {
+		v = v<<8 | int(b)
+	}
+	v = v >> f.shift & (1<<f.bits - 1)
+
+	if f.decode != nil {
+		return f.decode(v), nil
+	}
+	return v, nil
+}
+
+// signed13 converts a 13 bits two's complement value to an int.
+func signed13(v int) int {
+	if v&0x1000 != 0 {
+		v -= 0x2000
+	}
+	return v
+}
@@ -0,0 +1,51 @@
+package microchip
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFrameTx(t *testing.T) {
+	tests := []struct {
+		f         frame
+		channel   int
+		inputType adc.InputType
+		cmd       []byte
+		resp      []byte
+		code      int
+	}{
+		// Without a start bit no command is send.
+		{frame3001, 0, adc.SingleEnded, []byte{0, 0}, []byte{0xff, 0xf8}, 0x3ff},
+		{frame3201, 0, adc.SingleEnded, []byte{0, 0}, []byte{0x0a, 0xbc}, 0x55e},
+		{frame3002, 1, adc.SingleEnded, []byte{0x78, 0}, []byte{0xff, 0xff}, 0x3ff},
+		{frame3202, 1, adc.PseudoDifferential, []byte{1, 0x60, 0}, []byte{0xff, 0xe8, 0x00}, 0x800},
+		{frame300x, 7, adc.SingleEnded, []byte{1, 0xf0, 0}, []byte{0xff, 0xfe, 0xb7}, 0x2b7},
+		{frame320x, 3, adc.PseudoDifferential, []byte{4, 0xc0, 0}, []byte{0xff, 0xec, 0xb7}, 0xcb7},
+		// The result of the MCP330x is signed.
+		{frame330x, 0, adc.SingleEnded, []byte{0x0c, 0, 0}, []byte{0xff, 0xdf, 0xff}, -1},
+		// A frame with a dummy byte, in which the ADC clocks out nothing.
+		{frame{size: 3, start: 12, channel: 8, bits: 8}, 2, adc.SingleEnded, []byte{0, 0x1a, 0}, []byte{0xff, 0xff, 0x42}, 0x42},
+	}
+
+	for _, test := range tests {
+		c := testConn{
+			tx: func(w, r []byte) error {
+				assert.Equal(t, test.cmd, w, fmt.Sprintf("%+v", test.f))
+				copy(r, test.resp)
+				return nil
+			},
+		}
+
+		code, err := test.f.tx(c, test.channel, test.inputType, make([]byte, 6))
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code, fmt.Sprintf("%+v", test.f))
+	}
+
+	c := testConn{tx: func(w, r []byte) error { return errors.New("some error") }}
+	_, err := frame300x.tx(c, 2, adc.SingleEnded, make([]byte, 6))
+	assert.EqualError(t, err, "failed to read channel 2: some error")
+}
@@ -0,0 +1,222 @@
+package microchip
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/bus"
+	"golang.org/x/exp/io/spi"
+)
+
+var (
+	_ adc.Sized = MCP3302{}
+	_ adc.Sized = MCP3304{}
+)
+
+// MCP3302 is a 13-bits ADC with 4 single-ended or 2 differential inputs. The
+// result of a differential measurement is signed, it is negative when IN- is
+// higher than IN+. Single-ended measurements are never negative, those have a
+// resolution of 12 bits.
+// Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21697F.pdf
+type MCP3302 struct {
+	Conn bus.SPI
+
+	// Vref is the voltage on the reference input of the ADC.
+	Vref float64
+
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
+	InputType adc.InputType
+}
+
+// NewMCP3302 returns an MCP3302 that uses conn and vref. The maximum clock
+// of conn is set to 1.05 MHz, at 5V the ADC can be clocked at 2.1 MHz.
+func NewMCP3302(conn *spi.Device, vref float64) (*MCP3302, error) {
+	if err := configure(conn, 1050000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3302: %w", err)
+	}
+	return &MCP3302{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
+
+// Channels returns the number of channels, 4.
+func (m MCP3302) Channels() int {
+	return 4
+}
+
+// OutputCode queries the channel and returns its digital output code. When
+// InputType is adc.PseudoDifferential, channel is the value of one of the
+// differential pairs CH0PosCH1Neg - CH3PosCH2Neg. Use VoltageDifferential to
+// select the pair explicitly.
+func (m MCP3302) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return read(bus.LogSPI(m.Conn, m.Logger), channel, 4, m.InputType, frame330x)
+}
+
+// Voltage returns the voltage of a channel.
+func (m MCP3302) Voltage(channel int) (float64, error) {
+	code, err := m.OutputCode(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Resolution() * float64(code), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3302) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3302) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// VoltageDifferential returns the voltage of CH+ relative to CH- of a
+// differential pair, regardless of InputType. An error is returned when the
+// MCP3302 doesn't have the pair.
+func (m MCP3302) VoltageDifferential(pair DifferentialPair) (float64, error) {
+	if err := checkPair(pair, 4); err != nil {
+		return 0, err
+	}
+
+	m.InputType = adc.PseudoDifferential
+	return m.Voltage(int(pair))
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3302) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(12)
+}
+
+// Raw sends out to the MCP3302 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3302) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
+
+// MCP3304 is a 13-bits ADC with 8 single-ended or 4 differential inputs. The
+// result of a differential measurement is signed, it is negative when IN- is
+// higher than IN+. Single-ended measurements are never negative, those have a
+// resolution of 12 bits.
+// Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21697F.pdf
+type MCP3304 struct {
+	Conn bus.SPI
+
+	// Vref is the voltage on the reference input of the ADC.
+	Vref float64
+
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
+	InputType adc.InputType
+}
+
+// NewMCP3304 returns an MCP3304 that uses conn and vref. The maximum clock
+// of conn is set to 1.05 MHz, at 5V the ADC can be clocked at 2.1 MHz.
+func NewMCP3304(conn *spi.Device, vref float64) (*MCP3304, error) {
+	if err := configure(conn, 1050000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3304: %w", err)
+	}
+	return &MCP3304{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
+
+// Channels returns the number of channels, 8.
+func (m MCP3304) Channels() int {
+	return 8
+}
+
+// OutputCode queries the channel and returns its digital output code. When
+// InputType is adc.PseudoDifferential, channel is the value of one of the
+// differential pairs CH0PosCH1Neg - CH7PosCH6Neg. Use VoltageDifferential to
+// select the pair explicitly.
+func (m MCP3304) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return read(bus.LogSPI(m.Conn, m.Logger), channel, 8, m.InputType, frame330x)
+}
+
+// Voltage returns the voltage of a channel.
+func (m MCP3304) Voltage(channel int) (float64, error) {
+	code, err := m.OutputCode(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Resolution() * float64(code), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3304) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3304) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// VoltageDifferential returns the voltage of CH+ relative to CH- of a
+// differential pair, regardless of InputType. An error is returned when the
+// MCP3304 doesn't have the pair.
+func (m MCP3304) VoltageDifferential(pair DifferentialPair) (float64, error) {
+	if err := checkPair(pair, 8); err != nil {
+		return 0, err
+	}
+
+	m.InputType = adc.PseudoDifferential
+	return m.Voltage(int(pair))
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3304) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(12)
+}
+
+// Raw sends out to the MCP3304 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3304) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
@@ -0,0 +1,113 @@
+package microchip
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead13Command(t *testing.T) {
+	tests := []struct {
+		channel   int
+		inputType adc.InputType
+		cmd       []byte
+	}{
+		{0, adc.SingleEnded, []byte{0x0c, 0x00, 0}},
+		{1, adc.SingleEnded, []byte{0x0c, 0x80, 0}},
+		{5, adc.SingleEnded, []byte{0x0e, 0x80, 0}},
+		{7, adc.SingleEnded, []byte{0x0f, 0x80, 0}},
+		// CH0 is IN+ and CH1 is IN-.
+		{0, adc.PseudoDifferential, []byte{0x08, 0x00, 0}},
+		// CH1 is IN+ and CH0 is IN-.
+		{1, adc.PseudoDifferential, []byte{0x08, 0x80, 0}},
+		// CH6 is IN+ and CH7 is IN-.
+		{6, adc.PseudoDifferential, []byte{0x0b, 0x00, 0}},
+	}
+
+	for _, test := range tests {
+		c := testConn{
+			tx: func(w, r []byte) error {
+				assert.Equal(t, test.cmd, w)
+				return nil
+			},
+		}
+
+		_, err := read(c, test.channel, 8, test.inputType, frame330x)
+		assert.Nil(t, err)
+	}
+}
+
+func TestMCP330x(t *testing.T) {
+	tests := []struct {
+		resp []byte
+		code int
+	}{
+		{[]byte{0xff, 0xc0, 0x00}, 0},
+		{[]byte{0xff, 0xc0, 0x01}, 1},
+		{[]byte{0xff, 0xc8, 0x00}, 2048},
+		// Full scale positive.
+		{[]byte{0xff, 0xcf, 0xff}, 4095},
+		// -1 LSB.
+		{[]byte{0xff, 0xdf, 0xff}, -1},
+		{[]byte{0xff, 0xd8, 0x00}, -2048},
+		// Full scale negative.
+		{[]byte{0xff, 0xd0, 0x00}, -4096},
+	}
+
+	for _, test := range tests {
+		c := testConn{
+			tx: func(w, r []byte) error {
+				copy(r, test.resp)
+				return nil
+			},
+		}
+
+		adcs := []adc.ADC{
+			MCP3302{Conn: c, Vref: 4.096, InputType: adc.PseudoDifferential},
+			MCP3304{Conn: c, Vref: 4.096, InputType: adc.PseudoDifferential},
+		}
+
+		for _, a := range adcs {
+			code, err := a.OutputCode(1)
+			assert.Nil(t, err)
+			assert.Equal(t, test.code, code)
+
+			v, err := a.Voltage(1)
+			assert.Nil(t, err)
+			assert.Equal(t, float64(test.code)/1000, v)
+		}
+	}
+}
+
+func TestMCP330xWithInvalidChannels(t *testing.T) {
+	var tests = []struct {
+		adc      adc.ADC
+		channel  int
+		channels int
+	}{
+		{MCP3302{}, -1, 4},
+		{MCP3302{}, 4, 4},
+		{MCP3304{}, -1, 8},
+		{MCP3304{}, 8, 8},
+	}
+
+	for _, test := range tests {
+		_, err := test.adc.OutputCode(test.channel)
+		assert.Equal(t, adc.ErrInvalidChannel{Channel: test.channel, Channels: test.channels}, err)
+	}
+}
+
+func TestMCP330xWithFailingConnection(t *testing.T) {
+	c := testConn{
+		tx: func(w, r []byte) error {
+			return fmt.Errorf("some error occured")
+		},
+	}
+
+	for _, a := range []adc.ADC{MCP3302{Conn: c}, MCP3304{Conn: c}} {
+		_, err := a.Voltage(1)
+		assert.EqualError(t, err, "failed to read channel 1: some error occured")
+	}
+}
@@ -0,0 +1,184 @@
+package microchip
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/bus"
+	"golang.org/x/exp/io/spi"
+)
+
+var (
+	_ adc.Sized = MCP3001{}
+	_ adc.Sized = MCP3201{}
+)
+
+// MCP3001 is 10-bits ADC with a single pseudo-differential input. The only
+// valid channel is 0.
+// Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21293C.pdf
+type MCP3001 struct {
+	Conn bus.SPI
+
+	// Vref is the voltage on the reference input of the ADC.
+	Vref float64
+
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+}
+
+// NewMCP3001 returns an MCP3001 that uses conn and vref. The maximum clock
+// of conn is set to 750 kHz, at 5V the ADC can be clocked at 2.8 MHz.
+func NewMCP3001(conn *spi.Device, vref float64) (*MCP3001, error) {
+	if err := configure(conn, 750000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3001: %w", err)
+	}
+	return &MCP3001{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
+
+// Channels returns the number of channels, 1.
+func (m MCP3001) Channels() int {
+	return 1
+}
+
+// OutputCode queries the channel and returns its digital output code.
+func (m MCP3001) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return read(bus.LogSPI(m.Conn, m.Logger), channel, 1, adc.PseudoDifferential, frame3001)
+}
+
+// Voltage returns the voltage of a channel.
+func (m MCP3001) Voltage(channel int) (float64, error) {
+	code, err := m.OutputCode(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Resolution() * float64(code), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3001) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3001) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3001) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(10)
+}
+
+// Raw sends out to the MCP3001 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3001) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
+
+// MCP3201 is 12-bits ADC with a single pseudo-differential input. The only
+// valid channel is 0.
+// Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21290F.pdf
+type MCP3201 struct {
+	Conn bus.SPI
+
+	// Vref is the voltage on the reference input of the ADC.
+	Vref float64
+
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+}
+
+// NewMCP3201 returns an MCP3201 that uses conn and vref. The maximum clock
+// of conn is set to 800 kHz, at 5V the ADC can be clocked at 1.6 MHz.
+func NewMCP3201(conn *spi.Device, vref float64) (*MCP3201, error) {
+	if err := configure(conn, 800000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3201: %w", err)
+	}
+	return &MCP3201{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
+
+// Channels returns the number of channels, 1.
+func (m MCP3201) Channels() int {
+	return 1
+}
+
+// OutputCode queries the channel and returns its digital output code.
+func (m MCP3201) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return read(bus.LogSPI(m.Conn, m.Logger), channel, 1, adc.PseudoDifferential, frame3201)
+}
+
+// Voltage returns the voltage of a channel.
+func (m MCP3201) Voltage(channel int) (float64, error) {
+	code, err := m.OutputCode(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Resolution() * float64(code), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3201) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3201) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3201) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(12)
+}
+
+// Raw sends out to the MCP3201 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3201) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
@@ -0,0 +1,110 @@
+package microchip
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMCP3001(t *testing.T) {
+	var tests = []struct {
+		resp []byte
+		code int
+	}{
+		// Zero scale.
+		{[]byte{0x00, 0x00}, 0},
+		// Full scale, the undefined leading bits are high.
+		{[]byte{0xdf, 0xff}, 1023},
+		// 0x2aa, followed by B1 - B3 least significant bit first.
+		{[]byte{0x15, 0x55}, 682},
+		// 0x200, followed by B1 - B3 least significant bit first.
+		{[]byte{0x10, 0x00}, 512},
+	}
+
+	for _, test := range tests {
+		c := testConn{
+			tx: func(w, r []byte) error {
+				assert.Equal(t, []byte{0, 0}, w)
+				copy(r, test.resp)
+				return nil
+			},
+		}
+
+		m := MCP3001{Conn: c, Vref: 5}
+
+		code, err := m.OutputCode(0)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+
+		v, err := m.Voltage(0)
+		assert.Nil(t, err)
+		assert.Equal(t, 5.0/1024*float64(test.code), v)
+	}
+}
+
+func TestMCP3201(t *testing.T) {
+	var tests = []struct {
+		resp []byte
+		code int
+	}{
+		// Zero scale.
+		{[]byte{0x00, 0x00}, 0},
+		// Full scale, the undefined leading bits are high.
+		{[]byte{0xdf, 0xfe}, 4095},
+		// 0xaaa, followed by B1.
+		{[]byte{0x15, 0x55}, 2730},
+		// 0x800, followed by B1.
+		{[]byte{0x10, 0x00}, 2048},
+	}
+
+	for _, test := range tests {
+		c := testConn{
+			tx: func(w, r []byte) error {
+				assert.Equal(t, []byte{0, 0}, w)
+				copy(r, test.resp)
+				return nil
+			},
+		}
+
+		m := MCP3201{Conn: c, Vref: 5}
+
+		code, err := m.OutputCode(0)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+
+		v, err := m.Voltage(0)
+		assert.Nil(t, err)
+		assert.Equal(t, 5.0/4096*float64(test.code), v)
+	}
+}
+
+func TestMCP3x01WithInvalidChannels(t *testing.T) {
+	for _, a := range []adc.ADC{MCP3001{}, MCP3201{}} {
+		for _, ch := range []int{-1, 1} {
+			_, err := a.OutputCode(ch)
+			assert.Equal(t, adc.ErrInvalidChannel{Channel: ch, Channels: 1}, err)
+		}
+	}
+}
+
+func TestMCP3x01WithFailingConnection(t *testing.T) {
+	c := testConn{
+		tx: func(w, r []byte) error {
+			return fmt.Errorf("some error occured")
+		},
+	}
+
+	for _, a := range []adc.ADC{MCP3001{Conn: c}, MCP3201{Conn: c}} {
+		_, err := a.Voltage(0)
+		assert.EqualError(t, err, "failed to read channel 0: some error occured")
+		assert.True(t, errors.Is(err, adc.ErrBusFailure))
+	}
+}
+
+func TestMCP3x01Resolution(t *testing.T) {
+	assert.Equal(t, 5.0/1024, MCP3001{Vref: 5}.Resolution())
+	assert.Equal(t, 5.0/4096, MCP3201{Vref: 5}.Resolution())
+}
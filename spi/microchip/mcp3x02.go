@@ -0,0 +1,222 @@
+package microchip
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/bus"
+	"golang.org/x/exp/io/spi"
+)
+
+var (
+	_ adc.Sized = MCP3002{}
+	_ adc.Sized = MCP3202{}
+)
+
+// MCP3002 is 10-bits ADC with 2 single-ended inputs or 1 pseudo-differential
+// input.
+// Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21294E.pdf
+//
+// When InputType is adc.PseudoDifferential, channel 0 measures CH0PosCH1Neg and
+// channel 1 measures CH1PosCH0Neg. Use VoltageDifferential to select the pair
+// explicitly.
+type MCP3002 struct {
+	Conn bus.SPI
+
+	// Vref is the voltage on the reference input of the ADC. On the
+	// MCP3002 that is the supply voltage.
+	Vref float64
+
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
+	InputType adc.InputType
+}
+
+// NewMCP3002 returns an MCP3002 that uses conn and vref. The maximum clock
+// of conn is set to 1.2 MHz, at 5V the ADC can be clocked at 3.2 MHz.
+func NewMCP3002(conn *spi.Device, vref float64) (*MCP3002, error) {
+	if err := configure(conn, 1200000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3002: %w", err)
+	}
+	return &MCP3002{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
+
+// Channels returns the number of channels, 2.
+func (m MCP3002) Channels() int {
+	return 2
+}
+
+// OutputCode queries the channel and returns its digital output code.
+func (m MCP3002) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return read(bus.LogSPI(m.Conn, m.Logger), channel, 2, m.InputType, frame3002)
+}
+
+// Voltage returns the voltage of a channel.
+func (m MCP3002) Voltage(channel int) (float64, error) {
+	code, err := m.OutputCode(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Resolution() * float64(code), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3002) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3002) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// VoltageDifferential returns the voltage of CH+ relative to CH- of a
+// differential pair, regardless of InputType. An error is returned when the
+// MCP3002 doesn't have the pair.
+func (m MCP3002) VoltageDifferential(pair DifferentialPair) (float64, error) {
+	if err := checkPair(pair, 2); err != nil {
+		return 0, err
+	}
+
+	m.InputType = adc.PseudoDifferential
+	return m.Voltage(int(pair))
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3002) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(10)
+}
+
+// Raw sends out to the MCP3002 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3002) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
+
+// MCP3202 is 12-bits ADC with 2 single-ended inputs or 1 pseudo-differential
+// input.
+// Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21034F.pdf
+//
+// When InputType is adc.PseudoDifferential, channel 0 measures CH0PosCH1Neg and
+// channel 1 measures CH1PosCH0Neg. Use VoltageDifferential to select the pair
+// explicitly.
+type MCP3202 struct {
+	Conn bus.SPI
+
+	// Vref is the voltage on the reference input of the ADC. On the
+	// MCP3202 that is the supply voltage.
+	Vref float64
+
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
+	InputType adc.InputType
+}
+
+// NewMCP3202 returns an MCP3202 that uses conn and vref. The maximum clock
+// of conn is set to 900 kHz, at 5V the ADC can be clocked at 1.8 MHz.
+func NewMCP3202(conn *spi.Device, vref float64) (*MCP3202, error) {
+	if err := configure(conn, 900000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3202: %w", err)
+	}
+	return &MCP3202{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
+
+// Channels returns the number of channels, 2.
+func (m MCP3202) Channels() int {
+	return 2
+}
+
+// OutputCode queries the channel and returns its digital output code.
+func (m MCP3202) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return read(bus.LogSPI(m.Conn, m.Logger), channel, 2, m.InputType, frame3202)
+}
+
+// Voltage returns the voltage of a channel.
+func (m MCP3202) Voltage(channel int) (float64, error) {
+	code, err := m.OutputCode(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Resolution() * float64(code), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3202) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3202) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// VoltageDifferential returns the voltage of CH+ relative to CH- of a
+// differential pair, regardless of InputType. An error is returned when the
+// MCP3202 doesn't have the pair.
+func (m MCP3202) VoltageDifferential(pair DifferentialPair) (float64, error) {
+	if err := checkPair(pair, 2); err != nil {
+		return 0, err
+	}
+
+	m.InputType = adc.PseudoDifferential
+	return m.Voltage(int(pair))
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3202) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(12)
+}
+
+// Raw sends out to the MCP3202 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3202) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
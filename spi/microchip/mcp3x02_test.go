@@ -0,0 +1,122 @@
+package microchip
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMCP3002(t *testing.T) {
+	var tests = []struct {
+		channel   int
+		inputType adc.InputType
+		cmd       []byte
+		resp      []byte
+		code      int
+	}{
+		{0, adc.SingleEnded, []byte{0x68, 0}, []byte{0xfc, 0x00}, 0},
+		{1, adc.SingleEnded, []byte{0x78, 0}, []byte{0xfe, 0x00}, 512},
+		{0, adc.PseudoDifferential, []byte{0x48, 0}, []byte{0xff, 0xff}, 1023},
+		{1, adc.PseudoDifferential, []byte{0x58, 0}, []byte{0x01, 0x55}, 341},
+	}
+
+	for _, test := range tests {
+		c := testConn{
+			tx: func(w, r []byte) error {
+				assert.Equal(t, test.cmd, w)
+				copy(r, test.resp)
+				return nil
+			},
+		}
+
+		m := MCP3002{
+			Conn:      c,
+			Vref:      3.3,
+			InputType: test.inputType,
+		}
+
+		code, err := m.OutputCode(test.channel)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+
+		v, err := m.Voltage(test.channel)
+		assert.Nil(t, err)
+		assert.Equal(t, 3.3/1024*float64(test.code), v)
+	}
+}
+
+func TestMCP3202(t *testing.T) {
+	var tests = []struct {
+		channel   int
+		inputType adc.InputType
+		cmd       []byte
+		resp      []byte
+		code      int
+	}{
+		{0, adc.SingleEnded, []byte{1, 0xa0, 0}, []byte{0xff, 0xe0, 0x00}, 0},
+		{1, adc.SingleEnded, []byte{1, 0xe0, 0}, []byte{0xff, 0xe8, 0x00}, 2048},
+		{0, adc.PseudoDifferential, []byte{1, 0x20, 0}, []byte{0xff, 0xff, 0xff}, 4095},
+		{1, adc.PseudoDifferential, []byte{1, 0x60, 0}, []byte{0x00, 0x05, 0x55}, 1365},
+	}
+
+	for _, test := range tests {
+		c := testConn{
+			tx: func(w, r []byte) error {
+				assert.Equal(t, test.cmd, w)
+				copy(r, test.resp)
+				return nil
+			},
+		}
+
+		m := MCP3202{
+			Conn:      c,
+			Vref:      5,
+			InputType: test.inputType,
+		}
+
+		code, err := m.OutputCode(test.channel)
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+
+		v, err := m.Voltage(test.channel)
+		assert.Nil(t, err)
+		assert.Equal(t, 5.0/4096*float64(test.code), v)
+	}
+}
+
+func TestMCP3x02WithInvalidChannels(t *testing.T) {
+	var tests = []struct {
+		adc     adc.ADC
+		channel int
+	}{
+		{MCP3002{}, -1},
+		{MCP3002{}, 2},
+		{MCP3202{}, -1},
+		{MCP3202{}, 2},
+	}
+
+	for _, test := range tests {
+		_, err := test.adc.OutputCode(test.channel)
+		assert.Equal(t, adc.ErrInvalidChannel{Channel: test.channel, Channels: 2}, err)
+	}
+}
+
+func TestMCP3x02WithFailingConnection(t *testing.T) {
+	c := testConn{
+		tx: func(w, r []byte) error {
+			return fmt.Errorf("some error occured")
+		},
+	}
+
+	for _, a := range []adc.ADC{MCP3002{Conn: c}, MCP3202{Conn: c}} {
+		_, err := a.Voltage(1)
+		assert.EqualError(t, err, "failed to read channel 1: some error occured")
+	}
+}
+
+func TestMCP3x02Resolution(t *testing.T) {
+	assert.Equal(t, 3.3/1024, MCP3002{Vref: 3.3}.Resolution())
+	assert.Equal(t, 3.3/4096, MCP3202{Vref: 3.3}.Resolution())
+}
@@ -1,37 +1,113 @@
 // Package microchip implements drivers for a few SPI controlled chips produced
 // by Microchip.
+//
+// The drivers communicate using a bus.SPI, an interface with only a Tx method.
+// A *spi.Device of golang.org/x/exp/io/spi implements it, but so can a fake
+// connection in a test or any other SPI backend.
+//
+// The constructors, like NewMCP3008, set the SPI mode of the device to mode 0
+// and its clock to the maximum clock frequency of the ADC at a supply voltage
+// of 2.7V, so it works at any supply voltage. The ADCs can be clocked faster
+// at a higher supply voltage, call SetMaxSpeed of the device to do so.
+//
+// The 4 and 8 channel ADCs can oversample: Samples is the number of
+// conversions done back-to-back for a single output code. The output codes of
+// these conversions are reduced to a single code using Reduce, which defaults
+// to adc.Mean. 0 and 1 both result in a single conversion. Retries is the
+// number of times a failed transfer is retried before giving up and Backoff
+// is the time waited between 2 attempts, see bus.Retry. Invalid channels
+// aren't retried.
+//
+// Every OutputCode, OutputCodeAll and Raw call holds the Locker of the driver
+// during its transactions, so the frames of concurrent calls don't interleave
+// on the bus. The constructors, like NewMCP3008, give every driver a Locker of
+// its own. Drivers that share a SPI bus, for example using bus.ChipSelect,
+// must share the same Locker instead. A driver without a Locker isn't safe for
+// concurrent use.
 package microchip
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/advancedclimatesystems/io/adc"
+	"github.com/advancedclimatesystems/io/bus"
 	"golang.org/x/exp/io/spi"
 )
 
+var (
+	_ adc.MultiChannelADC = MCP3004{}
+	_ adc.MultiChannelADC = MCP3008{}
+	_ adc.MultiChannelADC = MCP3204{}
+	_ adc.MultiChannelADC = MCP3208{}
+
+	_ adc.Sized = MCP3004{}
+	_ adc.Sized = MCP3008{}
+	_ adc.Sized = MCP3204{}
+	_ adc.Sized = MCP3208{}
+)
+
 // MCP3004 is 10-bits ADC with 4 single-ended or 2 pseudo-differential inputs.
 // Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21295C.pdf
 type MCP3004 struct {
-	Conn *spi.Device
+	Conn bus.SPI
 
 	// Vref is the voltage on the reference input of the ADC.
 	Vref float64
 
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
 	InputType adc.InputType
+
+	// Samples and Reduce configure oversampling, Retries and Backoff the
+	// retries of failed transfers. See the package documentation.
+	Samples int
+	Reduce  adc.Reducer
+	Retries int
+	Backoff time.Duration
+
+	// Scan configures the conversions of ReadScan, see NewScanConfig.
+	Scan ScanConfig
 }
 
-// OutputCode queries the channel and returns its digital output code.
-func (m MCP3004) OutputCode(channel int) (int, error) {
-	if channel < 0 || channel > 3 {
-		return 0, fmt.Errorf("channel %d is invalid, ADC has only 4 channels", channel)
+// NewMCP3004 returns an MCP3004 that uses conn and vref. The maximum clock
+// of conn is set to 1.35 MHz, at 5V the ADC can be clocked at 3.6 MHz.
+func NewMCP3004(conn *spi.Device, vref float64) (*MCP3004, error) {
+	if err := configure(conn, 1350000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3004: %w", err)
 	}
+	return &MCP3004{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
 
-	code, err := read10(m.Conn, channel, m.InputType)
-	if err != nil {
-		return 0, err
-	}
+// Channels returns the number of channels, 4.
+func (m MCP3004) Channels() int {
+	return 4
+}
 
-	return code, nil
+// OutputCode queries the channel and returns its digital output code. When
+// InputType is adc.PseudoDifferential, channel is the value of one of the
+// differential pairs CH0PosCH1Neg - CH3PosCH2Neg. Use VoltageDifferential to
+// select the pair explicitly.
+func (m MCP3004) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return adc.Oversample(m.Samples, m.Reduce, func() (int, error) {
+		return retry(m.Retries, m.Backoff, func() (int, error) {
+			return read(bus.LogSPI(m.Conn, m.Logger), channel, 4, m.InputType, frame300x)
+		})
+	})
 }
 
 // Voltage returns the voltage of a channel.
@@ -41,32 +117,142 @@ func (m MCP3004) Voltage(channel int) (float64, error) {
 		return 0, err
 	}
 
-	return (m.Vref / 1024) * float64(code), nil
+	return m.Resolution() * float64(code), nil
+}
+
+// OutputCodeAll queries all 4 channels and returns their digital output codes.
+// Element i of the result is the output code of channel i. The same buffers are
+// used to query every channel.
+func (m MCP3004) OutputCodeAll() ([]int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return readAll(bus.LogSPI(m.Conn, m.Logger), 4, m.InputType, m.Samples, m.Reduce, m.Retries, m.Backoff, frame300x)
+}
+
+// VoltageAll returns the voltages of all 4 channels. Element i of the result
+// is the voltage of channel i.
+func (m MCP3004) VoltageAll() ([]float64, error) {
+	codes, err := m.OutputCodeAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return voltages(codes, m.Resolution()), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3004) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3004) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// VoltageDifferential returns the voltage of CH+ relative to CH- of a
+// differential pair, regardless of InputType. An error is returned when the
+// MCP3004 doesn't have the pair.
+func (m MCP3004) VoltageDifferential(pair DifferentialPair) (float64, error) {
+	if err := checkPair(pair, 4); err != nil {
+		return 0, err
+	}
+
+	m.InputType = adc.PseudoDifferential
+	return m.Voltage(int(pair))
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3004) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(10)
+}
+
+// Raw sends out to the MCP3004 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3004) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
+
+// ReadScan performs the conversions configured by Scan, each with its own
+// input type, and returns their voltages by name.
+func (m MCP3004) ReadScan() (map[string]float64, error) {
+	return readScan(m.Scan, func(channel int, inputType adc.InputType) (float64, error) {
+		m.InputType = inputType
+		return m.Voltage(channel)
+	})
 }
 
 // MCP3008 is 10-bits ADC with 8 single-ended or 4 pseudo-differential inputs.
 // Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21295C.pdf
 type MCP3008 struct {
-	Conn *spi.Device
+	Conn bus.SPI
 
 	// Vref is the voltage on the reference input of the ADC.
 	Vref float64
 
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
 	InputType adc.InputType
+
+	// Samples and Reduce configure oversampling, Retries and Backoff the
+	// retries of failed transfers. See the package documentation.
+	Samples int
+	Reduce  adc.Reducer
+	Retries int
+	Backoff time.Duration
+
+	// Scan configures the conversions of ReadScan, see NewScanConfig.
+	Scan ScanConfig
 }
 
-// OutputCode queries the channel and returns its digital output code.
-func (m MCP3008) OutputCode(channel int) (int, error) {
-	if channel < 0 || channel > 7 {
-		return 0, fmt.Errorf("channel %d is invalid, ADC has only 7 channels", channel)
+// NewMCP3008 returns an MCP3008 that uses conn and vref. The maximum clock
+// of conn is set to 1.35 MHz, at 5V the ADC can be clocked at 3.6 MHz.
+func NewMCP3008(conn *spi.Device, vref float64) (*MCP3008, error) {
+	if err := configure(conn, 1350000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3008: %w", err)
 	}
+	return &MCP3008{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
 
-	code, err := read10(m.Conn, channel, m.InputType)
-	if err != nil {
-		return 0, err
-	}
+// Channels returns the number of channels, 8.
+func (m MCP3008) Channels() int {
+	return 8
+}
 
-	return code, nil
+// OutputCode queries the channel and returns its digital output code. When
+// InputType is adc.PseudoDifferential, channel is the value of one of the
+// differential pairs CH0PosCH1Neg - CH7PosCH6Neg. Use VoltageDifferential to
+// select the pair explicitly.
+func (m MCP3008) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return adc.Oversample(m.Samples, m.Reduce, func() (int, error) {
+		return retry(m.Retries, m.Backoff, func() (int, error) {
+			return read(bus.LogSPI(m.Conn, m.Logger), channel, 8, m.InputType, frame300x)
+		})
+	})
 }
 
 // Voltage returns the voltage of a channel.
@@ -76,90 +262,155 @@ func (m MCP3008) Voltage(channel int) (float64, error) {
 		return 0, err
 	}
 
-	return (m.Vref / 1024) * float64(code), nil
+	return m.Resolution() * float64(code), nil
 }
 
-// read10 reads a 10 bits value from an channel of an ADC.
-func read10(conn *spi.Device, channel int, inputType adc.InputType) (int, error) {
-	var cmd int
+// OutputCodeAll queries all 8 channels and returns their digital output codes.
+// Element i of the result is the output code of channel i. The same buffers are
+// used to query every channel.
+func (m MCP3008) OutputCodeAll() ([]int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return readAll(bus.LogSPI(m.Conn, m.Logger), 8, m.InputType, m.Samples, m.Reduce, m.Retries, m.Backoff, frame300x)
+}
 
-	// The first bit after the start bit will determine if the conversion
-	// is done using single-ended or differential input mode. 0 means
-	// differential, 1 means single-ended.
-	if inputType == adc.SingleEnded {
-		cmd = 1
+// VoltageAll returns the voltages of all 8 channels. Element i of the result
+// is the voltage of channel i.
+func (m MCP3008) VoltageAll() ([]float64, error) {
+	codes, err := m.OutputCodeAll()
+	if err != nil {
+		return nil, err
 	}
-	// The bit is then shifted 3 times and the number is incremented with
-	// a 3 bits channel.
-	cmd = cmd << 3
-	cmd += channel
-
-	// The result is shifted 4 times so the high nibble of the byte
-	// contains 4 bits of data.
-	//
-	// 1 1 1 1 x x x x
-	// | | | | ------- 4 empty bits.
-	// | ------------- 3 bits selecting a channel
-	// --------------- The bit defining single-ended or pseudo-differential input mode.
-	cmd = cmd << 4
-
-	// The first byte contains a start bit, the second byte contains the
-	// actual data and the third byte is another empty byte.
-	out := []byte{1, byte(cmd), 0}
-
-	// For every byte send the SPI master reads a byte. Because we send 3
-	// bytes we read 3 bytes.
-	in := make([]byte, 3)
 
-	if err := conn.Tx(out, in); err != nil {
-		return 0, fmt.Errorf("failed to read channel %d: %v", channel, err)
+	return voltages(codes, m.Resolution()), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3008) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3008) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// VoltageDifferential returns the voltage of CH+ relative to CH- of a
+// differential pair, regardless of InputType. An error is returned when the
+// MCP3008 doesn't have the pair.
+func (m MCP3008) VoltageDifferential(pair DifferentialPair) (float64, error) {
+	if err := checkPair(pair, 8); err != nil {
+		return 0, err
 	}
 
-	// The 10-bits measurement are at the end of the 3 byte response.
-	//
-	// 11111111 11111010 10110111
-	//                ^^ ^^^^^^^^
-	// To get the base10 value of the channel the second byte is masked
-	// with 3:
-	//
-	//	    11111010
-	//          00000011
-	//          -------- &
-	//          00000010
-	//
-	// The byte is shifted 8 bits and the last byte is added:
-	// 00000010 00000000
-	//          10110111
-	//          -------- +
-	// 00000010 10110111
-	//
-	// 00000010 10110111 is 696 in base10.
-	return int(in[1]&3)<<8 + int(in[2]), nil
+	m.InputType = adc.PseudoDifferential
+	return m.Voltage(int(pair))
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3008) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(10)
+}
+
+// Raw sends out to the MCP3008 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3008) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
+
+// ReadScan performs the conversions configured by Scan, each with its own
+// input type, and returns their voltages by name.
+func (m MCP3008) ReadScan() (map[string]float64, error) {
+	return readScan(m.Scan, func(channel int, inputType adc.InputType) (float64, error) {
+		m.InputType = inputType
+		return m.Voltage(channel)
+	})
+}
+
+// read reads a channel of an ADC with the given number of channels using the
+// frame of the ADC.
+func read(conn bus.SPI, channel, channels int, inputType adc.InputType, f frame) (int, error) {
+	if err := checkChannel(channel, channels); err != nil {
+		return 0, err
+	}
+
+	buf := buffers.Get().(*[6]byte)
+	defer buffers.Put(buf)
+
+	return f.tx(conn, channel, inputType, buf[:])
 }
 
 // MCP3204 is 12-bits ADC with 4 single-ended or 2 pseudo-differential inputs.
 // Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21298e.pdf
 type MCP3204 struct {
-	Conn *spi.Device
+	Conn bus.SPI
 
 	// Vref is the voltage on the reference input of the ADC.
 	Vref float64
 
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
 	InputType adc.InputType
+
+	// Samples and Reduce configure oversampling, Retries and Backoff the
+	// retries of failed transfers. See the package documentation.
+	Samples int
+	Reduce  adc.Reducer
+	Retries int
+	Backoff time.Duration
+
+	// Scan configures the conversions of ReadScan, see NewScanConfig.
+	Scan ScanConfig
 }
 
-// OutputCode queries the channel and returns its digital output code.
-func (m MCP3204) OutputCode(channel int) (int, error) {
-	if channel < 0 || channel > 3 {
-		return 0, fmt.Errorf("channel %d is invalid, ADC has only 4 channels", channel)
+// NewMCP3204 returns an MCP3204 that uses conn and vref. The maximum clock
+// of conn is set to 1 MHz, at 5V the ADC can be clocked at 2 MHz.
+func NewMCP3204(conn *spi.Device, vref float64) (*MCP3204, error) {
+	if err := configure(conn, 1000000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3204: %w", err)
 	}
+	return &MCP3204{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
 
-	code, err := read12(m.Conn, channel, m.InputType)
-	if err != nil {
-		return 0, err
-	}
+// Channels returns the number of channels, 4.
+func (m MCP3204) Channels() int {
+	return 4
+}
 
-	return code, nil
+// OutputCode queries the channel and returns its digital output code. When
+// InputType is adc.PseudoDifferential, channel is the value of one of the
+// differential pairs CH0PosCH1Neg - CH3PosCH2Neg. Use VoltageDifferential to
+// select the pair explicitly.
+func (m MCP3204) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return adc.Oversample(m.Samples, m.Reduce, func() (int, error) {
+		return retry(m.Retries, m.Backoff, func() (int, error) {
+			return read(bus.LogSPI(m.Conn, m.Logger), channel, 4, m.InputType, frame320x)
+		})
+	})
 }
 
 // Voltage returns the voltage of a channel.
@@ -169,32 +420,142 @@ func (m MCP3204) Voltage(channel int) (float64, error) {
 		return 0, err
 	}
 
-	return (m.Vref / 4096) * float64(code), nil
+	return m.Resolution() * float64(code), nil
+}
+
+// OutputCodeAll queries all 4 channels and returns their digital output codes.
+// Element i of the result is the output code of channel i. The same buffers are
+// used to query every channel.
+func (m MCP3204) OutputCodeAll() ([]int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return readAll(bus.LogSPI(m.Conn, m.Logger), 4, m.InputType, m.Samples, m.Reduce, m.Retries, m.Backoff, frame320x)
+}
+
+// VoltageAll returns the voltages of all 4 channels. Element i of the result
+// is the voltage of channel i.
+func (m MCP3204) VoltageAll() ([]float64, error) {
+	codes, err := m.OutputCodeAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return voltages(codes, m.Resolution()), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3204) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3204) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// VoltageDifferential returns the voltage of CH+ relative to CH- of a
+// differential pair, regardless of InputType. An error is returned when the
+// MCP3204 doesn't have the pair.
+func (m MCP3204) VoltageDifferential(pair DifferentialPair) (float64, error) {
+	if err := checkPair(pair, 4); err != nil {
+		return 0, err
+	}
+
+	m.InputType = adc.PseudoDifferential
+	return m.Voltage(int(pair))
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3204) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(12)
+}
+
+// Raw sends out to the MCP3204 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3204) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
+
+// ReadScan performs the conversions configured by Scan, each with its own
+// input type, and returns their voltages by name.
+func (m MCP3204) ReadScan() (map[string]float64, error) {
+	return readScan(m.Scan, func(channel int, inputType adc.InputType) (float64, error) {
+		m.InputType = inputType
+		return m.Voltage(channel)
+	})
 }
 
 // MCP3208 is 12-bits ADC with 8 single-ended or 4 pseudo-differential inputs.
 // Datasheet: http://ww1.microchip.com/downloads/en/DeviceDoc/21298e.pdf
 type MCP3208 struct {
-	Conn *spi.Device
+	Conn bus.SPI
 
 	// Vref is the voltage on the reference input of the ADC.
 	Vref float64
 
+	// FullScale selects how an output code is converted to a voltage.
+	FullScale adc.FullScaleMode
+
+	// Locker is held during every transaction with the ADC, see the
+	// package documentation.
+	Locker sync.Locker
+
+	// Logger, when set, logs every transfer with the bytes in hex.
+	Logger bus.Logger
+
 	InputType adc.InputType
+
+	// Samples and Reduce configure oversampling, Retries and Backoff the
+	// retries of failed transfers. See the package documentation.
+	Samples int
+	Reduce  adc.Reducer
+	Retries int
+	Backoff time.Duration
+
+	// Scan configures the conversions of ReadScan, see NewScanConfig.
+	Scan ScanConfig
 }
 
-// OutputCode queries the channel and returns its digital output code.
-func (m MCP3208) OutputCode(channel int) (int, error) {
-	if channel < 0 || channel > 7 {
-		return 0, fmt.Errorf("channel %d is invalid, ADC has only 7 channels", channel)
+// NewMCP3208 returns an MCP3208 that uses conn and vref. The maximum clock
+// of conn is set to 1 MHz, at 5V the ADC can be clocked at 2 MHz.
+func NewMCP3208(conn *spi.Device, vref float64) (*MCP3208, error) {
+	if err := configure(conn, 1000000); err != nil {
+		return nil, fmt.Errorf("failed to create MCP3208: %w", err)
 	}
+	return &MCP3208{Conn: conn, Vref: vref, Locker: new(sync.Mutex)}, nil
+}
 
-	code, err := read12(m.Conn, channel, m.InputType)
-	if err != nil {
-		return 0, err
-	}
+// Channels returns the number of channels, 8.
+func (m MCP3208) Channels() int {
+	return 8
+}
 
-	return code, nil
+// OutputCode queries the channel and returns its digital output code. When
+// InputType is adc.PseudoDifferential, channel is the value of one of the
+// differential pairs CH0PosCH1Neg - CH7PosCH6Neg. Use VoltageDifferential to
+// select the pair explicitly.
+func (m MCP3208) OutputCode(channel int) (int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return adc.Oversample(m.Samples, m.Reduce, func() (int, error) {
+		return retry(m.Retries, m.Backoff, func() (int, error) {
+			return read(bus.LogSPI(m.Conn, m.Logger), channel, 8, m.InputType, frame320x)
+		})
+	})
 }
 
 // Voltage returns the voltage of a channel.
@@ -204,63 +565,175 @@ func (m MCP3208) Voltage(channel int) (float64, error) {
 		return 0, err
 	}
 
-	return (m.Vref / 4096) * float64(code), nil
+	return m.Resolution() * float64(code), nil
 }
 
-// read12 reads a 12 bits value from an channel of an ADC.
-func read12(conn *spi.Device, channel int, inputType adc.InputType) (int, error) {
-	// The start bit.
-	cmd := 1
-	cmd = cmd << 1
+// OutputCodeAll queries all 8 channels and returns their digital output codes.
+// Element i of the result is the output code of channel i. The same buffers are
+// used to query every channel.
+func (m MCP3208) OutputCodeAll() ([]int, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return readAll(bus.LogSPI(m.Conn, m.Logger), 8, m.InputType, m.Samples, m.Reduce, m.Retries, m.Backoff, frame320x)
+}
 
-	// The first bit after the start bit will determine if the conversion
-	// is done using single-ended or differential input mode. 0 means
-	// differential, 1 means single-ended.
-	if inputType == adc.SingleEnded {
-		cmd = cmd | 1
+// VoltageAll returns the voltages of all 8 channels. Element i of the result
+// is the voltage of channel i.
+func (m MCP3208) VoltageAll() ([]float64, error) {
+	codes, err := m.OutputCodeAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return voltages(codes, m.Resolution()), nil
+}
+
+// OutputCodeContext is like OutputCode, but returns ctx.Err() when ctx is done
+// before the output code has been read.
+func (m MCP3208) OutputCodeContext(ctx context.Context, channel int) (int, error) {
+	return adc.OutputCodeContext(ctx, m, channel)
+}
+
+// VoltageContext is like Voltage, but returns ctx.Err() when ctx is done before
+// the voltage has been read.
+func (m MCP3208) VoltageContext(ctx context.Context, channel int) (float64, error) {
+	return adc.VoltageContext(ctx, m, channel)
+}
+
+// VoltageDifferential returns the voltage of CH+ relative to CH- of a
+// differential pair, regardless of InputType. An error is returned when the
+// MCP3208 doesn't have the pair.
+func (m MCP3208) VoltageDifferential(pair DifferentialPair) (float64, error) {
+	if err := checkPair(pair, 8); err != nil {
+		return 0, err
+	}
+
+	m.InputType = adc.PseudoDifferential
+	return m.Voltage(int(pair))
+}
+
+// Resolution returns the voltage of a single step of the output code, that is
+// the smallest difference in voltage the ADC can measure.
+func (m MCP3208) Resolution() float64 {
+	return m.Vref / m.FullScale.Denominator(12)
+}
+
+// Raw sends out to the MCP3208 and returns the response, which has the same
+// length as out. It is an escape hatch for features the driver doesn't model.
+//
+// Use with care: out is send as is, it isn't validated in any way. A malformed
+// frame might leave the ADC in an unexpected state.
+func (m MCP3208) Raw(out []byte) ([]byte, error) {
+	l := locker(m.Locker)
+	l.Lock()
+	defer l.Unlock()
+
+	return raw(bus.LogSPI(m.Conn, m.Logger), out)
+}
+
+// ReadScan performs the conversions configured by Scan, each with its own
+// input type, and returns their voltages by name.
+func (m MCP3208) ReadScan() (map[string]float64, error) {
+	return readScan(m.Scan, func(channel int, inputType adc.InputType) (float64, error) {
+		m.InputType = inputType
+		return m.Voltage(channel)
+	})
+}
+
+// readAll queries all channels of an ADC using its frame. A single buffer is
+// used for all channels. Every channel is sampled the given
+// number of times, see adc.Oversample. Failed transfers are retried, see
+// retry.
+func readAll(conn bus.SPI, channels int, inputType adc.InputType, samples int, reduce adc.Reducer, retries int, backoff time.Duration, f frame) ([]int, error) {
+	buf := buffers.Get().(*[6]byte)
+	defer buffers.Put(buf)
+
+	codes := make([]int, channels)
+
+	for ch := range codes {
+		code, err := adc.Oversample(samples, reduce, func() (int, error) {
+			return retry(retries, backoff, func() (int, error) {
+				return f.tx(conn, ch, inputType, buf[:])
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		codes[ch] = code
 	}
-	// The bit is then shifted 3 times and the number is incremented with
-	// a 3 bits channel.
-	cmd = cmd << 3
-	cmd += channel
-
-	// The result is shifted 6 times.
-	//
-	// x x x x x 1 1 1   1 1 x x x x x x
-	//           | | |   | | ------- 3 bits for selecting channel
-	//	     | |---------------- 1 bit defining single-ended or pseudo-differential input mode
-	//	     |------------------ 1 start bit
-	cmd = cmd << 6
-
-	// The data is is in the first 2 bytes, the third byte is an empty byte.
-	out := []byte{byte(cmd >> 8), byte(cmd & 0xFF), 0}
-
-	// For every byte send the SPI master reads a byte. Because we send 3
-	// bytes we read 3 bytes.
-	in := make([]byte, 3)
 
+	return codes, nil
+}
+
+// retry calls read and retries it the given number of times when the transfer
+// fails, see bus.Retry.
+func retry(retries int, backoff time.Duration, read func() (int, error)) (int, error) {
+	var code int
+	err := bus.Retry(func() (err error) {
+		code, err = read()
+		return err
+	}, retries+1, backoff)
+	return code, err
+}
+
+// voltages converts output codes to voltages.
+func voltages(codes []int, resolution float64) []float64 {
+	v := make([]float64, len(codes))
+	for i, code := range codes {
+		v[i] = resolution * float64(code)
+	}
+	return v
+}
+
+// raw sends out and returns the response of the same length.
+func raw(conn bus.SPI, out []byte) ([]byte, error) {
+	in := make([]byte, len(out))
 	if err := conn.Tx(out, in); err != nil {
-		return 0, fmt.Errorf("failed to read channel %d: %v", channel, err)
+		return nil, fmt.Errorf("failed to send raw frame: %w", bus.Error{Err: err})
+	}
+	return in, nil
+}
+
+// buffers holds the buffers used by frame.tx. Those buffers escape to the
+// heap, reusing them keeps reading a channel free of allocations.
+var buffers = sync.Pool{
+	New: func() interface{} { return new([6]byte) },
+}
+
+// nopLocker is the Locker of drivers without a Locker, it doesn't lock.
+type nopLocker struct{}
+
+func (nopLocker) Lock()   {}
+func (nopLocker) Unlock() {}
+
+// locker returns l, or a nopLocker if l is nil.
+func locker(l sync.Locker) sync.Locker {
+	if l == nil {
+		return nopLocker{}
+	}
+	return l
+}
+
+// configure sets the SPI mode of conn to mode 0 and its clock to at most
+// maxSpeed Hz.
+func configure(conn *spi.Device, maxSpeed int) error {
+	if err := conn.SetMode(spi.Mode0); err != nil {
+		return fmt.Errorf("failed to set SPI mode: %w", err)
+	}
+	if err := conn.SetMaxSpeed(maxSpeed); err != nil {
+		return fmt.Errorf("failed to set SPI clock: %w", err)
 	}
+	return nil
+}
 
-	// The 12-bits measurement is at the end of the 3 byte response.
-	//
-	// 11111111 11101100 10110111
-	//              ^^^^ ^^^^^^^^
-	// To get the base10 value of the channel the second byte is masked
-	// with 15:
-	//
-	//	    11101100
-	//          00001111
-	//          -------- &
-	//          00001100
-	//
-	// The byte is shifted 8 bits and the last byte is added:
-	// 00001100 00000000
-	//          10110111
-	//          -------- +
-	// 00001100 10110111
-	//
-	// 00001100 10110111 is 3255 in base10.
-	return int(in[1]&0xF)<<8 + int(in[2]), nil
+// checkChannel returns an error if channel isn't one of the channels of an ADC
+// with the given number of channels. The channel is part of the command send
+// to the ADC, a channel out of range would corrupt that command.
+func checkChannel(channel, channels int) error {
+	if channel < 0 || channel >= channels {
+		return adc.ErrInvalidChannel{Channel: channel, Channels: channels}
+	}
+	return nil
 }
@@ -1,8 +1,17 @@
 package microchip
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/advancedclimatesystems/io/adc"
 	"github.com/stretchr/testify/assert"
@@ -10,28 +19,15 @@ import (
 	"golang.org/x/exp/io/spi/driver"
 )
 
-// testDriver is a mocked driver that implements the driver.Opener interface.
-type testDriver struct {
-	conn testConn
-}
-
-func (d testDriver) Open() (driver.Conn, error) {
-	return d.conn, nil
-}
-
-// testConn is a mocked connection that implements the spi.Conn interface.
+// testConn is a mocked connection that implements the bus.SPI interface.
 type testConn struct {
 	tx func(w, r []byte) error
 }
 
-func (c testConn) Configure(k, v int) error { return nil }
-
 func (c testConn) Tx(w, r []byte) error {
 	return c.tx(w, r)
 }
 
-func (c testConn) Close() error { return nil }
-
 func TestMCP300x(t *testing.T) {
 	var tests = []struct {
 		resp []byte
@@ -55,9 +51,8 @@ func TestMCP300x(t *testing.T) {
 			},
 		}
 
-		con, _ := spi.Open(&testDriver{c})
 		mcp3004 := MCP3004{
-			Conn:      con,
+			Conn:      c,
 			Vref:      5.0,
 			InputType: adc.SingleEnded,
 		}
@@ -66,7 +61,7 @@ func TestMCP300x(t *testing.T) {
 		assert.Equal(t, test.v, v)
 
 		mcp3008 := MCP3008{
-			Conn:      con,
+			Conn:      c,
 			Vref:      5.0,
 			InputType: adc.SingleEnded,
 		}
@@ -100,9 +95,8 @@ func TestMCP320x(t *testing.T) {
 			},
 		}
 
-		con, _ := spi.Open(&testDriver{c})
 		mcp3204 := MCP3204{
-			Conn:      con,
+			Conn:      c,
 			Vref:      5.0,
 			InputType: adc.PseudoDifferential,
 		}
@@ -111,7 +105,7 @@ func TestMCP320x(t *testing.T) {
 		assert.Equal(t, test.v, v)
 
 		mcp3208 := MCP3208{
-			Conn:      con,
+			Conn:      c,
 			Vref:      5.0,
 			InputType: adc.PseudoDifferential,
 		}
@@ -122,26 +116,97 @@ func TestMCP320x(t *testing.T) {
 	}
 }
 
-// TestWithInvalidChannels calls adc.OutputCode with a channel that isn't in
-// the range of the ADC.
+func TestMCP3x0xResolution(t *testing.T) {
+	assert.Equal(t, 5.0/1024, MCP3004{Vref: 5.0}.Resolution())
+	assert.Equal(t, 5.0/1024, MCP3008{Vref: 5.0}.Resolution())
+	assert.Equal(t, 5.0/4096, MCP3204{Vref: 5.0}.Resolution())
+	assert.Equal(t, 5.0/4096, MCP3208{Vref: 5.0}.Resolution())
+}
+
+// TestMCP3x0xVoltageContext tests if a read on a hanging connection is
+// abandoned when the context expires.
+func TestMCP3x0xVoltageContext(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	c := testConn{
+		tx: func(w, r []byte) error {
+			<-block
+			return nil
+		},
+	}
+	adcs := []interface {
+		OutputCodeContext(context.Context, int) (int, error)
+		VoltageContext(context.Context, int) (float64, error)
+	}{
+		MCP3004{Conn: c},
+		MCP3008{Conn: c},
+		MCP3204{Conn: c},
+		MCP3208{Conn: c},
+	}
+
+	for _, a := range adcs {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		_, err := a.OutputCodeContext(ctx, 1)
+		assert.Equal(t, context.DeadlineExceeded, err)
+
+		_, err = a.VoltageContext(ctx, 1)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		cancel()
+	}
+}
+
+// TestWithInvalidChannels calls adc.OutputCode with channels on and just
+// outside the boundaries of the range of the ADC.
 func TestMCP3x0xWithInvalidChannels(t *testing.T) {
+	c := testConn{
+		tx: func(w, r []byte) error {
+			return nil
+		},
+	}
+
 	var tests = []struct {
 		adc     adc.ADC
 		channel int
+		err     error
 	}{
-		{MCP3004{}, -1},
-		{MCP3004{}, 4},
-		{MCP3008{}, -1},
-		{MCP3008{}, 8},
-		{MCP3204{}, -1},
-		{MCP3204{}, 4},
-		{MCP3208{}, -1},
-		{MCP3208{}, 8},
+		{MCP3004{Conn: c}, -1, adc.ErrInvalidChannel{Channel: -1, Channels: 4}},
+		{MCP3004{Conn: c}, 3, nil},
+		{MCP3004{Conn: c}, 4, adc.ErrInvalidChannel{Channel: 4, Channels: 4}},
+		{MCP3008{Conn: c}, -1, adc.ErrInvalidChannel{Channel: -1, Channels: 8}},
+		{MCP3008{Conn: c}, 7, nil},
+		{MCP3008{Conn: c}, 8, adc.ErrInvalidChannel{Channel: 8, Channels: 8}},
+		{MCP3204{Conn: c}, -1, adc.ErrInvalidChannel{Channel: -1, Channels: 4}},
+		{MCP3204{Conn: c}, 3, nil},
+		{MCP3204{Conn: c}, 4, adc.ErrInvalidChannel{Channel: 4, Channels: 4}},
+		{MCP3208{Conn: c}, -1, adc.ErrInvalidChannel{Channel: -1, Channels: 8}},
+		{MCP3208{Conn: c}, 7, nil},
+		{MCP3208{Conn: c}, 8, adc.ErrInvalidChannel{Channel: 8, Channels: 8}},
 	}
 
 	for _, test := range tests {
 		_, err := test.adc.OutputCode(test.channel)
-		assert.NotNil(t, err)
+		assert.Equal(t, test.err, err)
+	}
+}
+
+// TestMCP3x0xInvalidChannelMessages tests if the error for an invalid channel
+// reports the right number of channels for every chip.
+func TestMCP3x0xInvalidChannelMessages(t *testing.T) {
+	var tests = []struct {
+		adc     adc.ADC
+		channel int
+		msg     string
+	}{
+		{MCP3004{}, 4, "channel 4 is invalid, ADC has only 4 channels"},
+		{MCP3204{}, 4, "channel 4 is invalid, ADC has only 4 channels"},
+		{MCP3008{}, 8, "channel 8 is invalid, ADC has only 8 channels"},
+		{MCP3208{}, 8, "channel 8 is invalid, ADC has only 8 channels"},
+	}
+
+	for _, test := range tests {
+		_, err := test.adc.OutputCode(test.channel)
+		assert.EqualError(t, err, test.msg)
 	}
 }
 
@@ -153,20 +218,18 @@ func TestMCP3x0xWithFailingConnection(t *testing.T) {
 			return fmt.Errorf("some error occured")
 		},
 	}
-	con, _ := spi.Open(&testDriver{c})
-
 	adcs := []adc.ADC{
 		MCP3004{
-			Conn: con,
+			Conn: c,
 		},
 		MCP3008{
-			Conn: con,
+			Conn: c,
 		},
 		MCP3204{
-			Conn: con,
+			Conn: c,
 		},
 		MCP3208{
-			Conn: con,
+			Conn: c,
 		},
 	}
 
@@ -176,6 +239,45 @@ func TestMCP3x0xWithFailingConnection(t *testing.T) {
 	}
 }
 
+// TestMCP3x0xWithFakeConnection tests if the ADC's accept any bus.SPI as
+// connection, not only a *spi.Device.
+func TestMCP3x0xWithFakeConnection(t *testing.T) {
+	c := testConn{
+		tx: func(w, r []byte) error {
+			copy(r, []byte{0, 3, 255})
+			return nil
+		},
+	}
+
+	a := MCP3208{
+		Conn: c,
+		Vref: 5,
+	}
+
+	code, err := a.OutputCode(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1023, code)
+}
+
+func TestCheckChannel(t *testing.T) {
+	tests := []struct {
+		channel  int
+		channels int
+		err      error
+	}{
+		{0, 4, nil},
+		{3, 4, nil},
+		{4, 4, adc.ErrInvalidChannel{Channel: 4, Channels: 4}},
+		{7, 8, nil},
+		{8, 8, adc.ErrInvalidChannel{Channel: 8, Channels: 8}},
+		{-1, 8, adc.ErrInvalidChannel{Channel: -1, Channels: 8}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.err, checkChannel(test.channel, test.channels))
+	}
+}
+
 func TestRead12(t *testing.T) {
 	tests := []struct {
 		channel     int
@@ -196,12 +298,332 @@ func TestRead12(t *testing.T) {
 			},
 		}
 
-		con, _ := spi.Open(&testDriver{c})
+		_, _ = read(c, test.channel, 8, test.inputType, frame320x)
+	}
+}
+
+func TestMCP3x0xAll(t *testing.T) {
+	var cmds [][]byte
+	c := testConn{
+		tx: func(w, r []byte) error {
+			cmds = append(cmds, append([]byte(nil), w...))
+
+			// Respond with the channel as output code.
+			r[1] = 0
+			r[2] = byte(len(cmds) - 1)
+			return nil
+		},
+	}
+
+	codes, err := MCP3008{Conn: c, InputType: adc.SingleEnded}.OutputCodeAll()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7}, codes)
+	assert.Equal(t, []byte{1, 0x80, 0}, cmds[0])
+	assert.Equal(t, []byte{1, 0xf0, 0}, cmds[7])
+
+	cmds = nil
+	v, err := MCP3004{Conn: c, Vref: 1.024}.VoltageAll()
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{0, 0.001, 0.002, 0.003}, v)
+	assert.Equal(t, []byte{1, 0xb0, 0}, cmds[3])
+
+	cmds = nil
+	codes, err = MCP3204{Conn: c}.OutputCodeAll()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, codes)
+	assert.Equal(t, []byte{6, 0xc0, 0}, cmds[3])
+
+	cmds = nil
+	v, err = MCP3208{Conn: c, Vref: 4.096}.VoltageAll()
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{0, 0.001, 0.002, 0.003, 0.004, 0.005, 0.006, 0.007}, v)
+	assert.Equal(t, []byte{7, 0xc0, 0}, cmds[7])
+}
+
+func TestMCP3x0xAllWithFailingChannel(t *testing.T) {
+	calls := 0
+	c := testConn{
+		tx: func(w, r []byte) error {
+			calls++
+			if calls == 3 {
+				return fmt.Errorf("some error occured")
+			}
+			return nil
+		},
+	}
+
+	_, err := MCP3008{Conn: c}.VoltageAll()
+	assert.EqualError(t, err, "failed to read channel 2: some error occured")
+	assert.Equal(t, 3, calls)
+}
+
+func TestMCP3x0xFullScale(t *testing.T) {
+	var tests = []struct {
+		adc  adc.ADC
+		resp []byte
+		v    float64
+	}{
+		{MCP3008{Vref: 5}, []byte{0x00, 0x00}, 0},
+		{MCP3008{Vref: 5}, []byte{0x03, 0xff}, 5 * 1023.0 / 1024},
+		{MCP3008{Vref: 5, FullScale: adc.CodeBased}, []byte{0x00, 0x00}, 0},
+		{MCP3008{Vref: 5, FullScale: adc.CodeBased}, []byte{0x03, 0xff}, 5},
+		{MCP3208{Vref: 5}, []byte{0x00, 0x00}, 0},
+		{MCP3208{Vref: 5}, []byte{0x0f, 0xff}, 5 * 4095.0 / 4096},
+		{MCP3208{Vref: 5, FullScale: adc.CodeBased}, []byte{0x00, 0x00}, 0},
+		{MCP3208{Vref: 5, FullScale: adc.CodeBased}, []byte{0x0f, 0xff}, 5},
+	}
+
+	for _, test := range tests {
+		c := testConn{
+			tx: func(w, r []byte) error {
+				r[1], r[2] = test.resp[0], test.resp[1]
+				return nil
+			},
+		}
+
+		var a adc.ADC
+		switch m := test.adc.(type) {
+		case MCP3008:
+			m.Conn = c
+			a = m
+		case MCP3208:
+			m.Conn = c
+			a = m
+		}
 
-		_, _ = read12(con, test.channel, test.inputType)
+		v, err := a.Voltage(0)
+		assert.Nil(t, err)
+		assert.InDelta(t, test.v, v, 1e-12)
 	}
 }
 
+func TestMCP3x0xRetries(t *testing.T) {
+	var tests = []struct {
+		adc      adc.ADC
+		failures int
+		calls    int
+		err      string
+	}{
+		{MCP3008{}, 1, 1, "failed to read channel 1: some error occured"},
+		{MCP3008{Retries: 2}, 2, 3, ""},
+		{MCP3204{Retries: 2, Backoff: time.Microsecond}, 2, 3, ""},
+		{MCP3208{Retries: 2}, 3, 3, "failed after 3 attempts: failed to read channel 1: some error occured"},
+	}
+
+	for _, test := range tests {
+		calls := 0
+		c := testConn{
+			tx: func(w, r []byte) error {
+				calls++
+				if calls <= test.failures {
+					return fmt.Errorf("some error occured")
+				}
+				return nil
+			},
+		}
+
+		var a adc.ADC
+		switch m := test.adc.(type) {
+		case MCP3008:
+			m.Conn = c
+			a = m
+		case MCP3204:
+			m.Conn = c
+			a = m
+		case MCP3208:
+			m.Conn = c
+			a = m
+		}
+
+		_, err := a.OutputCode(1)
+		assert.Equal(t, test.calls, calls)
+		if test.err == "" {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, test.err)
+	}
+
+	// Invalid channels aren't retried.
+	_, err := MCP3008{Retries: 2}.OutputCode(8)
+	assert.EqualError(t, err, "channel 8 is invalid, ADC has only 8 channels")
+
+	// OutputCodeAll retries failed transfers too.
+	calls := 0
+	c := testConn{
+		tx: func(w, r []byte) error {
+			calls++
+			if calls == 3 {
+				return fmt.Errorf("some error occured")
+			}
+			return nil
+		},
+	}
+	_, err = MCP3004{Conn: c, Retries: 1}.OutputCodeAll()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, calls)
+}
+
+func TestMCP3x0xAllocations(t *testing.T) {
+	c := testConn{
+		tx: func(w, r []byte) error { return nil },
+	}
+
+	for _, m := range []adc.MultiChannelADC{MCP3008{Conn: c, Vref: 5}, MCP3208{Conn: c, Vref: 5}} {
+		// Reading a channel reuses the buffers of earlier reads.
+		read := testing.AllocsPerRun(100, func() {
+			_, _ = m.Voltage(1)
+		})
+		assert.Equal(t, float64(0), read)
+
+		// Only the output codes and voltages are allocated.
+		all := testing.AllocsPerRun(100, func() {
+			_, _ = m.VoltageAll()
+		})
+		assert.Equal(t, float64(2), all)
+	}
+}
+
+func BenchmarkMCP3008Voltage(b *testing.B) {
+	c := testConn{
+		tx: func(w, r []byte) error { return nil },
+	}
+	m := MCP3008{Conn: c, Vref: 5}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for ch := 0; ch < 8; ch++ {
+			if _, err := m.Voltage(ch); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkMCP3008VoltageAll(b *testing.B) {
+	c := testConn{
+		tx: func(w, r []byte) error { return nil },
+	}
+	m := MCP3008{Conn: c, Vref: 5}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := m.VoltageAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMCP3x0xSamples(t *testing.T) {
+	var tests = []struct {
+		adc   adc.ADC
+		code  int
+		calls int
+	}{
+		{MCP3008{}, 10, 1},
+		{MCP3008{Samples: 1}, 10, 1},
+		{MCP3008{Samples: 2}, 15, 2},
+		{MCP3004{Samples: 4}, 263, 4},
+		{MCP3204{Samples: 3, Reduce: adc.Median}, 20, 3},
+		{MCP3208{Samples: 4, Reduce: adc.Median}, 20, 4},
+	}
+
+	for _, test := range tests {
+		codes := []int{10, 20, 1000, 20}
+		calls := 0
+		c := testConn{
+			tx: func(w, r []byte) error {
+				r[1] = byte(codes[calls] >> 8)
+				r[2] = byte(codes[calls])
+				calls++
+				return nil
+			},
+		}
+
+		var code int
+		var err error
+		switch a := test.adc.(type) {
+		case MCP3004:
+			a.Conn = c
+			code, err = a.OutputCode(1)
+		case MCP3008:
+			a.Conn = c
+			code, err = a.OutputCode(1)
+		case MCP3204:
+			a.Conn = c
+			code, err = a.OutputCode(1)
+		case MCP3208:
+			a.Conn = c
+			code, err = a.OutputCode(1)
+		}
+
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code)
+		assert.Equal(t, test.calls, calls)
+	}
+
+	// VoltageAll samples every channel.
+	calls := 0
+	c := testConn{
+		tx: func(w, r []byte) error {
+			r[1], r[2] = 0, byte(calls%2)*2
+			calls++
+			return nil
+		},
+	}
+
+	v, err := MCP3004{Conn: c, Vref: 1.024, Samples: 2}.VoltageAll()
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{0.001, 0.001, 0.001, 0.001}, v)
+	assert.Equal(t, 8, calls)
+}
+
+// TestMCP3x0xConcurrent reads channels from 8 goroutines at once. The
+// connection yields halfway every transfer, so transfers that aren't
+// serialized overlap and mangle each others frames.
+func TestMCP3x0xConcurrent(t *testing.T) {
+	var inflight, overlaps int32
+	c := testConn{
+		tx: func(w, r []byte) error {
+			if atomic.AddInt32(&inflight, 1) > 1 {
+				atomic.AddInt32(&overlaps, 1)
+			}
+			defer atomic.AddInt32(&inflight, -1)
+
+			// Respond with the channel of the command as output code.
+			r[1] = 0
+			runtime.Gosched()
+			r[2] = (w[1] >> 4) & 0x7
+			return nil
+		},
+	}
+
+	// Two devices on the same bus share a Locker.
+	var l sync.Mutex
+	adcs := []adc.ADC{
+		MCP3008{Conn: c, Vref: 1024, Locker: &l},
+		MCP3004{Conn: c, Vref: 1024, Locker: &l},
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+
+			a, ch := adcs[g%2], g%4
+			for i := 0; i < 100; i++ {
+				v, err := a.Voltage(ch)
+				assert.Nil(t, err)
+				assert.Equal(t, float64(ch), v)
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(0), overlaps)
+}
+
 func ExampleMCP3008() {
 	conn, err := spi.Open(&spi.Devfs{
 		Dev:      "/dev/spidev32766.0",
@@ -231,3 +653,154 @@ func ExampleMCP3008() {
 
 	fmt.Printf("read %f Volts from channel 3", v)
 }
+
+func TestRaw(t *testing.T) {
+	c := testConn{
+		tx: func(w, r []byte) error {
+			assert.Equal(t, []byte{0x01, 0x80, 0x00, 0xff}, w)
+			assert.Len(t, r, 4)
+
+			copy(r, []byte{0xff, 0xfa, 0x12, 0x34})
+			return nil
+		},
+	}
+
+	type rawADC interface {
+		Raw([]byte) ([]byte, error)
+	}
+
+	adcs := []rawADC{
+		MCP3001{Conn: c}, MCP3002{Conn: c}, MCP3004{Conn: c}, MCP3008{Conn: c},
+		MCP3201{Conn: c}, MCP3202{Conn: c}, MCP3204{Conn: c}, MCP3208{Conn: c},
+		MCP3302{Conn: c}, MCP3304{Conn: c},
+	}
+
+	for _, a := range adcs {
+		in, err := a.Raw([]byte{0x01, 0x80, 0x00, 0xff})
+		assert.Nil(t, err)
+		assert.Equal(t, []byte{0xff, 0xfa, 0x12, 0x34}, in)
+	}
+
+	c.tx = func(w, r []byte) error {
+		return fmt.Errorf("some error occured")
+	}
+
+	_, err := MCP3008{Conn: c}.Raw([]byte{0x01})
+	assert.EqualError(t, err, "failed to send raw frame: some error occured")
+}
+
+// configConn is a SPI driver that records the configuration of the device.
+type configConn struct {
+	config map[int]int
+	err    error
+}
+
+func (c *configConn) Open() (driver.Conn, error) {
+	return c, nil
+}
+
+func (c *configConn) Configure(k, v int) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.config[k] = v
+	return nil
+}
+
+func (c *configConn) Tx(w, r []byte) error {
+	return nil
+}
+
+func (c *configConn) Close() error {
+	return nil
+}
+
+func TestNewMCP3x0x(t *testing.T) {
+	tests := []struct {
+		new   func(conn *spi.Device) (adc.ADC, error)
+		speed int
+	}{
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3001(conn, 5) }, 750000},
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3201(conn, 5) }, 800000},
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3002(conn, 5) }, 1200000},
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3202(conn, 5) }, 900000},
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3004(conn, 5) }, 1350000},
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3008(conn, 5) }, 1350000},
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3204(conn, 5) }, 1000000},
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3208(conn, 5) }, 1000000},
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3302(conn, 5) }, 1050000},
+		{func(conn *spi.Device) (adc.ADC, error) { return NewMCP3304(conn, 5) }, 1050000},
+	}
+
+	var lockers []sync.Locker
+	for _, test := range tests {
+		c := &configConn{config: map[int]int{driver.Mode: 3}}
+		conn, _ := spi.Open(c)
+
+		a, err := test.new(conn)
+		assert.Nil(t, err)
+		assert.NotNil(t, a)
+		assert.Equal(t, map[int]int{driver.Mode: 0, driver.MaxSpeed: test.speed}, c.config)
+
+		// Every ADC gets a Locker of its own.
+		l := reflect.ValueOf(a).Elem().FieldByName("Locker").Interface().(sync.Locker)
+		assert.NotNil(t, l)
+		for _, other := range lockers {
+			assert.False(t, l == other)
+		}
+		lockers = append(lockers, l)
+	}
+
+	conn, _ := spi.Open(&configConn{err: errors.New("some error")})
+	m, err := NewMCP3008(conn, 5)
+	assert.Nil(t, m)
+	assert.EqualError(t, err, "failed to create MCP3008: failed to set SPI mode: some error")
+}
+
+func TestMCP3x0xLogger(t *testing.T) {
+	conn := testConn{tx: func(w, r []byte) error {
+		copy(r, []byte{0x00, 0x02, 0xff})
+		return nil
+	}}
+
+	var buf bytes.Buffer
+	m := MCP3008{Conn: conn, Vref: 3.3, Logger: log.New(&buf, "", 0)}
+
+	code, err := m.OutputCode(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 767, code)
+	assert.Equal(t, "spi: tx 01 90 00, rx 00 02 ff\n", buf.String())
+}
+
+func TestChannels(t *testing.T) {
+	conn := testConn{tx: func(w, r []byte) error { return nil }}
+
+	tests := []struct {
+		adc      adc.Sized
+		channels int
+	}{
+		{MCP3001{Conn: conn}, 1},
+		{MCP3201{Conn: conn}, 1},
+		{MCP3002{Conn: conn}, 2},
+		{MCP3202{Conn: conn}, 2},
+		{MCP3004{Conn: conn}, 4},
+		{MCP3204{Conn: conn}, 4},
+		{MCP3302{Conn: conn}, 4},
+		{MCP3008{Conn: conn}, 8},
+		{MCP3208{Conn: conn}, 8},
+		{MCP3304{Conn: conn}, 8},
+	}
+
+	for _, test := range tests {
+		a := test.adc
+		assert.Equal(t, test.channels, a.Channels())
+
+		// The channels are 0 up to Channels().
+		for ch := 0; ch < a.Channels(); ch++ {
+			_, err := a.OutputCode(ch)
+			assert.Nil(t, err)
+		}
+		_, err := a.OutputCode(a.Channels())
+		assert.Equal(t, adc.ErrInvalidChannel{Channel: test.channels, Channels: test.channels}, err)
+	}
+}
@@ -0,0 +1,94 @@
+package microchip
+
+import (
+	"fmt"
+
+	"github.com/advancedclimatesystems/io/adc"
+)
+
+// ScanChannel configures a single conversion of a scan.
+type ScanChannel struct {
+	// Name is the key of the voltage in the result of ReadScan.
+	Name string
+
+	// Channel is the channel that is converted. When InputType is
+	// adc.PseudoDifferential, it's the value of a DifferentialPair.
+	Channel int
+
+	InputType adc.InputType
+
+	// Scale multiplies the voltage, for example to undo a voltage divider.
+	// 0 is the same as 1.
+	Scale float64
+}
+
+// ScanConfig is a validated list of conversions, created by NewScanConfig.
+// The zero value is a scan without conversions.
+type ScanConfig struct {
+	channels []ScanChannel
+}
+
+// NewScanConfig returns a ScanConfig for an ADC with the given number of
+// channels. An error is returned when a channel or differential pair doesn't
+// exist, when a name is empty or used twice, or when a differential pair uses
+// a channel that is also converted single-ended.
+func NewScanConfig(channels int, scan ...ScanChannel) (ScanConfig, error) {
+	names := make(map[string]bool)
+	single := make(map[int]string)
+
+	for _, c := range scan {
+		if c.Name == "" {
+			return ScanConfig{}, fmt.Errorf("channel %d has no name", c.Channel)
+		}
+		if names[c.Name] {
+			return ScanConfig{}, fmt.Errorf("name %q is used more than once", c.Name)
+		}
+		names[c.Name] = true
+
+		if c.InputType == adc.SingleEnded {
+			if err := checkChannel(c.Channel, channels); err != nil {
+				return ScanConfig{}, err
+			}
+			single[c.Channel] = c.Name
+			continue
+		}
+
+		if err := checkPair(DifferentialPair(c.Channel), channels); err != nil {
+			return ScanConfig{}, err
+		}
+	}
+
+	for _, c := range scan {
+		if c.InputType == adc.SingleEnded {
+			continue
+		}
+
+		// A pair uses the channel with the same number and its
+		// neighbour.
+		for _, ch := range []int{c.Channel, c.Channel ^ 1} {
+			if name, ok := single[ch]; ok {
+				return ScanConfig{}, fmt.Errorf("differential pair %v of %q overlaps single-ended channel %d of %q", DifferentialPair(c.Channel), c.Name, ch, name)
+			}
+		}
+	}
+
+	return ScanConfig{channels: append([]ScanChannel(nil), scan...)}, nil
+}
+
+// readScan performs the conversions of scan using voltage and returns their
+// scaled voltages by name.
+func readScan(scan ScanConfig, voltage func(channel int, inputType adc.InputType) (float64, error)) (map[string]float64, error) {
+	voltages := make(map[string]float64, len(scan.channels))
+	for _, c := range scan.channels {
+		v, err := voltage(c.Channel, c.InputType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", c.Name, err)
+		}
+
+		if c.Scale != 0 {
+			v *= c.Scale
+		}
+		voltages[c.Name] = v
+	}
+	return voltages, nil
+}
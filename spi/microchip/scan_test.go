@@ -0,0 +1,115 @@
+package microchip
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/advancedclimatesystems/io/adc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadScan(t *testing.T) {
+	scan, err := NewScanConfig(8,
+		ScanChannel{Name: "a", Channel: 0},
+		ScanChannel{Name: "b", Channel: 1},
+		ScanChannel{Name: "c", Channel: 2},
+		ScanChannel{Name: "d", Channel: 3, Scale: 2},
+		ScanChannel{Name: "bridge 1", Channel: int(CH4PosCH5Neg), InputType: adc.PseudoDifferential},
+		ScanChannel{Name: "bridge 2", Channel: int(CH7PosCH6Neg), InputType: adc.PseudoDifferential},
+	)
+	assert.Nil(t, err)
+
+	var frames [][]byte
+	c := testConn{
+		tx: func(w, r []byte) error {
+			frames = append(frames, append([]byte(nil), w...))
+
+			// Respond with the channel as output code, plus 1000 for
+			// single-ended conversions.
+			code := int(w[0]&1)<<2 | int(w[1]>>6)
+			if w[0]&2 != 0 {
+				code += 1000
+			}
+			r[1], r[2] = byte(code>>8), byte(code)
+			return nil
+		},
+	}
+
+	m := MCP3208{Conn: c, Vref: 4096, Scan: scan}
+	v, err := m.ReadScan()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float64{
+		"a":        1000,
+		"b":        1001,
+		"c":        1002,
+		"d":        2006,
+		"bridge 1": 4,
+		"bridge 2": 7,
+	}, v)
+
+	// The SGL/DIFF bit is the second bit of the first byte.
+	assert.Equal(t, [][]byte{
+		{0x06, 0x00, 0},
+		{0x06, 0x40, 0},
+		{0x06, 0x80, 0},
+		{0x06, 0xc0, 0},
+		{0x05, 0x00, 0},
+		{0x05, 0xc0, 0},
+	}, frames)
+
+	// The input type of the driver itself is left untouched.
+	assert.Equal(t, adc.SingleEnded, m.InputType)
+
+	v, err = MCP3208{Conn: c}.ReadScan()
+	assert.Nil(t, err)
+	assert.Len(t, v, 0)
+}
+
+func TestReadScanWithFailingConnection(t *testing.T) {
+	c := testConn{
+		tx: func(w, r []byte) error {
+			return fmt.Errorf("some error occured")
+		},
+	}
+
+	scan, _ := NewScanConfig(4, ScanChannel{Name: "a", Channel: 1})
+	_, err := MCP3004{Conn: c, Scan: scan}.ReadScan()
+	assert.EqualError(t, err, `failed to read "a": failed to read channel 1: some error occured`)
+	assert.True(t, errors.Is(err, adc.ErrBusFailure))
+}
+
+func TestNewScanConfigWithInvalidChannels(t *testing.T) {
+	tests := []struct {
+		channels int
+		scan     []ScanChannel
+		err      string
+	}{
+		{4, []ScanChannel{{Name: "a", Channel: 4}}, "channel 4 is invalid, ADC has only 4 channels"},
+		{4, []ScanChannel{{Name: "a", Channel: 4, InputType: adc.PseudoDifferential}}, "differential pair 4 is invalid, ADC has only 4 channels"},
+		{4, []ScanChannel{{Channel: 1}}, "channel 1 has no name"},
+		{4, []ScanChannel{{Name: "a", Channel: 1}, {Name: "a", Channel: 2}}, `name "a" is used more than once`},
+		{
+			8,
+			[]ScanChannel{{Name: "a", Channel: 5}, {Name: "b", Channel: int(CH4PosCH5Neg), InputType: adc.PseudoDifferential}},
+			`differential pair CH4+/CH5- of "b" overlaps single-ended channel 5 of "a"`,
+		},
+		{
+			8,
+			[]ScanChannel{{Name: "a", Channel: int(CH3PosCH2Neg), InputType: adc.PseudoDifferential}, {Name: "b", Channel: 3}},
+			`differential pair CH3+/CH2- of "a" overlaps single-ended channel 3 of "b"`,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := NewScanConfig(test.channels, test.scan...)
+		assert.EqualError(t, err, test.err)
+	}
+
+	// Both polarities of a pair can be measured.
+	_, err := NewScanConfig(2,
+		ScanChannel{Name: "a", Channel: int(CH0PosCH1Neg), InputType: adc.PseudoDifferential},
+		ScanChannel{Name: "b", Channel: int(CH1PosCH0Neg), InputType: adc.PseudoDifferential},
+	)
+	assert.Nil(t, err)
+}